@@ -0,0 +1,80 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// terraformer-scaffold generates boilerplate of a new Terraformer provider from the
+// schema of its Terraform provider and a mapping of services to list API calls.
+//
+//	go run ./build/terraformer-scaffold --mapping=mapping.json --schema=schema.json
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	mappingPath := flag.String("mapping", "", "mapping.json - services, resource types and list API calls")
+	schemaPath := flag.String("schema", "", "schema.json - output of terraform providers schema -json, the installed plugin is asked if empty")
+	output := flag.String("output", ".", "root of the terraformer repository")
+	force := flag.Bool("force", false, "overwrite existing files")
+	flag.Parse()
+	if *mappingPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	mapping, err := ReadMapping(*mappingPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	schema, err := loadSchema(mapping.Provider, *schemaPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	files, err := Generate(mapping, schema)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for path, content := range files {
+		path = filepath.Join(*output, path)
+		if _, err := os.Stat(path); err == nil && !*force {
+			log.Printf("%s exists, skipping", path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Generated", path)
+	}
+
+	rootPath := filepath.Join(*output, "cmd", "root.go")
+	root, err := ioutil.ReadFile(rootPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	root, err = RegisterProvider(root, mapping.Title())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(rootPath, root, 0644); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Registered", mapping.Provider, "in", rootPath)
+}
@@ -0,0 +1,276 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Mapping describes the provider to scaffold
+//
+//	{
+//	  "provider": "rabbitmq",
+//	  "config": ["endpoint", "username", "password"],
+//	  "services": {
+//	    "vhosts": {"resource": "rabbitmq_vhost", "list": "GET /api/vhosts", "id": "name"}
+//	  }
+//	}
+type Mapping struct {
+	// Provider is the name of the Terraform provider
+	Provider string `json:"provider"`
+	// Config lists attributes of the provider configuration passed from environment variables,
+	// required string attributes of the provider schema by default
+	Config   []string                  `json:"config,omitempty"`
+	Services map[string]ServiceMapping `json:"services"`
+}
+
+// ServiceMapping maps a service to a resource type and the API call listing its resources
+type ServiceMapping struct {
+	Resource string `json:"resource"`
+	List     string `json:"list"`
+	// ID is the field of listed items used as resource ID, "id" by default
+	ID string `json:"id,omitempty"`
+	// Name is the field of listed items used as resource name, the ID by default
+	Name string `json:"name,omitempty"`
+}
+
+func ReadMapping(path string) (*Mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	mapping := &Mapping{}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(mapping); err != nil {
+		return nil, fmt.Errorf("failed to read mapping %s: %v", path, err)
+	}
+	return mapping, nil
+}
+
+// Title returns the provider name used in Go identifiers, e.g. EquinixMetal for equinix_metal
+func (m *Mapping) Title() string {
+	return camelCase(m.Provider, true)
+}
+
+func loadSchema(providerName, schemaPath string) (*providerwrapper.ProviderSchema, error) {
+	schema, err := providerwrapper.LoadSchema(providerName, schemaPath, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema of provider %s: %v", providerName, err)
+	}
+	return schema, nil
+}
+
+type configAttribute struct {
+	Name  string
+	Field string
+	Env   string
+}
+
+type serviceData struct {
+	Name           string
+	Type           string
+	Resource       string
+	ResourcePrefix string
+	List           string
+	ID             string
+	NameField      string
+}
+
+type providerData struct {
+	Name     string
+	Package  string
+	Title    string
+	Config   []configAttribute
+	Services []serviceData
+}
+
+// Generate returns files of the provider by path relative to the repository root
+func Generate(mapping *Mapping, schema *providerwrapper.ProviderSchema) (map[string][]byte, error) {
+	if mapping.Provider == "" {
+		return nil, fmt.Errorf("provider name is required")
+	}
+	if len(mapping.Services) == 0 {
+		return nil, fmt.Errorf("no services mapped for provider %s", mapping.Provider)
+	}
+	data := providerData{
+		Name:    mapping.Provider,
+		Package: strings.ToLower(mapping.Title()),
+		Title:   mapping.Title(),
+	}
+
+	configNames := mapping.Config
+	if configNames == nil {
+		for name, attribute := range schema.Provider.Block.Attributes {
+			if attribute.Required && attribute.Type == cty.String {
+				configNames = append(configNames, name)
+			}
+		}
+		sort.Strings(configNames)
+	}
+	for _, name := range configNames {
+		if _, ok := schema.Provider.Block.Attributes[name]; !ok {
+			return nil, fmt.Errorf("provider %s has no configuration attribute %s", mapping.Provider, name)
+		}
+		data.Config = append(data.Config, configAttribute{
+			Name:  name,
+			Field: goIdentifier(camelCase(name, false)),
+			Env:   strings.ToUpper(data.Package + "_" + name),
+		})
+	}
+
+	var serviceNames []string
+	for name := range mapping.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, name := range serviceNames {
+		service := mapping.Services[name]
+		if _, ok := schema.ResourceTypes[service.Resource]; !ok {
+			return nil, fmt.Errorf("service %s: provider %s has no resource type %s", name, mapping.Provider, service.Resource)
+		}
+		if service.ID == "" {
+			service.ID = "id"
+		}
+		if service.Name == "" {
+			service.Name = service.ID
+		}
+		data.Services = append(data.Services, serviceData{
+			Name:           name,
+			Type:           camelCase(name, true),
+			Resource:       service.Resource,
+			ResourcePrefix: strings.TrimPrefix(service.Resource, mapping.Provider+"_"),
+			List:           service.List,
+			ID:             service.ID,
+			NameField:      service.Name,
+		})
+	}
+
+	providerDir := "providers/" + data.Package + "/"
+	var buf bytes.Buffer
+	if err := providerwrapper.WriteSchemaJSON(&buf, schema); err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{
+		providerDir + "testdata/schema.json": buf.Bytes(),
+	}
+
+	templates := map[string]string{
+		providerDir + data.Package + "_provider.go": providerTemplate,
+		providerDir + data.Package + "_service.go":  serviceTemplate,
+		"cmd/provider_cmd_" + data.Package + ".go":  cmdTemplate,
+		"docs/" + data.Package + ".md":              docsTemplate,
+	}
+	for path, text := range templates {
+		content, err := execute(path, text, data)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = content
+	}
+	for _, service := range data.Services {
+		serviceData := struct {
+			providerData
+			Service serviceData
+		}{data, service}
+		for path, text := range map[string]string{
+			providerDir + service.Name + ".go":                           generatorTemplate,
+			providerDir + service.Name + "_test.go":                      testTemplate,
+			providerDir + "testdata/" + service.Name + "_resources.json": resourcesTemplate,
+		} {
+			content, err := execute(path, text, serviceData)
+			if err != nil {
+				return nil, err
+			}
+			files[path] = content
+		}
+	}
+	return files, nil
+}
+
+// RegisterProvider adds the importer and the provider to cmd/root.go. Imports of any provider stay last.
+func RegisterProvider(root []byte, title string) ([]byte, error) {
+	code := string(root)
+	importer := "\t\tnewCmd" + title + "Importer,\n"
+	provider := "\t\tnew" + title + "Provider,\n"
+	if strings.Contains(code, importer) {
+		return root, nil
+	}
+	for _, insert := range []struct{ marker, line string }{
+		{"\t\t// Any provider\n\t\tnewCmdGenericImporter,\n", importer},
+		{"\t} {\n\t\tlist[providerGen().GetName()] = providerGen\n", provider},
+	} {
+		i := strings.Index(code, insert.marker)
+		if i < 0 {
+			return nil, fmt.Errorf("failed to find providers in cmd/root.go, add %s manually", strings.TrimSpace(insert.line))
+		}
+		code = code[:i] + insert.line + code[i:]
+	}
+	return format.Source([]byte(code))
+}
+
+// camelCase converts names like equinix_metal or load-balancers to EquinixMetal or loadBalancers
+func camelCase(name string, upper bool) string {
+	var b strings.Builder
+	nextUpper := upper
+	for _, r := range name {
+		if r == '_' || r == '-' || r == '.' || r == ' ' {
+			nextUpper = b.Len() > 0 || upper
+			continue
+		}
+		if nextUpper {
+			r = unicode.ToUpper(r)
+			nextUpper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func goIdentifier(name string) string {
+	if token.IsKeyword(name) {
+		return name + "Value"
+	}
+	return name
+}
+
+func execute(name, text string, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	t := template.Must(template.New(name).Parse(text))
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(name, ".go") {
+		return buf.Bytes(), nil
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %v", name, err)
+	}
+	return code, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	schema, err := loadSchema("rabbitmq", "../../providers/rabbitmq/testdata/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping := &Mapping{
+		Provider: "rabbit_mq",
+		Services: map[string]ServiceMapping{
+			"queues": {Resource: "rabbitmq_queue", List: "GET /api/queues", ID: "name"},
+		},
+	}
+	files, err := Generate(mapping, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	expected := []string{
+		"cmd/provider_cmd_rabbitmq.go",
+		"docs/rabbitmq.md",
+		"providers/rabbitmq/queues.go",
+		"providers/rabbitmq/queues_test.go",
+		"providers/rabbitmq/rabbitmq_provider.go",
+		"providers/rabbitmq/rabbitmq_service.go",
+		"providers/rabbitmq/testdata/queues_resources.json",
+		"providers/rabbitmq/testdata/schema.json",
+	}
+	if strings.Join(paths, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected files:\n%s", strings.Join(paths, "\n"))
+	}
+
+	for path, snippet := range map[string]string{
+		"providers/rabbitmq/rabbitmq_provider.go": `"queues": &QueuesGenerator{},`,
+		"cmd/provider_cmd_rabbitmq.go":            `Import(provider, options, []string{os.Getenv("RABBITMQ_ENDPOINT"), os.Getenv("RABBITMQ_PASSWORD"), os.Getenv("RABBITMQ_USERNAME")})`,
+		"providers/rabbitmq/queues.go":            "ID string `json:\"name\"`",
+	} {
+		if !strings.Contains(string(files[path]), snippet) {
+			t.Errorf("missing %s in %s:\n%s", snippet, path, files[path])
+		}
+	}
+
+	if !strings.Contains(string(files["providers/rabbitmq/rabbitmq_provider.go"]), "if len(args) < 3 {") {
+		t.Errorf("missing check of provider arguments:\n%s", files["providers/rabbitmq/rabbitmq_provider.go"])
+	}
+
+	mapping.Services["exchanges"] = ServiceMapping{Resource: "rabbitmq_exchange"}
+	if _, err := Generate(mapping, schema); err == nil {
+		t.Error("expected error for resource type missing in schema")
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	root, err := ioutil.ReadFile("../../cmd/root.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered, err := RegisterProvider(root, "Example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"newCmdExampleImporter,\n\t\t// Any provider\n\t\tnewCmdGenericImporter,",
+		"newExampleProvider,\n\t} {",
+	} {
+		if !strings.Contains(string(registered), line) {
+			t.Errorf("missing %s in cmd/root.go", line)
+		}
+	}
+	again, err := RegisterProvider(registered, "Example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(registered) {
+		t.Error("provider registered twice")
+	}
+}
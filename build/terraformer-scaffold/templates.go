@@ -0,0 +1,272 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+const header = `// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+`
+
+const providerTemplate = header + `package {{.Package}}
+
+import (
+	"errors"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type {{.Title}}Provider struct { //nolint
+	terraformutils.Provider
+{{- range .Config}}
+	{{.Field}} string
+{{- end}}
+}
+
+func (p *{{.Title}}Provider) Init(args []string) error {
+{{- if .Config}}
+	if len(args) < {{len .Config}} {
+		return errors.New(p.GetName() + ": expected {{len .Config}} provider arguments")
+	}
+{{- end}}
+{{- range $i, $c := .Config}}
+	p.{{$c.Field}} = args[{{$i}}]
+{{- end}}
+	return nil
+}
+
+func (p *{{.Title}}Provider) GetName() string {
+	return "{{.Name}}"
+}
+
+func (p *{{.Title}}Provider) GetProviderData(arg ...string) map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (p *{{.Title}}Provider) GetConfig() cty.Value {
+{{- if .Config}}
+	return cty.ObjectVal(map[string]cty.Value{
+{{- range .Config}}
+		"{{.Name}}": cty.StringVal(p.{{.Field}}),
+{{- end}}
+	})
+{{- else}}
+	return cty.EmptyObjectVal
+{{- end}}
+}
+
+func (p *{{.Title}}Provider) GetBasicConfig() cty.Value {
+	return p.GetConfig()
+}
+
+func (p *{{.Title}}Provider) InitService(serviceName string, verbose bool) error {
+	var isSupported bool
+	if _, isSupported = p.GetSupportedService()[serviceName]; !isSupported {
+		return errors.New(p.GetName() + ": " + serviceName + " not supported service")
+	}
+	p.Service = p.GetSupportedService()[serviceName]
+	p.Service.SetName(serviceName)
+	p.Service.SetVerbose(verbose)
+	p.Service.SetProviderName(p.GetName())
+	p.Service.SetArgs(map[string]interface{}{
+{{- range .Config}}
+		"{{.Name}}": p.{{.Field}},
+{{- end}}
+	})
+	return nil
+}
+
+func (p *{{.Title}}Provider) GetSupportedService() map[string]terraformutils.ServiceGenerator {
+	return map[string]terraformutils.ServiceGenerator{
+{{- range .Services}}
+		"{{.Name}}": &{{.Type}}Generator{},
+{{- end}}
+	}
+}
+
+func ({{.Title}}Provider) GetResourceConnections() map[string]map[string][]string {
+	return map[string]map[string][]string{}
+}
+`
+
+const serviceTemplate = header + `package {{.Package}}
+
+import (
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+// {{.Title}}Service is embedded by generators of all services. Configuration of the provider
+// is in Args{{range .Config}}, Args["{{.Name}}"]{{end}}.
+type {{.Title}}Service struct { //nolint
+	terraformutils.Service
+}
+`
+
+const generatorTemplate = header + `package {{.Package}}
+
+import (
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+var {{.Service.Type}}AllowEmptyValues = []string{}
+
+type {{.Service.Type}}Generator struct {
+	{{.Title}}Service
+}
+
+// {{.Service.Type}}Item is an item listed by {{.Service.List}}
+type {{.Service.Type}}Item struct {
+	ID string ` + "`" + `json:"{{.Service.ID}}"` + "`" + `
+{{- if ne .Service.NameField .Service.ID}}
+	Name string ` + "`" + `json:"{{.Service.NameField}}"` + "`" + `
+{{- end}}
+}
+
+func (g {{.Service.Type}}Generator) createResources(items []{{.Service.Type}}Item) []terraformutils.Resource {
+	var resources []terraformutils.Resource
+	for _, item := range items {
+		resources = append(resources, terraformutils.NewSimpleResource(
+			item.ID,
+			"{{.Service.ResourcePrefix}}_"+item.{{if ne .Service.NameField .Service.ID}}Name{{else}}ID{{end}},
+			"{{.Service.Resource}}",
+			"{{.Name}}",
+			{{.Service.Type}}AllowEmptyValues,
+		))
+	}
+	return resources
+}
+
+// InitResources lists {{.Service.Resource}} resources with {{.Service.List}}
+func (g *{{.Service.Type}}Generator) InitResources() error {
+	var items []{{.Service.Type}}Item
+	// TODO: call {{.Service.List}} and decode its items
+	g.Resources = g.createResources(items)
+	return nil
+}
+`
+
+const testTemplate = header + `package {{.Package}}
+
+import (
+{{- if .Config}}
+	"os"
+{{- end}}
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformertest"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Resources are recorded from a real API and plugin with
+// TERRAFORMER_RECORD=1 {{range .Config}}{{.Env}}=... {{end}}go test ./providers/{{.Package}}/
+func Test{{.Service.Type}}Generator(t *testing.T) {
+{{- range .Config}}
+	{{.Field}} := os.Getenv("{{.Env}}")
+{{- end}}
+{{- if .Config}}
+	providerWrapper := terraformertest.NewProviderWrapper(t, "{{.Name}}", cty.ObjectVal(map[string]cty.Value{
+{{- range .Config}}
+		"{{.Name}}": cty.StringVal({{.Field}}),
+{{- end}}
+	}), "testdata/schema.json", "testdata/{{.Service.Name}}_resources.json")
+{{- else}}
+	providerWrapper := terraformertest.NewProviderWrapper(t, "{{.Name}}", cty.EmptyObjectVal, "testdata/schema.json", "testdata/{{.Service.Name}}_resources.json")
+{{- end}}
+
+	resources, err := terraformertest.RunService(&{{.Title}}Provider{}, "{{.Service.Name}}", []string{ {{- range $i, $c := .Config}}{{if $i}}, {{end}}{{$c.Field}}{{end -}} }, nil, providerWrapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// TODO: compare items of the recorded resources
+	for _, r := range resources {
+		if r.InstanceInfo.Type != "{{.Service.Resource}}" {
+			t.Errorf("unexpected resource %s", r.InstanceInfo.Id)
+		}
+	}
+}
+`
+
+const cmdTemplate = header + `package cmd
+
+import (
+{{- if .Config}}
+	"os"
+
+{{end}}
+	{{.Package}}_terraforming "github.com/GoogleCloudPlatform/terraformer/providers/{{.Package}}"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/spf13/cobra"
+)
+
+func newCmd{{.Title}}Importer(options ImportOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "{{.Name}}",
+		Short: "Import current state to Terraform configuration from {{.Title}}",
+		Long:  "Import current state to Terraform configuration from {{.Title}}",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider := new{{.Title}}Provider()
+			err := Import(provider, options, []string{ {{- range $i, $c := .Config}}{{if $i}}, {{end}}os.Getenv("{{$c.Env}}"){{end -}} })
+			if err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(listCmd(new{{.Title}}Provider()))
+	baseProviderFlags(cmd.PersistentFlags(), &options, "{{(index .Services 0).Name}}", "{{(index .Services 0).Name}}=id1:id2:id4")
+	return cmd
+}
+
+func new{{.Title}}Provider() terraformutils.ProviderGenerator {
+	return &{{.Package}}_terraforming.{{.Title}}Provider{}
+}
+`
+
+const resourcesTemplate = `{
+  "Resources": {}
+}
+`
+
+const docsTemplate = `### Use with {{.Title}}
+
+Example:
+
+` + "```" + `
+{{- range .Config}}
+ export {{.Env}}=[{{.Env}}]
+{{- end}}
+
+ terraformer import {{.Name}} --resources={{range $i, $s := .Services}}{{if $i}},{{end}}{{$s.Name}}{{end}}
+` + "```" + `
+
+List of supported {{.Title}} services:
+
+{{range .Services -}}
+*   ` + "`{{.Name}}`" + `
+    * ` + "`{{.Resource}}`" + `
+{{end -}}
+`
@@ -0,0 +1,172 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+var providerAliasInvalid = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// providerAlias returns alias of provider configuration for value like region eu-west-1
+func providerAlias(value string) string {
+	alias := providerAliasInvalid.ReplaceAllString(value, "_")
+	if alias == "" || (alias[0] >= '0' && alias[0] <= '9') {
+		alias = "_" + alias
+	}
+	return alias
+}
+
+// aliasedImport collects imports of a provider with different configurations, like regions, and prints
+// them to a single configuration. Resources of each import select its configuration by alias.
+type aliasedImport struct {
+	provider terraformutils.ProviderGenerator
+	plan     *ImportPlan
+	scopes   map[string]map[string]terraformutils.PathScope
+}
+
+func newAliasedImport() *aliasedImport {
+	return &aliasedImport{scopes: map[string]map[string]terraformutils.PathScope{}}
+}
+
+// add merges resources of plan, imported with configuration of provider, as resources of alias.
+// Configuration of the first alias is also the default one, unless an import without alias is added.
+func (a *aliasedImport) add(provider terraformutils.ProviderGenerator, alias string, plan *ImportPlan) error {
+	if a.plan == nil {
+		options := plan.Options
+		options.ProviderAlias = ""
+		options.AliasedImport = nil
+		a.provider = provider
+		a.plan = &ImportPlan{
+			Provider:         plan.Provider,
+			Options:          options,
+			Args:             plan.Args,
+			ImportedResource: map[string][]terraformutils.Resource{},
+			ProviderAliases:  map[string]map[string]interface{}{},
+		}
+	}
+	config := providerConfig(provider)
+	if _, exist := a.plan.ProviderAliases[""]; !exist || alias == "" {
+		a.plan.ProviderAliases[""] = config
+	}
+	if alias != "" {
+		aliasConfig := map[string]interface{}{"alias": alias}
+		for k, v := range config {
+			aliasConfig[k] = v
+		}
+		a.plan.ProviderAliases[alias] = aliasConfig
+	}
+
+	addresses := map[string]struct{}{}
+	for _, resources := range a.plan.ImportedResource {
+		for _, r := range resources {
+			addresses[r.Address()] = struct{}{}
+		}
+	}
+	services := make([]string, 0, len(plan.ImportedResource))
+	for service := range plan.ImportedResource {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	// resources are renamed before they're merged, references written by hooks of services follow them
+	renamed := map[string]string{}
+	for _, service := range services {
+		resources := plan.ImportedResource[service]
+		for i := range resources {
+			resources[i].ProviderAlias = alias
+			if _, exist := addresses[resources[i].Address()]; exist {
+				address := resources[i].InstanceInfo.Type + "." + resources[i].ResourceName
+				name := resources[i].ResourceName + "_" + alias
+				resources[i].ResourceName = name
+				// the renamed resource may collide too, e.g. with a resource imported with that name
+				for n := 2; ; n++ {
+					if _, exist := addresses[resources[i].Address()]; !exist {
+						break
+					}
+					resources[i].ResourceName = name + "_" + strconv.Itoa(n)
+				}
+				renamed[address] = resources[i].InstanceInfo.Type + "." + resources[i].ResourceName
+			}
+			addresses[resources[i].Address()] = struct{}{}
+		}
+	}
+	for _, service := range services {
+		terraformutils.UpdateReferences(plan.ImportedResource[service], renamed)
+		a.plan.ImportedResource[service] = append(a.plan.ImportedResource[service], plan.ImportedResource[service]...)
+		if a.scopes[service] == nil {
+			a.scopes[service] = map[string]terraformutils.PathScope{}
+		}
+		a.scopes[service][alias] = plan.PathScopes[service]
+	}
+	return nil
+}
+
+// print prints collected resources, if any, scopes of services are reduced to values shared by all aliases
+func (a *aliasedImport) print() error {
+	if a == nil || a.plan == nil {
+		return nil
+	}
+	for service, scopes := range a.scopes {
+		if scope := commonScope(scopes); len(scope) > 0 {
+			if a.plan.PathScopes == nil {
+				a.plan.PathScopes = map[string]terraformutils.PathScope{}
+			}
+			a.plan.PathScopes[service] = scope
+		}
+	}
+	if a.plan.Options.Plan {
+		return ExportPlanFile(a.plan, reportPath(a.provider, a.plan.Options, a.plan.PathScopes), "plan.json")
+	}
+	return printPlan(context.Background(), a.provider, a.plan)
+}
+
+// providerConfig returns arguments of provider block of provider
+func providerConfig(provider terraformutils.ProviderGenerator) map[string]interface{} {
+	config := map[string]interface{}{}
+	providers, _ := provider.GetProviderData()["provider"].(map[string]interface{})
+	if providerData, ok := providers[provider.GetName()].(map[string]interface{}); ok {
+		for k, v := range providerData {
+			config[k] = v
+		}
+	}
+	return config
+}
+
+// aliasedProvider prints provider block of each configuration of aliases
+type aliasedProvider struct {
+	terraformutils.ProviderGenerator
+	aliases map[string]map[string]interface{}
+}
+
+func (p aliasedProvider) GetProviderData(arg ...string) map[string]interface{} {
+	data := p.ProviderGenerator.GetProviderData(arg...)
+	aliases := make([]string, 0, len(p.aliases))
+	for alias := range p.aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	// blocks of the same provider are printed from a list of blocks
+	blocks := make([]interface{}, 0, len(aliases))
+	for _, alias := range aliases {
+		blocks = append(blocks, map[string]interface{}{p.GetName(): p.aliases[alias]})
+	}
+	data["provider"] = blocks
+	return data
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/providers/rabbitmq"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+func TestProviderAlias(t *testing.T) {
+	for value, expected := range map[string]string{
+		"eu-west-1":      "eu_west_1",
+		"my-project":     "my_project",
+		"123-project.io": "_123_project_io",
+	} {
+		if alias := providerAlias(value); alias != expected {
+			t.Errorf("expected alias %s of %s, got %s", expected, value, alias)
+		}
+	}
+}
+
+func TestAliasedImportDefaultConfig(t *testing.T) {
+	aliased := newAliasedImport()
+	for _, alias := range []string{"eu_west_1", "", "us_east_1"} {
+		plan := &ImportPlan{Provider: "aws", ImportedResource: map[string][]terraformutils.Resource{}}
+		provider := &aliasTestProvider{region: alias}
+		if err := aliased.add(provider, alias, plan); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := map[string]string{"": "", "eu_west_1": "eu_west_1", "us_east_1": "us_east_1"}
+	for alias, region := range expected {
+		if aliased.plan.ProviderAliases[alias]["region"] != region {
+			t.Errorf("expected region %q of alias %q, got %v", region, alias, aliased.plan.ProviderAliases[alias])
+		}
+	}
+	if _, exist := aliased.plan.ProviderAliases[""]["alias"]; exist {
+		t.Error("default configuration has alias")
+	}
+}
+
+func TestAliasedImportRenamedReferences(t *testing.T) {
+	aliased := newAliasedImport()
+	for _, alias := range []string{"eu_west_1", "us_east_1"} {
+		zone := terraformutils.NewSimpleResource("Z1", "example.com", "aws_route53_zone", "aws", []string{})
+		zone.Item = map[string]interface{}{"name": "example.com"}
+		record := terraformutils.NewSimpleResource("Z1_www", "www."+alias, "aws_route53_record", "aws", []string{})
+		record.Item = map[string]interface{}{"zone_id": "${aws_route53_zone.tfer--example-002E-com.zone_id}"}
+		plan := &ImportPlan{Provider: "aws", ImportedResource: map[string][]terraformutils.Resource{
+			"route53": {zone, record},
+		}}
+		if err := aliased.add(&aliasTestProvider{region: alias}, alias, plan); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resources := aliased.plan.ImportedResource["route53"]
+	if len(resources) != 4 {
+		t.Fatalf("expected 4 resources, got %d", len(resources))
+	}
+	if resources[2].ResourceName != "tfer--example-002E-com_us_east_1" {
+		t.Errorf("duplicate resource wasn't renamed, got %s", resources[2].ResourceName)
+	}
+	for i, expected := range map[int]string{
+		1: "${aws_route53_zone.tfer--example-002E-com.zone_id}",
+		3: "${aws_route53_zone.tfer--example-002E-com_us_east_1.zone_id}",
+	} {
+		if zoneID := resources[i].Item["zone_id"]; zoneID != expected {
+			t.Errorf("expected reference %s of %s, got %s", expected, resources[i].ResourceName, zoneID)
+		}
+	}
+}
+
+func TestAliasedImportRenamedCollision(t *testing.T) {
+	aliased := newAliasedImport()
+	for _, alias := range []string{"eu", "us", "us"} {
+		vhost := terraformutils.NewSimpleResource("prod", "prod", "rabbitmq_vhost", "rabbitmq", []string{})
+		renamed := terraformutils.NewSimpleResource("prod_us", "prod_us", "rabbitmq_vhost", "rabbitmq", []string{})
+		plan := &ImportPlan{Provider: "rabbitmq", ImportedResource: map[string][]terraformutils.Resource{
+			"vhosts": {vhost, renamed},
+		}}
+		if err := aliased.add(&aliasTestProvider{region: alias}, alias, plan); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var names []string
+	for _, r := range aliased.plan.ImportedResource["vhosts"] {
+		names = append(names, r.ResourceName)
+	}
+	expected := []string{
+		"tfer--prod", "tfer--prod_us",
+		"tfer--prod_us_2", "tfer--prod_us_us",
+		"tfer--prod_us_3", "tfer--prod_us_us_2",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected unique names %v, got %v", expected, names)
+	}
+}
+
+type aliasTestProvider struct {
+	rabbitmq.RBTProvider
+	region string
+}
+
+func (p aliasTestProvider) GetName() string {
+	return "aws"
+}
+
+func (p aliasTestProvider) GetProviderData(arg ...string) map[string]interface{} {
+	return map[string]interface{}{"provider": map[string]interface{}{"aws": map[string]interface{}{"region": p.region}}}
+}
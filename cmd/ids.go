@@ -0,0 +1,93 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+var (
+	// stdin can be read only once, its IDs are shared by imports of all regions or projects
+	stdinSelectedResourcesMutex sync.Mutex
+	stdinSelectedResources      []terraformutils.SelectedResource
+)
+
+// readSelectedResources reads IDs of resources to import from file on each import, or stdin for -
+func readSelectedResources(path string) ([]terraformutils.SelectedResource, error) {
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readSelectedResourcesFrom(f)
+	}
+	stdinSelectedResourcesMutex.Lock()
+	defer stdinSelectedResourcesMutex.Unlock()
+	if stdinSelectedResources != nil {
+		return stdinSelectedResources, nil
+	}
+	selected, err := readSelectedResourcesFrom(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	stdinSelectedResources = selected
+	return selected, nil
+}
+
+func readSelectedResourcesFrom(r io.Reader) ([]terraformutils.SelectedResource, error) {
+	selected, err := terraformutils.ReadSelectedResources(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no IDs found")
+	}
+	return selected, nil
+}
+
+// selectedServices returns services of selected resources, or all services if some resources have no service
+func selectedServices(services []string, selected []terraformutils.SelectedResource) ([]string, error) {
+	selectedServices, all := terraformutils.SelectedServices(selected)
+	if all {
+		return services, nil
+	}
+	var kept, skipped []string
+	for _, service := range services {
+		found := false
+		for _, selectedService := range selectedServices {
+			found = found || service == selectedService
+		}
+		if found {
+			kept = append(kept, service)
+		} else {
+			skipped = append(skipped, service)
+		}
+	}
+	if len(skipped) > 0 {
+		log.Println("Skipping services without IDs: " + strings.Join(skipped, ", "))
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("no IDs of services %s, IDs are of %s", strings.Join(services, ", "), strings.Join(selectedServices, ", "))
+	}
+	return kept, nil
+}
@@ -14,13 +14,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformerstring"
 
@@ -29,33 +32,73 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/policy"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/telemetry"
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformoutput"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/api/compute/v1"
 )
 
 type ImportOptions struct {
-	Resources     []string
-	Excludes      []string
-	PathPattern   string
-	PathOutput    string
-	State         string
-	Bucket        string
-	Profile       string
-	Verbose       bool
-	Zone          string
-	Regions       []string
-	Projects      []string
-	ResourceGroup string
-	Connect       bool
-	Compact       bool
-	Filter        []string
-	Plan          bool `json:"-"`
-	Output        string
-	RetryCount    int
-	RetrySleepMs  int
+	Resources       []string
+	Excludes        []string
+	PathPattern     string
+	PathOutput      string
+	State           string
+	Bucket          string
+	Profile         string
+	Verbose         bool
+	Zone            string
+	Regions         []string
+	Projects        []string
+	ResourceGroup   string
+	Connect         bool
+	Compact         bool
+	Filter          []string
+	Plan            bool `json:"-"`
+	Output          string
+	RetryCount      int
+	RetrySleepMs    int
+	ExcludeManaged  []string
+	Collapse        bool
+	ExternalRefs    string
+	Transform       string
+	TransformDryRun bool
+	Previous        string
+	Inventory       *terraformutils.Inventory   `json:"-"`
+	Watch           *watcher                    `json:"-"`
+	Progress        terraformutils.ProgressFunc `json:"-"`
+	Telemetry       string
+	TelemetryFile   string
+	ShowProgress    string
+	LogFile         string
+	Policy          string
+	SchemaFile      string
+	Annotate        bool
+	Layout          string
+	ProviderAliases bool
+	IDsFrom         string
+	Snapshot        string
+	// Schema of provider is needed by cdktf output to print values with types of attributes
+	Schema *providerwrapper.ProviderSchema `json:"-"`
+	// PreviousResources are loaded from Previous before any state is overwritten
+	PreviousResources terraformutils.PreviousResources `json:"-"`
+	// TerragruntRoot is directory of root terragrunt.hcl included by directories of services
+	TerragruntRoot string `json:"-"`
+	// ProviderAlias of configuration of provider of the import, imports are collected by AliasedImport
+	// and printed together when ProviderAliases is set
+	ProviderAlias string         `json:"-"`
+	AliasedImport *aliasedImport `json:"-"`
+	// SelectedResources are read from IDsFrom, only them are imported
+	SelectedResources []terraformutils.SelectedResource `json:"-"`
+	// Policies are compiled from Policy before resources are listed
+	Policies *policy.Evaluator `json:"-"`
 }
 
+// importTime is replaced by tests to get stable annotations
+var importTime = time.Now
+
 const DefaultPathPattern = "{output}/{provider}/{service}/"
 const DefaultPathOutput = "generated"
 const DefaultState = "local"
@@ -81,39 +124,131 @@ func newImportCmd() *cobra.Command {
 }
 
 func Import(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) error {
+	if options.Inventory != nil {
+		return importInventory(provider, options, args)
+	}
+	ctx := context.Background()
+	if options.Watch != nil {
+		options.Snapshot = options.Watch.snapshot
+		if options.Watch.ctx != nil {
+			ctx = options.Watch.ctx
+		}
+	}
+
+	if err := setupTelemetry(options); err != nil {
+		return err
+	}
+	if err := setupLogFile(options.LogFile); err != nil {
+		return err
+	}
+	showProgress, err := progressEnabled(options.ShowProgress, os.Stderr)
+	if err != nil {
+		return err
+	}
 
 	providerWrapper, options, err := initOptionsAndWrapper(provider, options, args)
 	if err != nil {
 		return err
 	}
 	defer providerWrapper.Kill()
+
+	if showProgress {
+		display := newProgressDisplay(os.Stderr, provider.GetName(), options.Resources)
+		progress := options.Progress
+		options.Progress = func(event terraformutils.ProgressEvent) {
+			if progress != nil {
+				progress(event)
+			}
+			display.Handle(event)
+		}
+		display.Start(200 * time.Millisecond)
+		defer display.Finish()
+	}
+
+	return importWithProviderWrapper(ctx, provider, options, args, providerWrapper)
+}
+
+func importWithProviderWrapper(ctx context.Context, provider terraformutils.ProviderGenerator, options ImportOptions, args []string, providerWrapper *providerwrapper.ProviderWrapper) (err error) {
+	ctx, span := telemetry.Start(ctx, "import", telemetry.ProviderKey.String(provider.GetName()))
+	defer func() {
+		telemetry.End(span, err)
+	}()
 	providerMapping := terraformutils.NewProvidersMapping(provider)
 
-	err = initAllServicesResources(providerMapping, options, args, providerWrapper)
+	err = initAllServicesResources(ctx, providerMapping, options, args, providerWrapper)
 	if err != nil {
 		return err
 	}
 
-	err = terraformutils.RefreshResourcesByProvider(providerMapping, providerWrapper)
+	refreshCtx, refreshSpan := telemetry.Start(ctx, "refresh")
+	err = terraformutils.RefreshResourcesByProvider(refreshCtx, providerMapping, providerWrapper, options.Progress)
+	telemetry.End(refreshSpan, err)
 	if err != nil {
 		return err
 	}
 
+	if options.Snapshot != "" {
+		if err = writeSnapshot(provider, options, args, providerMapping, providerWrapper); err != nil {
+			return err
+		}
+	}
+
+	_, convertSpan := telemetry.Start(ctx, "convert")
 	providerMapping.ConvertTFStates(providerWrapper)
 	// change structs with additional data for each resource
 	providerMapping.CleanupProviders()
+	telemetry.End(convertSpan, nil)
+
+	if terraformoutput.IsCdktfOutput(options.Output) {
+		options.Schema = providerWrapper.GetSchema()
+	}
 
-	err = importFromPlan(providerMapping, options, args)
+	return importFromPlan(ctx, provider, providerMapping, options, args)
+}
 
-	return err
+func (o ImportOptions) progress(event terraformutils.ProgressEvent) {
+	if o.Progress != nil {
+		o.Progress(event)
+	}
 }
 
 func initOptionsAndWrapper(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) (*providerwrapper.ProviderWrapper, ImportOptions, error) {
-	err := provider.Init(args)
+	options, err := initOptions(provider, options, args)
 	if err != nil {
 		return nil, options, err
 	}
 
+	wrapperOptions := map[string]int{"retryCount": options.RetryCount, "retrySleepMs": options.RetrySleepMs}
+	var providerWrapper *providerwrapper.ProviderWrapper
+	if options.SchemaFile != "" {
+		schema, err := providerwrapper.ReadSchemaFile(options.SchemaFile, provider.GetName())
+		if err != nil {
+			return nil, options, fmt.Errorf("failed to read schema file: %v", err)
+		}
+		providerWrapper, err = providerwrapper.NewProviderWrapperWithSchema(provider.GetName(), provider.GetConfig(), schema, options.Verbose, wrapperOptions)
+		if err != nil {
+			return nil, options, err
+		}
+	} else {
+		providerWrapper, err = providerwrapper.NewProviderWrapper(provider.GetName(), provider.GetConfig(), options.Verbose, wrapperOptions)
+		if err != nil {
+			return nil, options, err
+		}
+	}
+
+	return providerWrapper, options, nil
+}
+
+func initOptions(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) (ImportOptions, error) {
+	if err := validateOptions(options); err != nil {
+		return options, err
+	}
+	setSchemaFile(provider, options.SchemaFile)
+	err := provider.Init(args)
+	if err != nil {
+		return options, err
+	}
+
 	if terraformerstring.ContainsString(options.Resources, "*") {
 		log.Println("Attempting an import of ALL resources in " + provider.GetName())
 		options.Resources = providerServices(provider)
@@ -136,31 +271,76 @@ func initOptionsAndWrapper(provider terraformutils.ProviderGenerator, options Im
 		options.Resources = localSlice
 	}
 
-	providerWrapper, err := providerwrapper.NewProviderWrapper(provider.GetName(), provider.GetConfig(), options.Verbose, map[string]int{"retryCount": options.RetryCount, "retrySleepMs": options.RetrySleepMs})
-	if err != nil {
-		return nil, options, err
+	if options.IDsFrom != "" {
+		if options.SelectedResources, err = readSelectedResources(options.IDsFrom); err != nil {
+			return options, fmt.Errorf("failed to read IDs from %s: %v", options.IDsFrom, err)
+		}
+		if options.Resources, err = selectedServices(options.Resources, options.SelectedResources); err != nil {
+			return options, err
+		}
 	}
 
-	return providerWrapper, options, nil
+	if options.Policy != "" {
+		options.Policies, err = policy.Load(context.Background(), options.Policy)
+		if err != nil {
+			return options, fmt.Errorf("failed to load policies: %v", err)
+		}
+	}
+
+	return options, nil
 }
 
-func initAllServicesResources(providersMapping *terraformutils.ProvidersMapping, options ImportOptions, args []string, providerWrapper *providerwrapper.ProviderWrapper) error {
+// validateOptions checks combinations of output options
+func validateOptions(options ImportOptions) error {
+	switch options.Layout {
+	case "", "terraform":
+	case "terragrunt":
+		if options.Output != "hcl" {
+			return fmt.Errorf("--layout=terragrunt isn't supported with %s output", options.Output)
+		}
+	default:
+		return fmt.Errorf("unknown layout %s, use terraform or terragrunt", options.Layout)
+	}
+	if options.ProviderAliases && terraformoutput.IsCdktfOutput(options.Output) {
+		return fmt.Errorf("--provider-aliases isn't supported with %s output", options.Output)
+	}
+	return nil
+}
+
+func initAllServicesResources(ctx context.Context, providersMapping *terraformutils.ProvidersMapping, options ImportOptions, args []string, providerWrapper *providerwrapper.ProviderWrapper) error {
 	numOfResources := len(options.Resources)
 	var wg sync.WaitGroup
 	wg.Add(numOfResources)
 
 	var failedServices []string
 
+	managedResources, err := loadManagedResources(options.ExcludeManaged)
+	if err != nil {
+		return err
+	}
+
 	for _, service := range options.Resources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		serviceProvider := providersMapping.AddServiceToProvider(service)
 		err := serviceProvider.Init(args)
 		if err != nil {
 			return err
 		}
-		err = initServiceResources(service, serviceProvider, options, providerWrapper)
+		listCtx, span := telemetry.Start(ctx, "list "+service, telemetry.ServiceKey.String(service))
+		options.progress(terraformutils.ProgressEvent{Stage: terraformutils.ProgressListing, Service: service})
+		err = initServiceResources(service, serviceProvider, options, providerWrapper, managedResources)
+		telemetry.End(span, err)
 		if err != nil {
 			failedServices = append(failedServices, service)
+			telemetry.RecordListed(listCtx, providersMapping.GetBaseProvider().GetName(), service, 0, err)
+			options.progress(terraformutils.ProgressEvent{Stage: terraformutils.ProgressListed, Service: service, Err: err})
+			continue
 		}
+		resourcesCount := len(serviceProvider.GetService().GetResources())
+		telemetry.RecordListed(listCtx, providersMapping.GetBaseProvider().GetName(), service, resourcesCount, nil)
+		options.progress(terraformutils.ProgressEvent{Stage: terraformutils.ProgressListed, Service: service, Resources: resourcesCount})
 	}
 
 	// remove providers that failed to init their service
@@ -170,9 +350,10 @@ func initAllServicesResources(providersMapping *terraformutils.ProvidersMapping,
 	return nil
 }
 
-func importFromPlan(providerMapping *terraformutils.ProvidersMapping, options ImportOptions, args []string) error {
+func importFromPlan(ctx context.Context, provider terraformutils.ProviderGenerator, providerMapping *terraformutils.ProvidersMapping,
+	options ImportOptions, args []string) error {
 	plan := &ImportPlan{
-		Provider:         providerMapping.GetBaseProvider().GetName(),
+		Provider:         provider.GetName(),
 		Options:          options,
 		Args:             args,
 		ImportedResource: map[string][]terraformutils.Resource{},
@@ -181,18 +362,45 @@ func importFromPlan(providerMapping *terraformutils.ProvidersMapping, options Im
 	resourcesByService := providerMapping.GetResourcesByService()
 	for service := range resourcesByService {
 		plan.ImportedResource[service] = append(plan.ImportedResource[service], resourcesByService[service]...)
+		if scope := pathScope(providerMapping.ServiceArgs(service)); len(scope) > 0 {
+			if plan.PathScopes == nil {
+				plan.PathScopes = map[string]terraformutils.PathScope{}
+			}
+			plan.PathScopes[service] = scope
+		}
+	}
+
+	if options.Transform != "" {
+		rules, err := terraformutils.LoadTransformRules(options.Transform)
+		if err != nil {
+			return err
+		}
+		changes, err := terraformutils.TransformResources(plan.ImportedResource, rules)
+		if options.TransformDryRun {
+			for _, change := range changes {
+				fmt.Println(change)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("%s transform rules made %d changes\n", provider.GetName(), len(changes))
+	}
+
+	if options.AliasedImport != nil {
+		return options.AliasedImport.add(provider, options.ProviderAlias, plan)
 	}
 
 	if options.Plan {
-		path := Path(options.PathPattern, providerMapping.GetBaseProvider().GetName(), "terraformer", options.PathOutput)
-		return ExportPlanFile(plan, path, "plan.json")
+		return ExportPlanFile(plan, reportPath(provider, options, plan.PathScopes), "plan.json")
 	}
 
-	return ImportFromPlan(providerMapping.GetBaseProvider(), plan)
+	return printPlan(ctx, provider, plan)
 }
 
 func initServiceResources(service string, provider terraformutils.ProviderGenerator,
-	options ImportOptions, providerWrapper *providerwrapper.ProviderWrapper) error {
+	options ImportOptions, providerWrapper *providerwrapper.ProviderWrapper, managedResources terraformutils.ManagedResources) error {
 	log.Println(provider.GetName() + " importing... " + service)
 	err := provider.InitService(service, options.Verbose)
 	if err != nil {
@@ -200,27 +408,148 @@ func initServiceResources(service string, provider terraformutils.ProviderGenera
 		return err
 	}
 	provider.GetService().ParseFilters(options.Filter)
+	if options.IDsFrom != "" {
+		provider.GetService().AddFilters(terraformutils.SelectedFilters(options.SelectedResources, service, provider.GetName()))
+	}
 	err = provider.GetService().InitResources()
 	if err != nil {
 		log.Printf("%s error initializing resources in service %s, err: %s\n", provider.GetName(), service, err)
 		return err
 	}
+	if len(managedResources) > 0 {
+		resources := provider.GetService().GetResources()
+		unmanagedResources := managedResources.Filter(resources)
+		log.Printf("%s skipping %d already managed resources in service %s\n", provider.GetName(), len(resources)-len(unmanagedResources), service)
+		provider.GetService().SetResources(unmanagedResources)
+	}
 
-	provider.GetService().PopulateIgnoreKeys(providerWrapper)
+	if providerWrapper != nil {
+		provider.GetService().PopulateIgnoreKeys(providerWrapper)
+	}
 	provider.GetService().InitialCleanup()
 	log.Println(provider.GetName() + " done importing " + service)
 
 	return nil
 }
 
+func loadManagedResources(paths []string) (terraformutils.ManagedResources, error) {
+	managedResources := terraformutils.NewManagedResources()
+	for _, path := range paths {
+		if strings.HasPrefix(path, "gs://") {
+			parts := strings.SplitN(strings.TrimPrefix(path, "gs://"), "/", 2)
+			bucket := terraformoutput.BucketState{
+				Name: parts[0],
+			}
+			prefix := ""
+			if len(parts) > 1 {
+				prefix = parts[1]
+			}
+			states, err := bucket.BucketDownloadStates(prefix)
+			if err != nil {
+				return nil, err
+			}
+			for _, state := range states {
+				if err := managedResources.ParseState(state); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if err := managedResources.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return managedResources, nil
+}
+
 func ImportFromPlan(provider terraformutils.ProviderGenerator, plan *ImportPlan) error {
+	return printPlan(context.Background(), provider, plan)
+}
+
+func printPlan(ctx context.Context, provider terraformutils.ProviderGenerator, plan *ImportPlan) error {
 	options := plan.Options
 	importedResource := plan.ImportedResource
 	isServicePath := strings.Contains(options.PathPattern, "{service}")
+	for _, resources := range importedResource {
+		terraformutils.SortResources(resources)
+	}
+
+	if options.Policy != "" {
+		policyCtx, span := telemetry.Start(ctx, "evaluate policies")
+		err := evaluatePolicies(policyCtx, provider, options, importedResource, plan.PathScopes)
+		telemetry.End(span, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Annotate {
+		importedAt := importTime()
+		for serviceName, resources := range importedResource {
+			for i := range resources {
+				resources[i].Comments = terraformutils.Annotate(resources[i], plan.PathScopes[serviceName], importedAt, version)
+			}
+		}
+	}
 
+	// resources of a service are printed to paths resolved by resource with scope placeholders like {region}
+	var resourcePaths map[string][]string
+	if terraformutils.HasResourcePathPlaceholders(options.PathPattern) {
+		resourcePaths = map[string][]string{}
+		for serviceName, resources := range importedResource {
+			for _, r := range resources {
+				pathPattern := terraformutils.ResolveResourcePath(options.PathPattern, r, plan.PathScopes[serviceName])
+				resourcePaths[serviceName] = append(resourcePaths[serviceName], Path(pathPattern, provider.GetName(), serviceName, options.PathOutput))
+			}
+		}
+	}
+
+	var remoteStatesByPath map[string]map[string]string
 	if options.Connect {
 		log.Println(provider.GetName() + " Connecting.... ")
-		importedResource = terraformutils.ConnectServices(importedResource, isServicePath, provider.GetResourceConnections())
+		if resourcePaths != nil {
+			remoteStatesByPath = terraformutils.ConnectServicesByPath(importedResource, resourcePaths, provider.GetResourceConnections())
+		} else {
+			importedResource = terraformutils.ConnectServices(importedResource, isServicePath, provider.GetResourceConnections())
+		}
+	}
+
+	dataSources := map[string]map[string]map[string]interface{}{}
+	switch options.ExternalRefs {
+	case "":
+	case "data":
+		if referencesProvider, ok := provider.(terraformutils.DataSourceReferencesProvider); ok {
+			log.Println(provider.GetName() + " looking up external references.... ")
+			dataSources = terraformutils.ConnectExternalServices(importedResource, provider.GetResourceConnections(), referencesProvider.GetDataSourceReferences())
+		} else {
+			log.Println(provider.GetName() + " doesn't support data source lookups of external references")
+		}
+	default:
+		return fmt.Errorf("unsupported external references mode %s", options.ExternalRefs)
+	}
+
+	if options.Previous != "" {
+		previousResources, err := terraformutils.LoadPreviousResources(options.Previous, options.PathOutput)
+		if err != nil {
+			return fmt.Errorf("failed to load previous resources: %v", err)
+		}
+		options.PreviousResources = previousResources
+	}
+
+	// resources of aliased provider configurations are printed with all configurations
+	if len(plan.ProviderAliases) > 0 {
+		provider = aliasedProvider{ProviderGenerator: provider, aliases: plan.ProviderAliases}
+	}
+
+	if options.Layout == "terragrunt" {
+		var err error
+		if options.TerragruntRoot, err = printTerragruntRoot(provider, options, plan.PathScopes); err != nil {
+			return err
+		}
+	}
+
+	if resourcePaths != nil {
+		return printPaths(ctx, provider, options, importedResource, resourcePaths, remoteStatesByPath, dataSources, isServicePath)
 	}
 
 	if !isServicePath {
@@ -228,30 +557,203 @@ func ImportFromPlan(provider terraformutils.ProviderGenerator, plan *ImportPlan)
 		for _, resources := range importedResource {
 			compactedResources = append(compactedResources, resources...)
 		}
-		e := printService(provider, "", options, compactedResources, importedResource)
+		terraformutils.SortResources(compactedResources)
+		compactedDataSources := map[string]map[string]interface{}{}
+		for _, serviceDataSources := range dataSources {
+			mergeDataSources(compactedDataSources, serviceDataSources)
+		}
+		path := Path(options.PathPattern, provider.GetName(), "", options.PathOutput)
+		remoteStates := map[string]string{}
+		if options.Connect {
+			remoteStates["local"] = path
+		}
+		_, span := telemetry.Start(ctx, "write")
+		e := printService(provider, "", path, options, compactedResources, remoteStates, compactedDataSources)
+		telemetry.End(span, e)
 		if e != nil {
 			return e
 		}
+		options.progress(terraformutils.ProgressEvent{Stage: terraformutils.ProgressWritten, Resources: len(compactedResources)})
 	} else {
 		for serviceName, resources := range importedResource {
-			e := printService(provider, serviceName, options, resources, importedResource)
+			path := Path(options.PathPattern, provider.GetName(), serviceName, options.PathOutput)
+			remoteStates := map[string]string{}
+			if options.Connect {
+				for k := range provider.GetResourceConnections()[serviceName] {
+					if _, exist := importedResource[k]; exist {
+						remoteStates[k] = strings.ReplaceAll(path, serviceName, k)
+					}
+				}
+			}
+			_, span := telemetry.Start(ctx, "write "+serviceName, telemetry.ServiceKey.String(serviceName))
+			e := printService(provider, serviceName, path, options, resources, remoteStates, dataSources[serviceName])
+			telemetry.End(span, e)
 			if e != nil {
 				return e
 			}
+			options.progress(terraformutils.ProgressEvent{Stage: terraformutils.ProgressWritten, Service: serviceName, Resources: len(resources)})
 		}
 	}
 	return nil
 }
 
-func printService(provider terraformutils.ProviderGenerator, serviceName string, options ImportOptions, resources []terraformutils.Resource, importedResource map[string][]terraformutils.Resource) error {
-	log.Println(provider.GetName() + " save " + serviceName)
-	// Print HCL files for Resources
-	path := Path(options.PathPattern, provider.GetName(), serviceName, options.PathOutput)
-	err := terraformoutput.OutputHclFiles(resources, provider, path, serviceName, options.Compact, options.Output)
+// printPaths groups resources by their resolved paths and prints each path like a service,
+// resources of several services share a path when pattern has no {service}
+func printPaths(ctx context.Context, provider terraformutils.ProviderGenerator, options ImportOptions,
+	importedResource map[string][]terraformutils.Resource, resourcePaths map[string][]string,
+	remoteStatesByPath map[string]map[string]string, dataSources map[string]map[string]map[string]interface{}, isServicePath bool) error {
+	resourcesByPath := map[string][]terraformutils.Resource{}
+	servicesByPath := map[string]map[string]int{}
+	for serviceName, resources := range importedResource {
+		for i, r := range resources {
+			path := resourcePaths[serviceName][i]
+			resourcesByPath[path] = append(resourcesByPath[path], r)
+			if servicesByPath[path] == nil {
+				servicesByPath[path] = map[string]int{}
+			}
+			servicesByPath[path][serviceName]++
+		}
+	}
+	paths := make([]string, 0, len(resourcesByPath))
+	for path := range resourcesByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		resources := resourcesByPath[path]
+		terraformutils.SortResources(resources)
+		serviceName := ""
+		pathDataSources := map[string]map[string]interface{}{}
+		for service := range servicesByPath[path] {
+			if isServicePath {
+				serviceName = service
+			}
+			mergeDataSources(pathDataSources, dataSources[service])
+		}
+		_, span := telemetry.Start(ctx, "write "+path, telemetry.ServiceKey.String(serviceName))
+		e := printService(provider, serviceName, path, options, resources, remoteStatesByPath[path], pathDataSources)
+		telemetry.End(span, e)
+		if e != nil {
+			return e
+		}
+		for service, count := range servicesByPath[path] {
+			options.progress(terraformutils.ProgressEvent{Stage: terraformutils.ProgressWritten, Service: service, Resources: count})
+		}
+	}
+	return nil
+}
+
+// evaluatePolicies writes findings of policies for refreshed resources to policy_findings.json,
+// generated code doesn't depend on them
+func evaluatePolicies(ctx context.Context, provider terraformutils.ProviderGenerator, options ImportOptions,
+	importedResource map[string][]terraformutils.Resource, scopes map[string]terraformutils.PathScope) error {
+	policies := options.Policies
+	if policies == nil {
+		var err error
+		if policies, err = policy.Load(ctx, options.Policy); err != nil {
+			return fmt.Errorf("failed to load policies: %v", err)
+		}
+	}
+	services := make([]string, 0, len(importedResource))
+	for serviceName := range importedResource {
+		services = append(services, serviceName)
+	}
+	sort.Strings(services)
+	var findings []policy.Finding
+	for _, serviceName := range services {
+		serviceFindings, err := policies.Evaluate(ctx, serviceName, importedResource[serviceName])
+		if err != nil {
+			return err
+		}
+		for _, finding := range serviceFindings {
+			log.Printf("%s policy %s %s %s: %s", provider.GetName(), finding.Policy, finding.Level, finding.Address, finding.Message)
+		}
+		findings = append(findings, serviceFindings...)
+		options.progress(terraformutils.ProgressEvent{Stage: terraformutils.ProgressEvaluated, Service: serviceName, Findings: len(serviceFindings)})
+	}
+	path := reportPath(provider, options, scopes)
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(path, "policy_findings.json"))
 	if err != nil {
 		return err
 	}
-	tfStateFile, err := terraformutils.PrintTfState(resources)
+	defer f.Close()
+	log.Printf("%s %d policy findings saved to %s", provider.GetName(), len(findings), f.Name())
+	return policy.Write(f, findings)
+}
+
+func mergeDataSources(dataSources, serviceDataSources map[string]map[string]interface{}) {
+	for dataSource, blocks := range serviceDataSources {
+		if dataSources[dataSource] == nil {
+			dataSources[dataSource] = map[string]interface{}{}
+		}
+		for name, block := range blocks {
+			dataSources[dataSource][name] = block
+		}
+	}
+}
+
+// printService prints resources to path, remoteStates are paths of states read by terraform_remote_state
+// data sources by their names, connected resources read outputs of these states
+func printService(provider terraformutils.ProviderGenerator, serviceName, path string, options ImportOptions, resources []terraformutils.Resource,
+	remoteStates map[string]string, dataSources map[string]map[string]interface{}) error {
+	log.Println(provider.GetName() + " save " + serviceName)
+	isTerragrunt := options.Layout == "terragrunt"
+	var dependencies map[string]string
+	if isTerragrunt {
+		var err error
+		remoteStates, dependencies, err = printTerragruntService(provider, options, path, resources, remoteStates)
+		if err != nil {
+			return err
+		}
+	}
+	// Print HCL files for Resources
+	isCdktf := terraformoutput.IsCdktfOutput(options.Output)
+	if isCdktf {
+		if options.Collapse {
+			return fmt.Errorf("--collapse isn't supported with %s output", options.Output)
+		}
+		err := terraformoutput.OutputCdktfFiles(terraformoutput.CdktfStack{
+			ServiceName:  serviceName,
+			Provider:     provider,
+			Schema:       options.Schema,
+			Resources:    resources,
+			DataSources:  dataSources,
+			RemoteStates: cdktfRemoteStates(options, path, remoteStates),
+		}, path, options.Compact, options.Output)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := terraformoutput.OutputHclFiles(resources, provider, path, serviceName, options.Compact, options.Output, options.Collapse, !isTerragrunt)
+		if err != nil {
+			return err
+		}
+		// Print data sources looking up external references
+		if len(dataSources) > 0 {
+			dataFile, err := terraformutils.Print(map[string]interface{}{"data": dataSources}, map[string]struct{}{}, options.Output)
+			if err != nil {
+				return err
+			}
+			terraformoutput.PrintFile(path+"/data."+terraformoutput.GetFileExtension(options.Output), dataFile)
+		}
+	}
+	stateResources := resources
+	if options.PreviousResources != nil {
+		stateMoves, err := printMoves(provider, path, options, resources)
+		if err != nil {
+			return err
+		}
+		stateResources = withoutStateMoves(resources, stateMoves)
+	}
+	var previousTfStateFile []byte
+	if options.State != "bucket" {
+		// unchanged resources keep lineage and serial of already generated state
+		previousTfStateFile, _ = ioutil.ReadFile(path + "/terraform.tfstate")
+	}
+	tfStateFile, err := terraformutils.PrintTfStateWithPrevious(stateResources, previousTfStateFile)
 	if err != nil {
 		return err
 	}
@@ -264,9 +766,11 @@ func printService(provider terraformutils.ProviderGenerator, serviceName string,
 		if err := bucket.BucketUpload(path, tfStateFile); err != nil {
 			return err
 		}
-		// create Bucket file
-		if bucketStateDataFile, err := terraformutils.Print(bucket.BucketGetTfData(path), map[string]struct{}{}, options.Output); err == nil {
-			terraformoutput.PrintFile(path+"/bucket.tf", bucketStateDataFile)
+		// create Bucket file, backend of Terragrunt layout is generated by root terragrunt.hcl
+		if !isTerragrunt {
+			if bucketStateDataFile, err := terraformutils.Print(bucket.BucketGetTfData(path), map[string]struct{}{}, options.Output); err == nil {
+				terraformoutput.PrintFile(path+"/bucket.tf", bucketStateDataFile)
+			}
 		}
 	} else {
 		if serviceName == "" {
@@ -278,79 +782,153 @@ func printService(provider terraformutils.ProviderGenerator, serviceName string,
 			return err
 		}
 	}
+	// remote states are printed with cdktf code
+	if isCdktf || len(remoteStates)+len(dependencies) == 0 {
+		return nil
+	}
+	if len(remoteStates) == 0 {
+		terraformoutput.PrintFile(path+"/variables.tf", terraformutils.PrintTerragruntVariables(dependencies))
+		return nil
+	}
 	// Print hcl variables.tf
-	if serviceName != "" {
-		if options.Connect && len(provider.GetResourceConnections()[serviceName]) > 0 {
-			variables := map[string]map[string]map[string]interface{}{}
-			variables["data"] = map[string]map[string]interface{}{}
-			variables["data"]["terraform_remote_state"] = map[string]interface{}{}
-			if options.State == "bucket" {
-				bucket := terraformoutput.BucketState{
-					Name: options.Bucket,
-				}
-				for k := range provider.GetResourceConnections()[serviceName] {
-					if _, exist := importedResource[k]; !exist {
-						continue
-					}
-					variables["data"]["terraform_remote_state"][k] = map[string]interface{}{
-						"backend": "gcs",
-						"config":  bucket.BucketGetTfData(strings.ReplaceAll(path, serviceName, k)),
-					}
-				}
-			} else {
-				for k := range provider.GetResourceConnections()[serviceName] {
-					if _, exist := importedResource[k]; !exist {
-						continue
-					}
-					variables["data"]["terraform_remote_state"][k] = map[string]interface{}{
-						"backend": "local",
-						"config": map[string]interface{}{
-							"path": strings.Repeat("../", strings.Count(path, "/")) + strings.ReplaceAll(path, serviceName, k) + "terraform.tfstate",
-						},
-					}
-				}
+	variables := map[string]map[string]map[string]interface{}{}
+	variables["data"] = map[string]map[string]interface{}{}
+	variables["data"]["terraform_remote_state"] = map[string]interface{}{}
+	for name, statePath := range remoteStates {
+		if options.State == "bucket" {
+			bucket := terraformoutput.BucketState{
+				Name: options.Bucket,
 			}
-			// create variables file
-			if len(provider.GetResourceConnections()[serviceName]) > 0 && options.Connect && len(variables["data"]["terraform_remote_state"]) > 0 {
-				variablesFile, err := terraformutils.Print(variables, map[string]struct{}{"config": {}}, options.Output)
-				if err != nil {
-					return err
-				}
-				terraformoutput.PrintFile(path+"/variables."+terraformoutput.GetFileExtension(options.Output), variablesFile)
+			variables["data"]["terraform_remote_state"][name] = map[string]interface{}{
+				"backend": "gcs",
+				"config":  bucket.BucketGetTfData(statePath),
 			}
+			continue
 		}
-	} else {
-		if options.Connect {
-			variables := map[string]map[string]map[string]interface{}{}
-			variables["data"] = map[string]map[string]interface{}{}
-			variables["data"]["terraform_remote_state"] = map[string]interface{}{}
-			if options.State == "bucket" {
-				bucket := terraformoutput.BucketState{
-					Name: options.Bucket,
-				}
-				variables["data"]["terraform_remote_state"]["local"] = map[string]interface{}{
-					"backend": "gcs",
-					"config":  bucket.BucketGetTfData(path),
-				}
-			} else {
-				variables["data"]["terraform_remote_state"]["local"] = map[string]interface{}{
-					"backend": "local",
-					"config": map[string]interface{}{
-						"path": "terraform.tfstate",
-					},
-				}
+		localPath := "terraform.tfstate"
+		if statePath != path {
+			localPath = strings.Repeat("../", strings.Count(path, "/")) + statePath + "terraform.tfstate"
+		}
+		variables["data"]["terraform_remote_state"][name] = map[string]interface{}{
+			"backend": "local",
+			"config": map[string]interface{}{
+				"path": localPath,
+			},
+		}
+	}
+	// create variables file
+	variablesFile, err := terraformutils.Print(variables, map[string]struct{}{"config": {}}, options.Output)
+	if err != nil {
+		return err
+	}
+	if len(dependencies) > 0 {
+		variablesFile = append(append(variablesFile, '\n'), terraformutils.PrintTerragruntVariables(dependencies)...)
+	}
+	terraformoutput.PrintFile(path+"/variables."+terraformoutput.GetFileExtension(options.Output), variablesFile)
+	return nil
+}
+
+// printMoves writes moved blocks for resources renamed since the previous run and
+// a script of terraform state mv commands for resources generated in another directory,
+// moves of the script are returned
+func printMoves(provider terraformutils.ProviderGenerator, path string, options ImportOptions, resources []terraformutils.Resource) ([]terraformutils.ResourceMove, error) {
+	moved, stateMoves := options.PreviousResources.Moves(resources, path)
+	if terraformoutput.IsCdktfOutput(options.Output) {
+		// cdktf code can't declare moved blocks, resources renamed in the state which is
+		// overwritten now are already written under their new address
+		for _, move := range moved {
+			if filepath.Clean(move.FromState) != filepath.Clean(move.ToState) {
+				stateMoves = append(stateMoves, move)
 			}
-			// create variables file
-			if options.Connect {
-				variablesFile, err := terraformutils.Print(variables, map[string]struct{}{"config": {}}, options.Output)
-				if err != nil {
-					return err
-				}
-				terraformoutput.PrintFile(path+"/variables."+terraformoutput.GetFileExtension(options.Output), variablesFile)
+		}
+		moved = nil
+	}
+	if len(moved) > 0 {
+		log.Printf("%s write %d moved blocks", provider.GetName(), len(moved))
+		movedFile, err := terraformutils.PrintMovedBlocks(moved, options.Output)
+		if err != nil {
+			return nil, err
+		}
+		terraformoutput.PrintFile(path+"/moved."+terraformoutput.GetFileExtension(options.Output), movedFile)
+	}
+	if len(stateMoves) > 0 {
+		log.Printf("%s write %d terraform state mv commands", provider.GetName(), len(stateMoves))
+		return stateMoves, ioutil.WriteFile(path+"/state_mv.sh", terraformutils.PrintStateMvScript(stateMoves, path), os.ModePerm)
+	}
+	return nil, nil
+}
+
+// withoutStateMoves leaves out resources moved by state_mv.sh, terraform state mv
+// refuses to move them to a state which already has them
+func withoutStateMoves(resources []terraformutils.Resource, stateMoves []terraformutils.ResourceMove) []terraformutils.Resource {
+	if len(stateMoves) == 0 {
+		return resources
+	}
+	movedAddresses := map[string]struct{}{}
+	for _, move := range stateMoves {
+		movedAddresses[move.To] = struct{}{}
+	}
+	var stateResources []terraformutils.Resource
+	for _, resource := range resources {
+		if _, exist := movedAddresses[resource.Address()]; !exist {
+			stateResources = append(stateResources, resource)
+		}
+	}
+	return stateResources
+}
+
+// cdktfRemoteStates returns terraform_remote_state arguments of connected services,
+// local paths are relative to stack directory in cdktf.out/stacks
+func cdktfRemoteStates(options ImportOptions, path string, remoteStates map[string]string) map[string]map[string]interface{} {
+	cdktfRemoteStates := map[string]map[string]interface{}{}
+	for name, statePath := range remoteStates {
+		if options.State == "bucket" {
+			bucket := terraformoutput.BucketState{
+				Name: options.Bucket,
+			}
+			cdktfRemoteStates[name] = map[string]interface{}{
+				"backend": "gcs",
+				"config": map[string]interface{}{
+					"bucket": strings.ReplaceAll(options.Bucket, "gs://", ""),
+					"prefix": bucket.BucketPrefix(statePath),
+				},
 			}
+			continue
+		}
+		cdktfRemoteStates[name] = map[string]interface{}{
+			"backend": "local",
+			"config": map[string]interface{}{
+				"path": strings.Repeat("../", strings.Count(path, "/")+2) + statePath + "terraform.tfstate",
+			},
 		}
 	}
-	return nil
+	return cdktfRemoteStates
+}
+
+// reportPath is directory of files describing the run like plan.json, rather than resources.
+// Scope placeholders like {region} are resolved by values shared by all services of the run,
+// so reports of runs for several regions or projects don't overwrite each other.
+func reportPath(provider terraformutils.ProviderGenerator, options ImportOptions, scopes map[string]terraformutils.PathScope) string {
+	pathPattern := terraformutils.ResolveResourcePath(options.PathPattern, terraformutils.Resource{}, commonScope(scopes))
+	return Path(pathPattern, provider.GetName(), "terraformer", options.PathOutput)
+}
+
+// pathScope returns values of scope placeholders of path pattern from service args
+func pathScope(args map[string]interface{}) terraformutils.PathScope {
+	scope := terraformutils.PathScope{}
+	for _, key := range []string{"account", "project", "resource_group", "region"} {
+		switch value := args[key].(type) {
+		case string:
+			if value != "" {
+				scope[key] = value
+			}
+		case compute.Region:
+			if value.Name != "" {
+				scope[key] = value.Name
+			}
+		}
+	}
+	return scope
 }
 
 func Path(pathPattern, providerName, serviceName, output string) string {
@@ -367,6 +945,8 @@ func listCmd(provider terraformutils.ProviderGenerator) *cobra.Command {
 		Short: "List supported resources for " + provider.GetName() + " provider",
 		Long:  "List supported resources for " + provider.GetName() + " provider",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			schemaFile, _ := cmd.Flags().GetString("schema-file")
+			setSchemaFile(provider, schemaFile)
 			services := providerServices(provider)
 			for _, k := range services {
 				fmt.Println(k)
@@ -378,6 +958,13 @@ func listCmd(provider terraformutils.ProviderGenerator) *cobra.Command {
 	return cmd
 }
 
+// setSchemaFile passes --schema-file to providers reading their schema to list services
+func setSchemaFile(provider terraformutils.ProviderGenerator, schemaFile string) {
+	if setter, ok := provider.(terraformutils.SchemaFileSetter); ok && schemaFile != "" {
+		setter.SetSchemaFile(schemaFile)
+	}
+}
+
 func providerServices(provider terraformutils.ProviderGenerator) []string {
 	var services []string
 	for k := range provider.GetSupportedService() {
@@ -390,6 +977,7 @@ func providerServices(provider terraformutils.ProviderGenerator) []string {
 func baseProviderFlags(flag *pflag.FlagSet, options *ImportOptions, sampleRes, sampleFilters string) {
 	flag.BoolVarP(&options.Connect, "connect", "c", true, "")
 	flag.BoolVarP(&options.Compact, "compact", "C", false, "")
+	flag.BoolVarP(&options.Collapse, "collapse", "", false, "collapse resources differing only in a few attributes into for_each blocks")
 	flag.StringSliceVarP(&options.Resources, "resources", "r", []string{}, sampleRes)
 	flag.StringSliceVarP(&options.Excludes, "excludes", "x", []string{}, sampleRes)
 	flag.StringVarP(&options.PathPattern, "path-pattern", "p", DefaultPathPattern, "{output}/{provider}/")
@@ -397,8 +985,23 @@ func baseProviderFlags(flag *pflag.FlagSet, options *ImportOptions, sampleRes, s
 	flag.StringVarP(&options.State, "state", "s", DefaultState, "local or bucket")
 	flag.StringVarP(&options.Bucket, "bucket", "b", "", "gs://terraform-state")
 	flag.StringSliceVarP(&options.Filter, "filter", "f", []string{}, sampleFilters)
+	flag.StringVarP(&options.IDsFrom, "ids-from", "", "", "ids.csv or - for stdin - import only resources of IDs, one per line, in CSV with id, service and type columns or in JSON")
 	flag.BoolVarP(&options.Verbose, "verbose", "v", false, "")
-	flag.StringVarP(&options.Output, "output", "O", "hcl", "output format hcl or json")
+	flag.StringVarP(&options.Output, "output", "O", "hcl", "output format hcl, json, cdktf-typescript, cdktf-python or cdktf-go")
 	flag.IntVarP(&options.RetryCount, "retry-number", "n", 5, "number of retries to perform when refresh fails")
 	flag.IntVarP(&options.RetrySleepMs, "retry-sleep-ms", "m", 300, "time in ms to sleep between retries")
+	flag.StringSliceVarP(&options.ExcludeManaged, "exclude-managed", "", []string{}, "terraform.tfstate,states/,gs://terraform-state/prefix")
+	flag.StringVarP(&options.ExternalRefs, "external-refs", "", "", "data - look up references to services outside of the import with data sources")
+	flag.StringVarP(&options.Transform, "transform", "", "", "rules.json - transform generated resources with rules")
+	flag.BoolVarP(&options.TransformDryRun, "transform-dry-run", "", false, "print changes of transform rules without generating files")
+	flag.StringVarP(&options.Snapshot, "snapshot", "", "", "snapshot/ - record listed resources with refreshed state to regenerate code offline with terraformer regenerate")
+	flag.StringVarP(&options.SchemaFile, "schema-file", "", "", "schema.json - output of terraform providers schema -json, used instead of asking the plugin for its schema")
+	flag.StringVarP(&options.ShowProgress, "progress", "", "auto", "auto, always or never - draw progress of listing and refresh, auto draws only to a terminal")
+	flag.StringVarP(&options.LogFile, "log-file", "", "", "write logs to file instead of stderr")
+	flag.StringVarP(&options.Telemetry, "telemetry", "", "", "otlp or json - export OpenTelemetry spans and metrics")
+	flag.StringVarP(&options.TelemetryFile, "telemetry-file", "", "telemetry.json", "file of json telemetry")
+	flag.StringVarP(&options.Previous, "previous", "", "", "generated/ or terraform.tfstate - write moved blocks for resources with changed addresses")
+	flag.StringVarP(&options.Policy, "policy", "", "", "policies/ - evaluate Rego policies against refreshed resources")
+	flag.StringVarP(&options.Layout, "layout", "", "terraform", "terraform or terragrunt - write terragrunt.hcl files with dependencies between services")
+	flag.BoolVarP(&options.Annotate, "annotate", "", false, "write comments with ID, scope, import time and metadata above resources")
 }
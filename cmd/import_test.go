@@ -0,0 +1,545 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/terraformer/providers/rabbitmq"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformertest"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata/golden")
+
+func TestImportGolden(t *testing.T) {
+	isolatePlugins(t)
+
+	testCases := map[string]ImportOptions{
+		"services": {
+			PathPattern: DefaultPathPattern,
+			Connect:     true,
+			Output:      "hcl",
+		},
+		"compact": {
+			PathPattern: "{output}/{provider}/",
+			Connect:     true,
+			Compact:     true,
+			Collapse:    true,
+			Output:      "hcl",
+		},
+		"json": {
+			PathPattern: DefaultPathPattern,
+			Connect:     true,
+			Output:      "json",
+		},
+		"cdktf-typescript": {
+			PathPattern: DefaultPathPattern,
+			Connect:     true,
+			Output:      "cdktf-typescript",
+		},
+		"cdktf-python": {
+			PathPattern: DefaultPathPattern,
+			Connect:     true,
+			Output:      "cdktf-python",
+		},
+		"paths": {
+			PathPattern: "{output}/{provider}/{attr:vhost}/{service}/",
+			Connect:     true,
+			Output:      "hcl",
+		},
+		"terragrunt": {
+			PathPattern: DefaultPathPattern,
+			Connect:     true,
+			Output:      "hcl",
+			Layout:      "terragrunt",
+		},
+		"cdktf-go": {
+			PathPattern: "{output}/{provider}/",
+			Connect:     true,
+			Compact:     true,
+			Output:      "cdktf-go",
+		},
+	}
+	for name, options := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// generated remote state paths are relative to working directory
+			testdataPath := chdirTemp(t)
+			options.Resources = []string{"vhosts", "exchanges", "queues"}
+			options.PathOutput = DefaultPathOutput
+			options.State = DefaultState
+
+			runImport(t, testdataPath, options)
+			firstRun := readTree(t, options.PathOutput)
+			runImport(t, testdataPath, options)
+			secondRun := readTree(t, options.PathOutput)
+			compareTrees(t, "second run", secondRun, firstRun)
+
+			goldenPath := filepath.Join(testdataPath, "golden", name)
+			if *update {
+				writeTree(t, goldenPath, firstRun)
+			}
+			compareTrees(t, "golden files", firstRun, readTree(t, goldenPath))
+		})
+	}
+}
+
+// chdirTemp changes working directory to a temporary directory until the test ends
+// and returns absolute path of testdata
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	workingDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(workingDir)
+	})
+	return filepath.Join(workingDir, "testdata")
+}
+
+// isolatePlugins hides installed plugins, provider version in provider.tf is read from them
+func isolatePlugins(t *testing.T) {
+	t.Helper()
+	for _, env := range []string{"HOME", "TF_DATA_DIR"} {
+		env := env
+		value, isSet := os.LookupEnv(env)
+		if err := os.Setenv(env, t.TempDir()); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			if isSet {
+				_ = os.Setenv(env, value)
+			} else {
+				_ = os.Unsetenv(env)
+			}
+		})
+	}
+}
+
+func runImport(t *testing.T, testdataPath string, options ImportOptions) {
+	t.Helper()
+	if err := runImportContext(context.Background(), t, testdataPath, options); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runImportContext imports resources of the rabbitmq cassette with ctx and returns error of the import
+func runImportContext(ctx context.Context, t *testing.T, testdataPath string, options ImportOptions) error {
+	t.Helper()
+	server := terraformertest.NewServer(t, filepath.Join(testdataPath, "rabbitmq", "cassette.json"), os.Getenv("RABBITMQ_SERVER_URL"))
+	providerWrapper := terraformertest.NewProviderWrapper(t, "rabbitmq", cty.ObjectVal(map[string]cty.Value{
+		"endpoint": cty.StringVal(os.Getenv("RABBITMQ_SERVER_URL")),
+		"username": cty.StringVal(os.Getenv("RABBITMQ_USERNAME")),
+		"password": cty.StringVal(os.Getenv("RABBITMQ_PASSWORD")),
+	}), filepath.Join(testdataPath, "rabbitmq", "schema.json"), filepath.Join(testdataPath, "rabbitmq", "resources.json"))
+	provider := &rabbitmq.RBTProvider{}
+	args := []string{server.URL, os.Getenv("RABBITMQ_USERNAME"), os.Getenv("RABBITMQ_PASSWORD")}
+	options, err := initOptions(provider, options, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return importWithProviderWrapper(ctx, provider, options, args, providerWrapper)
+}
+
+// readTree returns content of all files in dir by relative path
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relativePath)] = string(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func compareTrees(t *testing.T, name string, actual, expected map[string]string) {
+	t.Helper()
+	for path, content := range expected {
+		actualContent, exist := actual[path]
+		if !exist {
+			t.Errorf("%s: missing file %s", name, path)
+			continue
+		}
+		if actualContent != content {
+			t.Errorf("%s: file %s differs, got:\n%s\nexpected:\n%s", name, path, actualContent, content)
+		}
+	}
+	for path := range actual {
+		if _, exist := expected[path]; !exist {
+			t.Errorf("%s: unexpected file %s", name, path)
+		}
+	}
+}
+
+func TestImportPrevious(t *testing.T) {
+	testdataPath := chdirTemp(t)
+
+	options := ImportOptions{
+		Resources:   []string{"vhosts", "exchanges", "queues"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Connect:     true,
+		Output:      "hcl",
+	}
+	runImport(t, testdataPath, options)
+
+	// same resources moved from service directories into one directory
+	options.PathPattern = "{output}/{provider}/"
+	options.Compact = true
+	options.Previous = DefaultPathOutput
+	runImport(t, testdataPath, options)
+
+	script, err := ioutil.ReadFile(filepath.Join(DefaultPathOutput, "rabbitmq", "state_mv.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "terraform state mv -state='vhosts/terraform.tfstate' -state-out='terraform.tfstate' 'rabbitmq_vhost.tfer--vhost_prod' 'rabbitmq_vhost.tfer--vhost_prod'\n"
+	if !strings.Contains(string(script), expected) {
+		t.Errorf("missing state mv command of vhost, got:\n%s", script)
+	}
+	if _, err := os.Stat(filepath.Join(DefaultPathOutput, "rabbitmq", "moved.tf")); !os.IsNotExist(err) {
+		t.Errorf("unexpected moved.tf for resources of other directories")
+	}
+	// moved resources are added to the new state by the script
+	state, err := ioutil.ReadFile(filepath.Join(DefaultPathOutput, "rabbitmq", "terraform.tfstate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(state), "tfer--vhost_prod") {
+		t.Errorf("resource moved by state mv is in the new state:\n%s", state)
+	}
+}
+
+func TestImportTelemetry(t *testing.T) {
+	testdataPath := chdirTemp(t)
+
+	options := ImportOptions{
+		Resources:     []string{"vhosts", "queues"},
+		PathPattern:   DefaultPathPattern,
+		PathOutput:    DefaultPathOutput,
+		State:         DefaultState,
+		Output:        "hcl",
+		Telemetry:     "json",
+		TelemetryFile: "telemetry.json",
+	}
+	if err := setupTelemetry(options); err != nil {
+		t.Fatal(err)
+	}
+	runImport(t, testdataPath, options)
+	if err := shutdownTelemetry(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile("telemetry.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`"Name":"import"`, `"Name":"list vhosts"`, `"Name":"refresh rabbitmq_queue"`,
+		`"Name":"convert"`, `"Name":"write queues"`, `terraformer.resources.listed{`, `terraformer.refresh.duration{`} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("missing %s in telemetry", expected)
+		}
+	}
+}
+
+func TestImportPolicy(t *testing.T) {
+	testdataPath := chdirTemp(t)
+	policies := `package terraformer.vhosts
+
+deny[msg] {
+	input.type == "rabbitmq_vhost"
+	input.item.name == "/"
+	msg := "default vhost is used"
+}
+`
+	if err := os.Mkdir("policies", os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join("policies", "vhosts.rego"), []byte(policies), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	options := ImportOptions{
+		Resources:   []string{"vhosts", "exchanges", "queues"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Connect:     true,
+		Output:      "hcl",
+		Policy:      "policies",
+	}
+	runImport(t, testdataPath, options)
+
+	generated := readTree(t, DefaultPathOutput)
+	report := generated["rabbitmq/terraformer/policy_findings.json"]
+	expected := `"policy": "vhosts",
+      "level": "deny",
+      "message": "default vhost is used",
+      "service": "vhosts",
+      "address": "rabbitmq_vhost.tfer--vhost_slash"`
+	if !strings.Contains(report, expected) || strings.Count(report, `"policy"`) != 1 {
+		t.Errorf("unexpected policy findings:\n%s", report)
+	}
+	// findings don't change generated code
+	delete(generated, "rabbitmq/terraformer/policy_findings.json")
+	compareTrees(t, "golden files", generated, readTree(t, filepath.Join(testdataPath, "golden", "services")))
+}
+
+func TestReportPath(t *testing.T) {
+	options := ImportOptions{PathPattern: "{output}/{provider}/{region}/{service}/", PathOutput: DefaultPathOutput}
+	scopes := map[string]terraformutils.PathScope{
+		"vpc":    {"region": "eu-west-1", "account": "123"},
+		"subnet": {"region": "eu-west-1"},
+	}
+	if path := reportPath(&rabbitmq.RBTProvider{}, options, scopes); path != "generated/rabbitmq/eu-west-1/terraformer/" {
+		t.Errorf("wrong report path %s", path)
+	}
+	if path := reportPath(&rabbitmq.RBTProvider{}, options, nil); path != "generated/rabbitmq/terraformer/" {
+		t.Errorf("wrong report path without scopes %s", path)
+	}
+}
+
+func TestImportAnnotate(t *testing.T) {
+	testdataPath := chdirTemp(t)
+	defer func() {
+		importTime = time.Now
+	}()
+	importTime = func() time.Time {
+		return time.Date(2021, 5, 6, 7, 8, 9, 0, time.UTC)
+	}
+
+	options := ImportOptions{
+		Resources:   []string{"vhosts"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Connect:     true,
+		Output:      "hcl",
+		Annotate:    true,
+	}
+	runImport(t, testdataPath, options)
+
+	generated := readTree(t, DefaultPathOutput)
+	expected := `# id: prod
+# imported: 2021-05-06T07:08:09Z, terraformer ` + version + `
+resource "rabbitmq_vhost" "tfer--vhost_prod" {
+  name = "prod"
+}
+
+# id: /
+# imported: 2021-05-06T07:08:09Z, terraformer ` + version + `
+resource "rabbitmq_vhost" "tfer--vhost_slash" {
+  name = "/"
+}
+`
+	if generated["rabbitmq/vhosts/vhost.tf"] != expected {
+		t.Errorf("unexpected annotated resources:\n%s", generated["rabbitmq/vhosts/vhost.tf"])
+	}
+	// state is not affected by comments
+	golden := readTree(t, filepath.Join(testdataPath, "golden", "services", "rabbitmq", "vhosts"))
+	if generated["rabbitmq/vhosts/terraform.tfstate"] != golden["terraform.tfstate"] {
+		t.Errorf("unexpected state:\n%s", generated["rabbitmq/vhosts/terraform.tfstate"])
+	}
+}
+
+func TestImportTransformIgnoreKeys(t *testing.T) {
+	testdataPath := chdirTemp(t)
+	rules := `{"rules": [{"types": ["rabbitmq_queue"], "ignore_keys": ["^settings\\.[0-9]+\\.auto_delete$"]}]}`
+	if err := ioutil.WriteFile("rules.json", []byte(rules), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	options := ImportOptions{
+		Resources:   []string{"queues"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Output:      "hcl",
+		Transform:   "rules.json",
+	}
+	runImport(t, testdataPath, options)
+
+	expected := `resource "rabbitmq_queue" "tfer--queue_prod_events" {
+  name = "events"
+
+  settings {
+    durable = true
+  }
+
+  vhost = "prod"
+}
+
+resource "rabbitmq_queue" "tfer--queue_slash_orders" {
+  name = "orders"
+
+  settings {
+    durable = false
+  }
+
+  vhost = "/"
+}
+`
+	generated := readTree(t, DefaultPathOutput)
+	if generated["rabbitmq/queues/queue.tf"] != expected {
+		t.Errorf("ignore_keys of transform rules weren't applied:\n%s", generated["rabbitmq/queues/queue.tf"])
+	}
+}
+
+func TestImportIDsFrom(t *testing.T) {
+	testdataPath := chdirTemp(t)
+	ids := "ids.csv"
+	if err := ioutil.WriteFile(ids, []byte("service,id\nqueues,events@prod\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	options := ImportOptions{
+		Resources:   []string{"vhosts", "queues"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Connect:     true,
+		Output:      "hcl",
+		IDsFrom:     ids,
+	}
+	runImport(t, testdataPath, options)
+
+	generated := readTree(t, DefaultPathOutput)
+	if _, ok := generated["rabbitmq/vhosts/vhost.tf"]; ok {
+		t.Errorf("vhosts without IDs are imported")
+	}
+	queues := generated["rabbitmq/queues/queue.tf"]
+	if !strings.Contains(queues, `"tfer--queue_prod_events"`) || strings.Contains(queues, `"tfer--queue_slash_orders"`) {
+		t.Errorf("unexpected queues:\n%s", queues)
+	}
+
+	// next import, e.g. of a watch, reads changed IDs
+	if err := ioutil.WriteFile(ids, []byte("service,id\nqueues,orders@/\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	runImport(t, testdataPath, options)
+	queues = readTree(t, DefaultPathOutput)["rabbitmq/queues/queue.tf"]
+	if strings.Contains(queues, `"tfer--queue_prod_events"`) || !strings.Contains(queues, `"tfer--queue_slash_orders"`) {
+		t.Errorf("changed IDs weren't read again, got queues:\n%s", queues)
+	}
+}
+
+func TestImportProviderAliases(t *testing.T) {
+	isolatePlugins(t)
+	testdataPath := chdirTemp(t)
+
+	options := ImportOptions{
+		Resources:       []string{"vhosts", "queues"},
+		PathPattern:     "{output}/{provider}/",
+		PathOutput:      DefaultPathOutput,
+		State:           DefaultState,
+		Connect:         true,
+		Compact:         true,
+		Output:          "hcl",
+		ProviderAliases: true,
+		AliasedImport:   newAliasedImport(),
+	}
+	for _, alias := range []string{"eu_west_1", "us_east_1"} {
+		options.ProviderAlias = alias
+		runImport(t, testdataPath, options)
+	}
+	if err := options.AliasedImport.print(); err != nil {
+		t.Fatal(err)
+	}
+
+	generated := readTree(t, DefaultPathOutput)
+	for _, expected := range []string{
+		"provider \"rabbitmq\" {\n  alias = \"eu_west_1\"\n}",
+		"provider \"rabbitmq\" {\n  alias = \"us_east_1\"\n}",
+	} {
+		if !strings.Contains(generated["rabbitmq/provider.tf"], expected) {
+			t.Errorf("missing %s in provider.tf:\n%s", expected, generated["rabbitmq/provider.tf"])
+		}
+	}
+	for _, expected := range []string{
+		"resource \"rabbitmq_vhost\" \"tfer--vhost_prod\" {\n  name     = \"prod\"\n  provider = rabbitmq.eu_west_1\n}",
+		"resource \"rabbitmq_vhost\" \"tfer--vhost_prod_us_east_1\" {\n  name     = \"prod\"\n  provider = rabbitmq.us_east_1\n}",
+	} {
+		if !strings.Contains(generated["rabbitmq/resources.tf"], expected) {
+			t.Errorf("missing %s in resources.tf:\n%s", expected, generated["rabbitmq/resources.tf"])
+		}
+	}
+	if !strings.Contains(generated["rabbitmq/terraform.tfstate"], `"provider": "provider.rabbitmq.us_east_1"`) {
+		t.Errorf("missing aliased provider in state:\n%s", generated["rabbitmq/terraform.tfstate"])
+	}
+}
+
+func TestGooglePathPattern(t *testing.T) {
+	for pathPattern, expected := range map[string]string{
+		DefaultPathPattern:                     "{output}/{provider}/my-project/{service}/europe-west1/",
+		"{output}/{provider}/{service}/{type}": "{output}/{provider}/my-project/{service}/europe-west1/{type}",
+		"{output}/{provider}/{tag:team}/":      "{output}/{provider}/{tag:team}/my-project/europe-west1/",
+		"{output}/{project}/{service}/":        "{output}/{project}/{service}/",
+		"{output}/{region}/{service}/":         "{output}/{region}/{service}/",
+	} {
+		if actual := googlePathPattern(pathPattern, "my-project", "europe-west1", true); actual != expected {
+			t.Errorf("wrong path pattern of %s, got %s, expected %s", pathPattern, actual, expected)
+		}
+	}
+	// custom patterns of a single project and region are kept as they are
+	if actual := googlePathPattern("{output}/{provider}/{tag:team}/", "my-project", "europe-west1", false); actual != "{output}/{provider}/{tag:team}/" {
+		t.Errorf("wrong path pattern of single project and region, got %s", actual)
+	}
+	if actual := googlePathPattern(DefaultPathPattern, "my-project", "europe-west1", false); actual != "{output}/{provider}/my-project/{service}/europe-west1/" {
+		t.Errorf("wrong default path pattern of single project and region, got %s", actual)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/spf13/cobra"
+)
+
+func newInventoryCmd() *cobra.Command {
+	options := ImportOptions{
+		Inventory: terraformutils.NewInventory(),
+	}
+	format := ""
+	cmd := &cobra.Command{
+		Use:           "inventory",
+		Short:         "List resources without refresh or code generation",
+		Long:          "List resources without refresh or code generation",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			// resources of other services are printed even if some services failed
+			if err := options.Inventory.Write(cmd.OutOrStdout(), format); err != nil {
+				return err
+			}
+			return options.Inventory.Err()
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&format, "format", "", "table", "table, csv or json")
+
+	for _, subcommand := range providerImporterSubcommands() {
+		cmd.AddCommand(subcommand(options))
+	}
+	return cmd
+}
+
+// importInventory lists resources of services like Import, skipping refresh and output
+func importInventory(provider terraformutils.ProviderGenerator, options ImportOptions, args []string) error {
+	options, err := initOptions(provider, options, args)
+	if err != nil {
+		return err
+	}
+	managedResources, err := loadManagedResources(options.ExcludeManaged)
+	if err != nil {
+		return err
+	}
+
+	providerMapping := terraformutils.NewProvidersMapping(provider)
+	for _, service := range options.Resources {
+		serviceProvider := providerMapping.AddServiceToProvider(service)
+		if err := serviceProvider.Init(args); err != nil {
+			return err
+		}
+		if err := initServiceResources(service, serviceProvider, options, nil, managedResources); err != nil {
+			scope := ""
+			if serviceProvider.GetService() != nil {
+				scope = inventoryScope(serviceProvider.GetService().GetArgs())
+			}
+			options.Inventory.AddFailure(provider.GetName(), scope, service, err)
+			continue
+		}
+		options.Inventory.Add(provider.GetName(), inventoryScope(serviceProvider.GetService().GetArgs()),
+			service, serviceProvider.GetService().GetResources())
+	}
+	return nil
+}
+
+// inventoryScope describes where resources were listed from service args,
+// other args like credentials are never printed
+func inventoryScope(args map[string]interface{}) string {
+	var scope []string
+	values := pathScope(args)
+	for _, key := range []string{"project", "resource_group", "region"} {
+		if values[key] != "" {
+			scope = append(scope, values[key])
+		}
+	}
+	return strings.Join(scope, "/")
+}
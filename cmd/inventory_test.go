@@ -0,0 +1,67 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/providers/rabbitmq"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformertest"
+	"google.golang.org/api/compute/v1"
+)
+
+func TestImportInventory(t *testing.T) {
+	server := terraformertest.NewServer(t, filepath.Join("testdata", "rabbitmq", "cassette.json"), os.Getenv("RABBITMQ_SERVER_URL"))
+	options := ImportOptions{
+		Resources: []string{"*"},
+		Excludes:  []string{"bindings", "exchanges", "permissions", "policies", "shovels", "users"},
+		Filter:    []string{"queue=events@prod"},
+		Inventory: terraformutils.NewInventory(),
+	}
+	// no provider plugin is started, import would fail without one
+	err := Import(&rabbitmq.RBTProvider{}, options, []string{server.URL, os.Getenv("RABBITMQ_USERNAME"), os.Getenv("RABBITMQ_PASSWORD")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []terraformutils.InventoryItem{
+		{Provider: "rabbitmq", Service: "queues", Type: "rabbitmq_queue", ID: "events@prod", Name: "tfer--queue_prod_events"},
+		{Provider: "rabbitmq", Service: "vhosts", Type: "rabbitmq_vhost", ID: "/", Name: "tfer--vhost_slash"},
+		{Provider: "rabbitmq", Service: "vhosts", Type: "rabbitmq_vhost", ID: "prod", Name: "tfer--vhost_prod"},
+	}
+	items := options.Inventory.Items()
+	if len(items) != len(expected) {
+		t.Fatalf("wrong inventory %v", items)
+	}
+	for i := range expected {
+		if items[i] != expected[i] {
+			t.Errorf("wrong inventory item %v, expected %v", items[i], expected[i])
+		}
+	}
+}
+
+func TestInventoryScope(t *testing.T) {
+	scope := inventoryScope(map[string]interface{}{
+		"project":  "my-project",
+		"region":   compute.Region{Name: "europe-west1"},
+		"password": "secret",
+	})
+	if scope != "my-project/europe-west1" {
+		t.Errorf("wrong scope %s", scope)
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"path/filepath"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformoutput"
 	"github.com/spf13/cobra"
 )
 
@@ -30,6 +32,10 @@ type ImportPlan struct {
 	Options          ImportOptions
 	Args             []string
 	ImportedResource map[string][]terraformutils.Resource
+	// PathScopes are values of scope placeholders of path pattern by service
+	PathScopes map[string]terraformutils.PathScope `json:",omitempty"`
+	// ProviderAliases are configurations of provider by alias of resources, "" is the default configuration
+	ProviderAliases map[string]map[string]interface{} `json:",omitempty"`
 }
 
 func newPlanCmd() *cobra.Command {
@@ -63,13 +69,15 @@ func newCmdPlanImporter(options ImportOptions) *cobra.Command {
 				return err
 			}
 
-			var provider terraformutils.ProviderGenerator
-			if providerGen, ok := providerGenerators()[plan.Provider]; ok {
-				provider = providerGen()
-			} else {
-				return fmt.Errorf("unsupported provider: %s", plan.Provider)
+			provider, err := importedProvider(plan.Provider, plan.Args)
+			if err != nil {
+				return err
 			}
 
+			if options.SchemaFile != "" {
+				plan.Options.SchemaFile = options.SchemaFile
+			}
+			setSchemaFile(provider, plan.Options.SchemaFile)
 			if err = provider.Init(plan.Args); err != nil {
 				return err
 			}
@@ -80,12 +88,32 @@ func newCmdPlanImporter(options ImportOptions) *cobra.Command {
 				}
 			}
 
+			if terraformoutput.IsCdktfOutput(plan.Options.Output) {
+				plan.Options.Schema, err = providerwrapper.LoadSchema(provider.GetName(), plan.Options.SchemaFile, plan.Options.Verbose)
+				if err != nil {
+					return err
+				}
+			}
+
 			return ImportFromPlan(provider, plan)
 		},
 	}
+	cmd.Flags().StringVarP(&options.SchemaFile, "schema-file", "", "", "schema.json - output of terraform providers schema -json, used instead of asking the plugin for its schema")
 	return cmd
 }
 
+// importedProvider returns provider of a recorded import by its name and arguments
+func importedProvider(name string, args []string) (terraformutils.ProviderGenerator, error) {
+	if providerGen, ok := providerGenerators()[name]; ok {
+		return providerGen(), nil
+	}
+	if len(args) > 0 && args[0] == name {
+		// imports of `import generic` are named by the provider plugin, passed as first argument
+		return newGenericProvider(), nil
+	}
+	return nil, fmt.Errorf("unsupported provider: %s", name)
+}
+
 func LoadPlanfile(path string) (*ImportPlan, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -0,0 +1,61 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/providers/rabbitmq"
+)
+
+func TestImportPlanWithSchemaFile(t *testing.T) {
+	isolatePlugins(t)
+	if err := os.Setenv("TERRAFORMER_SCHEMA_CACHE_DIR", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("TERRAFORMER_SCHEMA_CACHE_DIR")
+
+	testdataPath := chdirTemp(t)
+
+	options := ImportOptions{
+		Resources:   []string{"vhosts", "exchanges", "queues"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Connect:     true,
+		Output:      "cdktf-typescript",
+		Plan:        true,
+	}
+	runImport(t, testdataPath, options)
+	planPath := filepath.Join(reportPath(&rabbitmq.RBTProvider{}, options, nil), "plan.json")
+
+	// no plugin is installed, cdktf output needs the schema
+	cmd := newCmdPlanImporter(ImportOptions{})
+	cmd.SetArgs([]string{planPath})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error without plugin and schema file")
+	}
+
+	cmd = newCmdPlanImporter(ImportOptions{})
+	cmd.SetArgs([]string{planPath, "--schema-file", filepath.Join(testdataPath, "rabbitmq", "schema.json")})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	generated := readTree(t, DefaultPathOutput)
+	delete(generated, planPath[len(DefaultPathOutput)+1:])
+	compareTrees(t, "golden files", generated, readTree(t, filepath.Join(testdataPath, "golden", "cdktf-typescript")))
+}
@@ -0,0 +1,363 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+const progressBarWidth = 20
+
+// progressDisplay redraws listing and refresh progress of services in place
+// and prints a summary table when the import ends
+type progressDisplay struct {
+	w        io.Writer
+	provider string
+	now      func() time.Time
+	started  time.Time
+
+	mu             sync.Mutex
+	services       []string
+	progress       map[string]*serviceProgress
+	refreshStarted time.Time
+	written        int
+	findings       int
+	evaluated      bool
+	lines          int
+
+	stop    chan struct{}
+	stopped chan struct{}
+	// logWriter gets logs written while drawing above the frame, it's restored when the display finishes
+	logWriter io.Writer
+}
+
+// progressLogWriter passes logs through above the progress frame, so logs written before
+// log.Fatal or a panic are not lost
+type progressLogWriter struct {
+	d *progressDisplay
+}
+
+func (w progressLogWriter) Write(p []byte) (int, error) {
+	d := w.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.lines > 0 {
+		// move to first line of the frame and clear it
+		_, _ = fmt.Fprintf(d.w, "\x1b[%dA\x1b[J", d.lines)
+		d.lines = 0
+	}
+	n, err := d.logWriter.Write(p)
+	d.drawFrame()
+	return n, err
+}
+
+type serviceProgress struct {
+	status     string
+	err        error
+	listed     int
+	refreshing int
+	refreshed  int
+	failed     int
+	retries    int
+	written    int
+}
+
+var (
+	logFileMu sync.Mutex
+	logFile   *os.File
+)
+
+// progressEnabled decides whether to draw progress, auto draws only to a terminal
+func progressEnabled(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "auto", "":
+		return isTerminal(f), nil
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported progress mode %s", mode)
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// setupLogFile moves logs to path on first import, imports of all regions or projects share it
+func setupLogFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	logFileMu.Lock()
+	defer logFileMu.Unlock()
+	if logFile != nil {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	logFile = f
+	log.SetOutput(f)
+	return nil
+}
+
+func closeLogFile() error {
+	logFileMu.Lock()
+	defer logFileMu.Unlock()
+	if logFile == nil {
+		return nil
+	}
+	log.SetOutput(os.Stderr)
+	err := logFile.Close()
+	logFile = nil
+	return err
+}
+
+func newProgressDisplay(w io.Writer, provider string, services []string) *progressDisplay {
+	d := &progressDisplay{
+		w:        w,
+		provider: provider,
+		now:      time.Now,
+		services: services,
+		progress: map[string]*serviceProgress{},
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	for _, service := range services {
+		d.progress[service] = &serviceProgress{status: "waiting"}
+	}
+	d.started = d.now()
+	return d
+}
+
+// Start redraws progress until Finish, logs are printed above the progress unless they're written to a log file
+func (d *progressDisplay) Start(interval time.Duration) {
+	logFileMu.Lock()
+	if logFile == nil {
+		d.logWriter = log.Writer()
+		log.SetOutput(progressLogWriter{d: d})
+	}
+	logFileMu.Unlock()
+	go func() {
+		defer close(d.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+				d.draw()
+			}
+		}
+	}()
+}
+
+// Finish draws final progress followed by the summary
+func (d *progressDisplay) Finish() {
+	close(d.stop)
+	<-d.stopped
+	if d.logWriter != nil {
+		log.SetOutput(d.logWriter)
+	}
+	d.draw()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, _ = d.w.Write([]byte(d.summary()))
+}
+
+// Handle updates progress with an event of the import
+func (d *progressDisplay) Handle(event terraformutils.ProgressEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch event.Stage {
+	case terraformutils.ProgressWritten:
+		d.written += event.Resources
+	case terraformutils.ProgressEvaluated:
+		d.evaluated = true
+		d.findings += event.Findings
+	}
+	progress, exist := d.progress[event.Service]
+	if !exist {
+		return
+	}
+	switch event.Stage {
+	case terraformutils.ProgressListing:
+		progress.status = "listing"
+	case terraformutils.ProgressListed:
+		if event.Err != nil {
+			progress.status = "failed"
+			progress.err = event.Err
+			return
+		}
+		progress.status = "listed"
+		progress.listed = event.Resources
+	case terraformutils.ProgressRefreshing:
+		if d.refreshStarted.IsZero() {
+			d.refreshStarted = d.now()
+		}
+		progress.status = "refreshing"
+		progress.refreshing += event.Resources
+	case terraformutils.ProgressRefreshed:
+		progress.refreshed += event.Resources
+		progress.retries += event.Retries
+		if event.Err != nil {
+			progress.failed += event.Resources
+		}
+		if progress.refreshed == progress.refreshing {
+			progress.status = "refreshed"
+		}
+	case terraformutils.ProgressWritten:
+		progress.status = "written"
+		progress.written += event.Resources
+	}
+}
+
+func (d *progressDisplay) draw() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drawFrame()
+}
+
+// drawFrame overwrites the previous frame, d.mu must be held
+func (d *progressDisplay) drawFrame() {
+	var frame bytes.Buffer
+	if d.lines > 0 {
+		// move to first line of previous frame
+		fmt.Fprintf(&frame, "\x1b[%dA", d.lines)
+	}
+	lines := d.render()
+	for _, line := range lines {
+		frame.WriteString("\x1b[2K" + line + "\n")
+	}
+	d.lines = len(lines)
+	_, _ = d.w.Write(frame.Bytes())
+}
+
+// render returns a header line and a line for each service, d.mu must be held
+func (d *progressDisplay) render() []string {
+	listed, refreshing, refreshed, retries := 0, 0, 0, 0
+	nameWidth := 0
+	for _, service := range d.services {
+		progress := d.progress[service]
+		if progress.status != "waiting" && progress.status != "listing" {
+			listed++
+		}
+		refreshing += progress.refreshing
+		refreshed += progress.refreshed
+		retries += progress.retries
+		if len(service) > nameWidth {
+			nameWidth = len(service)
+		}
+	}
+	header := fmt.Sprintf("%s: listed %d/%d services", d.provider, listed, len(d.services))
+	if refreshing > 0 {
+		header += fmt.Sprintf(", refreshed %d/%d resources", refreshed, refreshing)
+		if eta, ok := d.eta(refreshed, refreshing); ok {
+			header += ", ETA " + eta.String()
+		}
+	}
+	if retries > 0 {
+		header += fmt.Sprintf(", %d retries", retries)
+	}
+	lines := []string{header}
+	for _, service := range d.services {
+		progress := d.progress[service]
+		line := fmt.Sprintf("  %-*s  %-10s", nameWidth, service, progress.status)
+		switch {
+		case progress.err != nil:
+			line += " " + progress.err.Error()
+		case progress.refreshing > 0:
+			line += " " + progressBar(progress.refreshed, progress.refreshing) + fmt.Sprintf(" %d/%d", progress.refreshed, progress.refreshing)
+			if progress.failed > 0 {
+				line += fmt.Sprintf(", %d failed", progress.failed)
+			}
+			if progress.retries > 0 {
+				line += fmt.Sprintf(", %d retries", progress.retries)
+			}
+		case progress.status == "listed":
+			line += fmt.Sprintf(" %d resources", progress.listed)
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return lines
+}
+
+// eta estimates remaining refresh time from rate of refreshed resources
+func (d *progressDisplay) eta(refreshed, refreshing int) (time.Duration, bool) {
+	if refreshed == 0 || refreshed >= refreshing {
+		return 0, false
+	}
+	elapsed := d.now().Sub(d.refreshStarted)
+	remaining := time.Duration(float64(elapsed) / float64(refreshed) * float64(refreshing-refreshed))
+	return remaining.Round(time.Second), true
+}
+
+// summary returns table of all services, d.mu must be held
+func (d *progressDisplay) summary() string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "SERVICE\tLISTED\tREFRESHED\tFAILED\tRETRIES\tWRITTEN")
+	total := serviceProgress{}
+	var failedServices []string
+	for _, service := range d.services {
+		progress := d.progress[service]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\n", service, progress.listed, progress.refreshed-progress.failed,
+			progress.failed, progress.retries, progress.written)
+		total.listed += progress.listed
+		total.refreshed += progress.refreshed - progress.failed
+		total.failed += progress.failed
+		total.retries += progress.retries
+		if progress.err != nil {
+			failedServices = append(failedServices, fmt.Sprintf("%s: %v", service, progress.err))
+		}
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%d\t%d\t%d\n", total.listed, total.refreshed, total.failed, total.retries, d.written)
+	_ = tw.Flush()
+	for _, failedService := range failedServices {
+		fmt.Fprintln(&buf, "failed to list "+failedService)
+	}
+	if d.evaluated {
+		fmt.Fprintf(&buf, "%d policy findings\n", d.findings)
+	}
+	fmt.Fprintf(&buf, "%s done in %s\n", d.provider, d.now().Sub(d.started).Round(time.Second))
+	return buf.String()
+}
+
+func progressBar(done, total int) string {
+	filled := 0
+	if total > 0 {
+		filled = done * progressBarWidth / total
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled) + "]"
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+func TestProgressDisplay(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	display := newProgressDisplay(&buf, "rabbitmq", []string{"vhosts", "queues", "users"})
+	display.now = func() time.Time { return now }
+	display.started = now
+
+	for _, event := range []terraformutils.ProgressEvent{
+		{Stage: terraformutils.ProgressListing, Service: "vhosts"},
+		{Stage: terraformutils.ProgressListed, Service: "vhosts", Resources: 2},
+		{Stage: terraformutils.ProgressListing, Service: "queues"},
+		{Stage: terraformutils.ProgressListed, Service: "queues", Resources: 4},
+		{Stage: terraformutils.ProgressListing, Service: "users"},
+		{Stage: terraformutils.ProgressListed, Service: "users", Err: errors.New("forbidden")},
+		{Stage: terraformutils.ProgressRefreshing, Service: "vhosts", Resources: 2},
+		{Stage: terraformutils.ProgressRefreshing, Service: "queues", Resources: 4},
+		{Stage: terraformutils.ProgressRefreshed, Service: "vhosts", Resources: 1},
+		{Stage: terraformutils.ProgressRefreshed, Service: "queues", Resources: 1, Retries: 2, Err: errors.New("gone")},
+	} {
+		display.Handle(event)
+	}
+	now = now.Add(10 * time.Second)
+
+	expected := []string{
+		"rabbitmq: listed 3/3 services, refreshed 2/6 resources, ETA 20s, 2 retries",
+		"  vhosts  refreshing [##########----------] 1/2",
+		"  queues  refreshing [#####---------------] 1/4, 1 failed, 2 retries",
+		"  users   failed     forbidden",
+	}
+	if lines := display.render(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("unexpected progress:\n%s\nexpected:\n%s", strings.Join(lines, "\n"), strings.Join(expected, "\n"))
+	}
+
+	display.draw()
+	display.draw()
+	if frames := buf.String(); !strings.HasPrefix(frames, "\x1b[2K") || !strings.Contains(frames, "\x1b[4A") {
+		t.Errorf("expected second frame to overwrite first one, got %q", frames)
+	}
+
+	display.Handle(terraformutils.ProgressEvent{Stage: terraformutils.ProgressRefreshed, Service: "vhosts", Resources: 1})
+	display.Handle(terraformutils.ProgressEvent{Stage: terraformutils.ProgressEvaluated, Service: "vhosts", Findings: 3})
+	display.Handle(terraformutils.ProgressEvent{Stage: terraformutils.ProgressWritten, Service: "vhosts", Resources: 2})
+	summary := display.summary()
+	for _, expected := range []string{
+		"SERVICE  LISTED  REFRESHED  FAILED  RETRIES  WRITTEN\n",
+		"vhosts   2       2          0       0        2\n",
+		"queues   4       0          1       2        0\n",
+		"users    0       0          0       0        0\n",
+		"TOTAL    6       2          1       2        2\n",
+		"failed to list users: forbidden\n",
+		"3 policy findings\n",
+		"rabbitmq done in 10s\n",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("missing %q in summary:\n%s", expected, summary)
+		}
+	}
+}
+
+func TestProgressDisplayImport(t *testing.T) {
+	testdataPath := chdirTemp(t)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var buf bytes.Buffer
+	services := []string{"vhosts", "exchanges", "queues"}
+	display := newProgressDisplay(&buf, "rabbitmq", services)
+	display.Start(time.Millisecond)
+	display.draw()
+	log.Println("warning while drawing")
+	// logs are printed right away, e.g. before log.Fatal, and the progress is drawn again below them
+	if !strings.Contains(logs.String(), "warning while drawing") {
+		t.Errorf("missing log written while drawing:\n%s", logs.String())
+	}
+	if !strings.Contains(buf.String(), "\x1b[4A\x1b[J") || !strings.HasSuffix(buf.String(), "queues     waiting\n") {
+		t.Errorf("expected progress to be cleared and drawn again after log, got %q", buf.String())
+	}
+	runImport(t, testdataPath, ImportOptions{
+		Resources:   services,
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Output:      "hcl",
+		Progress:    display.Handle,
+	})
+	display.Finish()
+
+	for _, expected := range []string{"warning while drawing", "rabbitmq save vhosts"} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("missing %q in logs:\n%s", expected, logs.String())
+		}
+	}
+	for _, expected := range []string{
+		"vhosts     written    [####################] 2/2",
+		"vhosts     2       2          0       0        2",
+		"TOTAL      6       6          0       0        6",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("missing %q in progress:\n%s", expected, buf.String())
+		}
+	}
+}
+
+func TestProgressEnabled(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for mode, expected := range map[string]bool{"auto": false, "always": true, "never": false} {
+		if enabled, err := progressEnabled(mode, f); err != nil || enabled != expected {
+			t.Errorf("%s: expected %v, got %v %v", mode, expected, enabled, err)
+		}
+	}
+	if _, err := progressEnabled("sometimes", f); err == nil {
+		t.Errorf("expected error for unsupported mode")
+	}
+}
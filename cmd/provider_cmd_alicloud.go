@@ -15,6 +15,7 @@ package cmd
 
 import (
 	"log"
+	"strings"
 
 	alicloud_terraforming "github.com/GoogleCloudPlatform/terraformer/providers/alicloud"
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
@@ -31,7 +32,9 @@ func newCmdAliCloudImporter(options ImportOptions) *cobra.Command {
 			for _, region := range options.Regions {
 				provider := newAliCloudProvider()
 				options.PathPattern = originalPathPattern
-				options.PathPattern += region + "/"
+				if !strings.Contains(options.PathPattern, "{region}") {
+					options.PathPattern += region + "/"
+				}
 				log.Println(provider.GetName() + " importing region " + region)
 				profile := options.Profile
 				err := Import(provider, options, []string{region, profile})
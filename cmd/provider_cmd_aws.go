@@ -15,6 +15,7 @@ package cmd
 
 import (
 	"log"
+	"strings"
 
 	awsterraformer "github.com/GoogleCloudPlatform/terraformer/providers/aws"
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
@@ -30,6 +31,9 @@ func newCmdAwsImporter(options ImportOptions) *cobra.Command {
 			originalResources := options.Resources
 			originalRegions := options.Regions
 			originalPathPattern := options.PathPattern
+			if options.ProviderAliases {
+				options.AliasedImport = newAliasedImport()
+			}
 
 			if len(options.Regions) > 0 {
 				shouldSpecifyPathRegion := len(options.Regions) > 1
@@ -54,13 +58,13 @@ func newCmdAwsImporter(options ImportOptions) *cobra.Command {
 						}
 					}
 				}
-				return nil
+				return options.AliasedImport.print()
 			}
 			err := importRegionResources(options, options.PathPattern, awsterraformer.NoRegion, false)
 			if err != nil {
 				return err
 			}
-			return nil
+			return options.AliasedImport.print()
 		},
 	}
 	cmd.AddCommand(listCmd(newAWSProvider()))
@@ -68,6 +72,7 @@ func newCmdAwsImporter(options ImportOptions) *cobra.Command {
 
 	cmd.PersistentFlags().StringVarP(&options.Profile, "profile", "", "default", "prod")
 	cmd.PersistentFlags().StringSliceVarP(&options.Regions, "regions", "", []string{}, "eu-west-1,eu-west-2,us-east-1")
+	cmd.PersistentFlags().BoolVarP(&options.ProviderAliases, "provider-aliases", "", false, "generate regions into a single configuration with a provider alias for each region")
 	return cmd
 }
 
@@ -102,7 +107,9 @@ func importRegionResources(options ImportOptions, originalPathPattern string, re
 	provider := newAWSProvider()
 	options.PathPattern = originalPathPattern
 	if region != awsterraformer.GlobalRegion && region != awsterraformer.NoRegion {
-		if shouldSpecifyPathRegion {
+		if options.AliasedImport != nil {
+			options.ProviderAlias = providerAlias(region)
+		} else if shouldSpecifyPathRegion && !strings.Contains(options.PathPattern, "{region}") {
 			options.PathPattern += region + "/"
 		}
 		log.Println(provider.GetName() + " importing region " + region)
@@ -0,0 +1,57 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+
+	generic_terraforming "github.com/GoogleCloudPlatform/terraformer/providers/generic"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/spf13/cobra"
+)
+
+func newCmdGenericImporter(options ImportOptions) *cobra.Command {
+	var providerName, ids string
+	var config []string
+	cmd := &cobra.Command{
+		Use:   "generic",
+		Short: "Import current state to Terraform configuration from any provider by resource IDs",
+		Long:  "Import current state to Terraform configuration from any provider by resource IDs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if providerName == "" || ids == "" {
+				return errors.New("--provider and --ids are required")
+			}
+			if len(options.Resources) == 0 {
+				options.Resources = []string{"*"}
+			}
+			provider := newGenericProvider()
+			err := Import(provider, options, append([]string{providerName, ids}, config...))
+			if err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	baseProviderFlags(cmd.PersistentFlags(), &options, "repository,branch_protection or * for all types of the IDs file", "repository=id1:id2:id4")
+	cmd.PersistentFlags().StringVarP(&providerName, "provider", "", "", "github - name of the provider plugin")
+	cmd.PersistentFlags().StringVarP(&ids, "ids", "", "", "ids.csv - rows of type,id[,name]")
+	cmd.PersistentFlags().StringSliceVarP(&config, "config", "", []string{}, "key=value - provider configuration")
+	return cmd
+}
+
+func newGenericProvider() terraformutils.ProviderGenerator {
+	return &generic_terraforming.GenericProvider{}
+}
@@ -30,11 +30,20 @@ func newCmdGoogleImporter(options ImportOptions) *cobra.Command {
 		Long:  "Import current state to Terraform configuration from Google Cloud",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			originalPathPattern := options.PathPattern
+			severalScopes := len(options.Projects) > 1 || len(options.Regions) > 1
+			if options.ProviderAliases {
+				options.AliasedImport = newAliasedImport()
+			}
 			for _, project := range options.Projects {
 				for _, region := range options.Regions {
 					provider := newGoogleProvider()
 					options.PathPattern = originalPathPattern
-					options.PathPattern = strings.ReplaceAll(options.PathPattern, "{provider}/{service}", "{provider}/"+project+"/{service}/"+region)
+					if options.AliasedImport != nil {
+						// configuration of provider differs only by project
+						options.ProviderAlias = providerAlias(project)
+					} else {
+						options.PathPattern = googlePathPattern(originalPathPattern, project, region, severalScopes)
+					}
 					log.Println(provider.GetName() + " importing project " + project + " region " + region)
 					err := Import(provider, options, []string{region, project, providerType})
 					if err != nil {
@@ -42,7 +51,7 @@ func newCmdGoogleImporter(options ImportOptions) *cobra.Command {
 					}
 				}
 			}
-			return nil
+			return options.AliasedImport.print()
 		},
 	}
 	cmd.AddCommand(listCmd(newGoogleProvider()))
@@ -50,10 +59,26 @@ func newCmdGoogleImporter(options ImportOptions) *cobra.Command {
 	cmd.PersistentFlags().StringSliceVarP(&options.Regions, "regions", "z", []string{"global"}, "europe-west1,")
 	cmd.PersistentFlags().StringSliceVarP(&options.Projects, "projects", "", []string{}, "")
 	cmd.PersistentFlags().StringVarP(&providerType, "provider-type", "", "", "beta")
+	cmd.PersistentFlags().BoolVarP(&options.ProviderAliases, "provider-aliases", "", false, "generate projects and regions into a single configuration with a provider alias for each project")
 	_ = cmd.MarkPersistentFlagRequired("projects")
 	return cmd
 }
 
+// googlePathPattern keeps files of each project and region apart unless path pattern places them by itself.
+// Other custom patterns get project and region directories only if several projects or regions are imported.
+func googlePathPattern(pathPattern, project, region string, severalScopes bool) string {
+	if strings.Contains(pathPattern, "{project}") || strings.Contains(pathPattern, "{region}") {
+		return pathPattern
+	}
+	if strings.Contains(pathPattern, "{provider}/{service}") {
+		return strings.ReplaceAll(pathPattern, "{provider}/{service}", "{provider}/"+project+"/{service}/"+region)
+	}
+	if !severalScopes {
+		return pathPattern
+	}
+	return strings.TrimSuffix(pathPattern, "/") + "/" + project + "/" + region + "/"
+}
+
 func newGoogleProvider() terraformutils.ProviderGenerator {
 	return &gcp_terraforming.GCPProvider{}
 }
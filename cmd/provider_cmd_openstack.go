@@ -15,6 +15,7 @@ package cmd
 
 import (
 	"log"
+	"strings"
 
 	openstack_terraforming "github.com/GoogleCloudPlatform/terraformer/providers/openstack"
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
@@ -31,7 +32,9 @@ func newCmdOpenStackImporter(options ImportOptions) *cobra.Command {
 			for _, region := range options.Regions {
 				provider := newOpenStackProvider()
 				options.PathPattern = originalPathPattern
-				options.PathPattern += region + "/"
+				if !strings.Contains(options.PathPattern, "{region}") {
+					options.PathPattern += region + "/"
+				}
 				log.Println(provider.GetName() + " importing region " + region)
 				err := Import(provider, options, []string{region})
 				if err != nil {
@@ -27,13 +27,24 @@ func NewCmdRoot() *cobra.Command {
 	}
 	cmd.AddCommand(newImportCmd())
 	cmd.AddCommand(newPlanCmd())
+	cmd.AddCommand(newInventoryCmd())
+	cmd.AddCommand(newRegenerateCmd())
+	cmd.AddCommand(newServeCmd())
+	cmd.AddCommand(newWatchCmd())
 	cmd.AddCommand(versionCmd)
 	return cmd
 }
 
 func Execute() error {
 	cmd := NewCmdRoot()
-	return cmd.Execute()
+	err := cmd.Execute()
+	if shutdownErr := shutdownTelemetry(); err == nil {
+		err = shutdownErr
+	}
+	if closeErr := closeLogFile(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
 func providerImporterSubcommands() []func(options ImportOptions) *cobra.Command {
@@ -77,6 +88,8 @@ func providerImporterSubcommands() []func(options ImportOptions) *cobra.Command
 		newCmdXenorchestraImporter,
 		newCmdGmailfilterImporter,
 		newCmdVaultImporter,
+		// Any provider
+		newCmdGenericImporter,
 	}
 }
 
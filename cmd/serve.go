@@ -0,0 +1,548 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/spf13/cobra"
+)
+
+// Statuses of serve jobs
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+)
+
+// ServeJobRequest starts an import job, Args are passed to the provider like in plan.json
+type ServeJobRequest struct {
+	Provider string
+	Args     []string
+	Options  ServeJobOptions
+}
+
+// ServeJobOptions are import options which can be set by a job. Options reading or writing
+// files of the server (state, previous, policy, transform, schema, snapshot and IDs files)
+// aren't accepted, they would give API clients access to files and credentials of the server.
+type ServeJobOptions struct {
+	Resources     []string
+	Excludes      []string
+	PathPattern   string
+	Profile       string
+	Verbose       bool
+	Zone          string
+	Regions       []string
+	Projects      []string
+	ResourceGroup string
+	Connect       bool
+	Compact       bool
+	Filter        []string
+	Output        string
+	RetryCount    int
+	RetrySleepMs  int
+	Collapse      bool
+	ExternalRefs  string
+	Annotate      bool
+	Layout        string
+}
+
+// importOptions validates options of a job, generated files are always written into the job directory
+func (o ServeJobOptions) importOptions() (ImportOptions, error) {
+	if len(o.Resources) == 0 {
+		return ImportOptions{}, fmt.Errorf("no resources to import")
+	}
+	if filepath.IsAbs(o.PathPattern) || strings.HasPrefix(o.PathPattern, "/") || strings.HasPrefix(o.PathPattern, `\`) {
+		return ImportOptions{}, fmt.Errorf("path pattern must be relative to the job directory: %s", o.PathPattern)
+	}
+	for _, element := range strings.FieldsFunc(o.PathPattern, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if element == ".." {
+			return ImportOptions{}, fmt.Errorf("path pattern must not leave the job directory: %s", o.PathPattern)
+		}
+	}
+	return ImportOptions{
+		Resources:     o.Resources,
+		Excludes:      o.Excludes,
+		PathPattern:   o.PathPattern,
+		PathOutput:    DefaultPathOutput,
+		State:         DefaultState,
+		Profile:       o.Profile,
+		Verbose:       o.Verbose,
+		Zone:          o.Zone,
+		Regions:       o.Regions,
+		Projects:      o.Projects,
+		ResourceGroup: o.ResourceGroup,
+		Connect:       o.Connect,
+		Compact:       o.Compact,
+		Filter:        o.Filter,
+		Output:        o.Output,
+		RetryCount:    o.RetryCount,
+		RetrySleepMs:  o.RetrySleepMs,
+		Collapse:      o.Collapse,
+		ExternalRefs:  o.ExternalRefs,
+		Annotate:      o.Annotate,
+		Layout:        o.Layout,
+		// telemetry and logs are set up for the whole process, not for a job
+		ShowProgress: "never",
+	}, nil
+}
+
+// ServeJobProgress counts finished steps of a job
+type ServeJobProgress struct {
+	Stage     string   `json:"stage,omitempty"`
+	Services  int      `json:"services"`
+	Listed    int      `json:"listed"`
+	Failed    []string `json:"failed,omitempty"`
+	Resources int      `json:"resources"`
+	Refreshed int      `json:"refreshed"`
+	Findings  int      `json:"findings"`
+	Written   int      `json:"written"`
+}
+
+// ServeJob is status of a job, provider args are never returned
+type ServeJob struct {
+	ID         string           `json:"id"`
+	Provider   string           `json:"provider"`
+	Status     string           `json:"status"`
+	Error      string           `json:"error,omitempty"`
+	Progress   ServeJobProgress `json:"progress"`
+	CreatedAt  time.Time        `json:"created_at"`
+	StartedAt  *time.Time       `json:"started_at,omitempty"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+}
+
+type serveJob struct {
+	ServeJob
+	request ServeJobRequest
+	options ImportOptions
+	dir     string
+}
+
+// importServer runs import jobs one at a time, every job in its own directory.
+// Importers use the working directory and provider credentials from environment,
+// both are global to the process.
+type importServer struct {
+	workDir string
+	queue   chan *serveJob
+	run     func(job *serveJob) error
+
+	mu   sync.Mutex
+	jobs map[string]*serveJob
+	// cwdMu is held while a job changes working directory
+	cwdMu sync.Mutex
+}
+
+func newServeCmd() *cobra.Command {
+	listen := ""
+	workDir := ""
+	queueSize := 0
+	cmd := &cobra.Command{
+		Use:           "serve",
+		Short:         "Run imports requested with HTTP API",
+		Long:          "Run imports requested with HTTP API",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server, err := newImportServer(workDir, queueSize, runServeJob)
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			go server.work(ctx)
+
+			httpServer := &http.Server{Addr: listen, Handler: server.handler()}
+			go func() {
+				<-ctx.Done()
+				_ = httpServer.Shutdown(context.Background())
+			}()
+			log.Printf("serving import jobs on %s, jobs are stored in %s\n", listen, server.workDir)
+			if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&listen, "listen", "", "localhost:8080", "address of HTTP API")
+	cmd.Flags().StringVarP(&workDir, "work-dir", "", "terraformer-jobs", "directory of job working directories")
+	cmd.Flags().IntVarP(&queueSize, "queue-size", "", 10, "number of queued jobs, further jobs are rejected")
+	return cmd
+}
+
+func newImportServer(workDir string, queueSize int, run func(job *serveJob) error) (*importServer, error) {
+	if queueSize < 1 {
+		return nil, fmt.Errorf("queue size must be positive")
+	}
+	// job paths don't depend on working directory changed by jobs
+	workDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(workDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &importServer{
+		workDir: workDir,
+		queue:   make(chan *serveJob, queueSize),
+		run:     run,
+		jobs:    map[string]*serveJob{},
+	}, nil
+}
+
+func (s *importServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.listJobs())
+		case http.MethodPost:
+			s.createJob(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		}
+	})
+	mux.HandleFunc("/v1/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/jobs/"), "/")
+		job, exist := s.job(parts[0])
+		if !exist {
+			writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", parts[0]))
+			return
+		}
+		switch {
+		case len(parts) == 1 && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, job)
+		case len(parts) == 1 && r.Method == http.MethodDelete:
+			s.deleteJob(w, job.ID)
+		case len(parts) == 2 && parts[1] == "archive" && r.Method == http.MethodGet:
+			s.downloadArchive(w, job)
+		case len(parts) == 2 && parts[1] == "log" && r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "text/plain")
+			http.ServeFile(w, r, filepath.Join(s.workDir, job.ID, "terraformer.log"))
+		default:
+			writeError(w, http.StatusNotFound, fmt.Errorf("%s %s not found", r.Method, r.URL.Path))
+		}
+	})
+	return mux
+}
+
+func (s *importServer) createJob(w http.ResponseWriter, r *http.Request) {
+	request := ServeJobRequest{
+		Options: ServeJobOptions{
+			PathPattern:  DefaultPathPattern,
+			Connect:      true,
+			Output:       "hcl",
+			RetryCount:   5,
+			RetrySleepMs: 300,
+		},
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid job: %v", err))
+		return
+	}
+	if _, exist := providerGenerators()[request.Provider]; !exist {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported provider: %s", request.Provider))
+		return
+	}
+	options, err := request.Options.importOptions()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid job: %v", err))
+		return
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	job := &serveJob{
+		ServeJob: ServeJob{
+			ID:        id,
+			Provider:  request.Provider,
+			Status:    jobQueued,
+			CreatedAt: time.Now().UTC(),
+		},
+		request: request,
+		options: options,
+		dir:     filepath.Join(s.workDir, id),
+	}
+	s.mu.Lock()
+	select {
+	case s.queue <- job:
+		s.jobs[id] = job
+		s.mu.Unlock()
+	default:
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("job queue is full"))
+		return
+	}
+	w.Header().Set("Location", "/v1/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, s.status(job))
+}
+
+func (s *importServer) deleteJob(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	job, exist := s.jobs[id]
+	if !exist {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", id))
+		return
+	}
+	if job.Status == jobQueued || job.Status == jobRunning {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Errorf("job %s is %s", job.ID, job.Status))
+		return
+	}
+	delete(s.jobs, job.ID)
+	s.mu.Unlock()
+	if err := os.RemoveAll(job.dir); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// downloadArchive writes generated tree of a finished job as tar.gz
+func (s *importServer) downloadArchive(w http.ResponseWriter, job ServeJob) {
+	if job.Status != jobSucceeded {
+		writeError(w, http.StatusConflict, fmt.Errorf("job %s is %s", job.ID, job.Status))
+		return
+	}
+	root := filepath.Join(s.workDir, job.ID, DefaultPathOutput)
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.ID+".tar.gz"))
+	if err := writeTarGz(w, root); err != nil {
+		// headers are already sent
+		log.Printf("failed to write archive of job %s: %v\n", job.ID, err)
+	}
+}
+
+func (s *importServer) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			s.runJob(job)
+		}
+	}
+}
+
+func (s *importServer) runJob(job *serveJob) {
+	started := time.Now().UTC()
+	s.update(job, func() {
+		job.Status = jobRunning
+		job.StartedAt = &started
+		job.Progress.Services = len(job.options.Resources)
+	})
+	job.options.Progress = func(event terraformutils.ProgressEvent) {
+		s.update(job, func() {
+			job.Progress.Stage = event.Stage
+			switch event.Stage {
+			case terraformutils.ProgressListed:
+				if event.Err != nil {
+					job.Progress.Failed = append(job.Progress.Failed, event.Service)
+					return
+				}
+				job.Progress.Listed++
+			case terraformutils.ProgressRefreshing:
+				job.Progress.Resources += event.Resources
+			case terraformutils.ProgressRefreshed:
+				job.Progress.Refreshed += event.Resources
+			case terraformutils.ProgressEvaluated:
+				job.Progress.Findings += event.Findings
+			case terraformutils.ProgressWritten:
+				job.Progress.Written += event.Resources
+			}
+		})
+	}
+
+	err := s.inJobDir(job, func() error {
+		return s.run(job)
+	})
+
+	finished := time.Now().UTC()
+	s.update(job, func() {
+		job.FinishedAt = &finished
+		if err != nil {
+			job.Status = jobFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = jobSucceeded
+	})
+	log.Printf("job %s %s\n", job.ID, job.Status)
+}
+
+// inJobDir runs fn with working directory and log output of the job
+func (s *importServer) inJobDir(job *serveJob, fn func() error) error {
+	if err := os.MkdirAll(job.dir, os.ModePerm); err != nil {
+		return err
+	}
+	logFile, err := os.Create(filepath.Join(job.dir, "terraformer.log"))
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	s.cwdMu.Lock()
+	defer s.cwdMu.Unlock()
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(job.dir); err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Chdir(workingDir)
+	}()
+	log.SetOutput(io.MultiWriter(os.Stderr, logFile))
+	defer log.SetOutput(os.Stderr)
+	return fn()
+}
+
+func (s *importServer) update(job *serveJob, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
+}
+
+func (s *importServer) job(id string) (ServeJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exist := s.jobs[id]
+	if !exist {
+		return ServeJob{}, false
+	}
+	return s.copyStatus(job), true
+}
+
+func (s *importServer) status(job *serveJob) ServeJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.copyStatus(job)
+}
+
+// copyStatus copies status of job, s.mu must be held
+func (s *importServer) copyStatus(job *serveJob) ServeJob {
+	status := job.ServeJob
+	status.Progress.Failed = append([]string(nil), job.Progress.Failed...)
+	return status
+}
+
+func (s *importServer) listJobs() []ServeJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := []ServeJob{}
+	for _, job := range s.jobs {
+		jobs = append(jobs, s.copyStatus(job))
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs
+}
+
+// runServeJob imports resources of a job with the real provider
+func runServeJob(job *serveJob) error {
+	provider := providerGenerators()[job.request.Provider]()
+	return Import(provider, job.options, job.request.Args)
+}
+
+func newJobID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func writeTarGz(w io.Writer, root string) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil || relativePath == "." {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relativePath)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tarWriter, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
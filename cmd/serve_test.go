@@ -0,0 +1,210 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/providers/rabbitmq"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformertest"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestServeImportJob(t *testing.T) {
+	isolatePlugins(t)
+	workingDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testdataPath := filepath.Join(workingDir, "testdata")
+
+	rabbitmqServer := terraformertest.NewServer(t, filepath.Join(testdataPath, "rabbitmq", "cassette.json"), os.Getenv("RABBITMQ_SERVER_URL"))
+	providerWrapper := terraformertest.NewProviderWrapper(t, "rabbitmq", cty.ObjectVal(map[string]cty.Value{
+		"endpoint": cty.StringVal(os.Getenv("RABBITMQ_SERVER_URL")),
+		"username": cty.StringVal(os.Getenv("RABBITMQ_USERNAME")),
+		"password": cty.StringVal(os.Getenv("RABBITMQ_PASSWORD")),
+	}), filepath.Join(testdataPath, "rabbitmq", "schema.json"), filepath.Join(testdataPath, "rabbitmq", "resources.json"))
+	server, err := newImportServer(t.TempDir(), 1, func(job *serveJob) error {
+		provider := &rabbitmq.RBTProvider{}
+		options, err := initOptions(provider, job.options, job.request.Args)
+		if err != nil {
+			return err
+		}
+		return importWithProviderWrapper(context.Background(), provider, options, job.request.Args, providerWrapper)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := httptest.NewServer(server.handler())
+	defer api.Close()
+
+	request := `{"Provider": "rabbitmq", "Args": ["` + rabbitmqServer.URL + `", "", ""], "Options": {"Resources": ["vhosts", "exchanges", "queues"]}}`
+	job := ServeJob{}
+	requestJSON(t, http.MethodPost, api.URL+"/v1/jobs", request, http.StatusAccepted, &job)
+	if job.Status != jobQueued || job.Provider != "rabbitmq" {
+		t.Errorf("unexpected job %+v", job)
+	}
+	requestJSON(t, http.MethodPost, api.URL+"/v1/jobs", request, http.StatusServiceUnavailable, nil)
+	requestJSON(t, http.MethodGet, api.URL+"/v1/jobs/"+job.ID+"/archive", "", http.StatusConflict, nil)
+
+	// worker runs jobs in test goroutine
+	server.runJob(<-server.queue)
+	if workingDirAfterJob, _ := os.Getwd(); workingDirAfterJob != workingDir {
+		t.Errorf("working directory wasn't restored, got %s", workingDirAfterJob)
+	}
+
+	requestJSON(t, http.MethodGet, api.URL+"/v1/jobs/"+job.ID, "", http.StatusOK, &job)
+	if job.Status != jobSucceeded || job.StartedAt == nil || job.FinishedAt == nil {
+		t.Fatalf("job didn't succeed, got %+v", job)
+	}
+	progress := job.Progress
+	if progress.Stage != "written" || progress.Services != 3 || progress.Listed != 3 || progress.Resources == 0 ||
+		progress.Refreshed != progress.Resources || progress.Written != progress.Resources {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+
+	jobs := []ServeJob{}
+	requestJSON(t, http.MethodGet, api.URL+"/v1/jobs", "", http.StatusOK, &jobs)
+	if len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("unexpected jobs %+v", jobs)
+	}
+
+	response, err := http.Get(api.URL + "/v1/jobs/" + job.ID + "/archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("failed to download archive, status %d", response.StatusCode)
+	}
+	compareTrees(t, "archive", readTarGz(t, response.Body), readTree(t, filepath.Join(testdataPath, "golden", "services")))
+
+	jobLog := requestText(t, api.URL+"/v1/jobs/"+job.ID+"/log")
+	if !strings.Contains(jobLog, "rabbitmq save vhosts") {
+		t.Errorf("missing import log, got:\n%s", jobLog)
+	}
+
+	requestJSON(t, http.MethodDelete, api.URL+"/v1/jobs/"+job.ID, "", http.StatusNoContent, nil)
+	requestJSON(t, http.MethodGet, api.URL+"/v1/jobs/"+job.ID, "", http.StatusNotFound, nil)
+	if _, err := os.Stat(filepath.Join(server.workDir, job.ID)); !os.IsNotExist(err) {
+		t.Errorf("job directory wasn't removed")
+	}
+}
+
+func TestServeInvalidJobs(t *testing.T) {
+	server, err := newImportServer(t.TempDir(), 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := httptest.NewServer(server.handler())
+	defer api.Close()
+
+	for name, request := range map[string]string{
+		"unknown provider": `{"Provider": "unknown", "Options": {"Resources": ["vhosts"]}}`,
+		"unknown option":   `{"Provider": "rabbitmq", "Options": {"Resources": ["vhosts"], "Unknown": true}}`,
+		"no resources":     `{"Provider": "rabbitmq"}`,
+		"invalid json":     `{`,
+		"absolute path":    `{"Provider": "rabbitmq", "Options": {"Resources": ["vhosts"], "PathPattern": "/etc/{service}"}}`,
+		"parent path":      `{"Provider": "rabbitmq", "Options": {"Resources": ["vhosts"], "PathPattern": "{output}/../../{service}"}}`,
+		"server files":     `{"Provider": "rabbitmq", "Options": {"Resources": ["vhosts"], "Snapshot": "/tmp/snapshot"}}`,
+		"server stdin":     `{"Provider": "rabbitmq", "Options": {"Resources": ["vhosts"], "IDsFrom": "-"}}`,
+		"remote state":     `{"Provider": "rabbitmq", "Options": {"Resources": ["vhosts"], "State": "bucket", "Bucket": "gs://b"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			requestJSON(t, http.MethodPost, api.URL+"/v1/jobs", request, http.StatusBadRequest, nil)
+		})
+	}
+	requestJSON(t, http.MethodGet, api.URL+"/v1/jobs/unknown", "", http.StatusNotFound, nil)
+	requestJSON(t, http.MethodPut, api.URL+"/v1/jobs", "", http.StatusMethodNotAllowed, nil)
+}
+
+func requestJSON(t *testing.T, method, url, body string, expectedStatus int, result interface{}) {
+	t.Helper()
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != expectedStatus {
+		t.Fatalf("%s %s: expected status %d, got %d: %s", method, url, expectedStatus, response.StatusCode, content)
+	}
+	if result != nil {
+		if err := json.Unmarshal(content, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func requestText(t *testing.T, url string) string {
+	t.Helper()
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// readTarGz returns content of all files in archive by path
+func readTarGz(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	files := map[string]string{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		var content bytes.Buffer
+		if _, err := io.Copy(&content, tarReader); err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = content.String()
+	}
+}
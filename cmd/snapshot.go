@@ -0,0 +1,356 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformoutput"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/compute/v1"
+)
+
+const snapshotFile = "snapshot.json"
+const snapshotSchemaFile = "schema.json"
+
+// Snapshot records imports of a provider after refresh, before any conversion of states,
+// so the code can be regenerated from it without access to the provider APIs
+type Snapshot struct {
+	Version  string
+	Provider string
+	Imports  []SnapshotImport
+}
+
+// SnapshotImport is a single import, like of a region, with its listed resources by service.
+// Provider data and path scopes of services are recorded from the initialized provider, so regenerate
+// doesn't initialize it again, which may call the provider APIs, e.g. to look up regions.
+type SnapshotImport struct {
+	Args              []string
+	Options           ImportOptions
+	ProviderAlias     string                              `json:",omitempty"`
+	ProviderData      map[string]interface{}              `json:",omitempty"`
+	PathScopes        map[string]terraformutils.PathScope `json:",omitempty"`
+	SelectedResources []terraformutils.SelectedResource   `json:",omitempty"`
+	Resources         map[string][]terraformutils.SnapshotResource
+}
+
+var (
+	// snapshots written by this process by directory, imports of all regions are recorded in the same snapshot
+	snapshotsMutex sync.Mutex
+	snapshots      = map[string]*Snapshot{}
+)
+
+// writeSnapshot adds refreshed resources of the import to snapshot in options.Snapshot, with schema of provider
+func writeSnapshot(provider terraformutils.ProviderGenerator, options ImportOptions, args []string,
+	providerMapping *terraformutils.ProvidersMapping, providerWrapper *providerwrapper.ProviderWrapper) error {
+	snapshotsMutex.Lock()
+	defer snapshotsMutex.Unlock()
+	dir := options.Snapshot
+	snapshot, exist := snapshots[dir]
+	if !exist {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+		schema := providerWrapper.GetSchema()
+		if schema.Diagnostics.HasErrors() {
+			return schema.Diagnostics.Err()
+		}
+		if err := writeSnapshotSchema(filepath.Join(dir, snapshotSchemaFile), schema); err != nil {
+			return err
+		}
+		snapshot = &Snapshot{Version: version, Provider: provider.GetName()}
+		snapshots[dir] = snapshot
+	}
+
+	recorded := SnapshotImport{
+		Args:              args,
+		Options:           options,
+		ProviderAlias:     options.ProviderAlias,
+		ProviderData:      provider.GetProviderData(),
+		SelectedResources: options.SelectedResources,
+		Resources:         map[string][]terraformutils.SnapshotResource{},
+	}
+	recorded.Options.Snapshot = ""
+	for service, resources := range providerMapping.GetResourcesByService() {
+		if scope := pathScope(providerMapping.ServiceArgs(service)); len(scope) > 0 {
+			if recorded.PathScopes == nil {
+				recorded.PathScopes = map[string]terraformutils.PathScope{}
+			}
+			recorded.PathScopes[service] = scope
+		}
+		recorded.Resources[service] = []terraformutils.SnapshotResource{}
+		for _, r := range resources {
+			snapshotResource, err := terraformutils.NewSnapshotResource(r)
+			if err != nil {
+				return fmt.Errorf("failed to record state of %s: %v", r.InstanceInfo.Id, err)
+			}
+			recorded.Resources[service] = append(recorded.Resources[service], snapshotResource)
+		}
+	}
+	snapshot.Imports = append(snapshot.Imports, recorded)
+
+	snapshotPath := filepath.Join(dir, snapshotFile)
+	log.Println("Saving snapshot to", snapshotPath)
+	f, err := os.OpenFile(snapshotPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "\t")
+	return enc.Encode(snapshot)
+}
+
+// forgetSnapshot releases snapshot in dir written by this process, next import to dir starts a new snapshot
+func forgetSnapshot(dir string) {
+	snapshotsMutex.Lock()
+	defer snapshotsMutex.Unlock()
+	delete(snapshots, dir)
+}
+
+func writeSnapshotSchema(path string, schema *providerwrapper.ProviderSchema) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return providerwrapper.WriteSchemaJSON(f, schema)
+}
+
+func LoadSnapshot(dir string) (*Snapshot, error) {
+	f, err := os.Open(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	snapshot := &Snapshot{}
+	if err := json.NewDecoder(f).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("error parsing snapshot: %v", err)
+	}
+	return snapshot, nil
+}
+
+// regenerateOptions are options of regenerate, options of output given by flags replace options of imports
+type regenerateOptions struct {
+	ImportOptions
+	RefreshSchema bool
+	changed       func(flag string) bool
+}
+
+func (o regenerateOptions) apply(options *ImportOptions) {
+	if o.changed("output") {
+		options.Output = o.Output
+	}
+	if o.changed("path-pattern") {
+		options.PathPattern = o.PathPattern
+	}
+	if o.changed("path-output") {
+		options.PathOutput = o.PathOutput
+	}
+	if o.changed("connect") {
+		options.Connect = o.Connect
+	}
+	if o.changed("compact") {
+		options.Compact = o.Compact
+	}
+	if o.changed("collapse") {
+		options.Collapse = o.Collapse
+	}
+	if o.changed("layout") {
+		options.Layout = o.Layout
+	}
+	if o.changed("annotate") {
+		options.Annotate = o.Annotate
+	}
+	if o.changed("transform") {
+		options.Transform = o.Transform
+	}
+	if o.changed("previous") {
+		options.Previous = o.Previous
+	}
+	if o.changed("policy") {
+		options.Policy = o.Policy
+	}
+	if o.changed("verbose") {
+		options.Verbose = o.Verbose
+	}
+}
+
+func newRegenerateCmd() *cobra.Command {
+	options := regenerateOptions{}
+	cmd := &cobra.Command{
+		Use:   "regenerate [snapshot]",
+		Short: "Regenerate Terraform configuration from a snapshot of an import",
+		Long: "Regenerate Terraform configuration from a snapshot recorded by import --snapshot, e.g. with other output options " +
+			"or a newer provider schema, without access to the provider APIs",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.changed = cmd.Flags().Changed
+			return regenerate(context.Background(), args[0], options)
+		},
+	}
+	flag := cmd.Flags()
+	flag.BoolVarP(&options.Connect, "connect", "c", true, "")
+	flag.BoolVarP(&options.Compact, "compact", "C", false, "")
+	flag.BoolVarP(&options.Collapse, "collapse", "", false, "collapse resources differing only in a few attributes into for_each blocks")
+	flag.StringVarP(&options.PathPattern, "path-pattern", "p", DefaultPathPattern, "{output}/{provider}/")
+	flag.StringVarP(&options.PathOutput, "path-output", "o", DefaultPathOutput, "")
+	flag.BoolVarP(&options.Verbose, "verbose", "v", false, "")
+	flag.StringVarP(&options.Output, "output", "O", "hcl", "output format hcl, json, cdktf-typescript, cdktf-python or cdktf-go")
+	flag.StringVarP(&options.Transform, "transform", "", "", "rules.json - transform generated resources with rules")
+	flag.StringVarP(&options.Previous, "previous", "", "", "generated/ or terraform.tfstate - write moved blocks for resources with changed addresses")
+	flag.StringVarP(&options.Policy, "policy", "", "", "policies/ - evaluate Rego policies against refreshed resources")
+	flag.StringVarP(&options.Layout, "layout", "", "terraform", "terraform or terragrunt - write terragrunt.hcl files with dependencies between services")
+	flag.BoolVarP(&options.Annotate, "annotate", "", false, "write comments with ID, scope, import time and metadata above resources")
+	flag.StringVarP(&options.SchemaFile, "schema-file", "", "", "schema.json - output of terraform providers schema -json, used instead of the schema of the snapshot")
+	flag.BoolVarP(&options.RefreshSchema, "refresh-schema", "", false, "use schema of the installed provider plugin instead of the schema of the snapshot")
+	return cmd
+}
+
+// regenerate converts states of the snapshot in dir and prints them like the recorded imports, options of output
+// given to regenerate replace the recorded ones. Listing and refresh aren't repeated, so no provider API is called.
+func regenerate(ctx context.Context, dir string, overrides regenerateOptions) error {
+	snapshot, err := LoadSnapshot(dir)
+	if err != nil {
+		return err
+	}
+
+	schemaFile := overrides.SchemaFile
+	if schemaFile == "" && !overrides.RefreshSchema {
+		schemaFile = filepath.Join(dir, snapshotSchemaFile)
+	}
+	schema, err := providerwrapper.LoadSchema(snapshot.Provider, schemaFile, overrides.Verbose)
+	if err != nil {
+		return fmt.Errorf("failed to load schema of provider %s: %v", snapshot.Provider, err)
+	}
+	providerWrapper := providerwrapper.NewSchemaProviderWrapper(snapshot.Provider, schema)
+
+	var aliased *aliasedImport
+	for _, recorded := range snapshot.Imports {
+		options := recorded.Options
+		overrides.apply(&options)
+		options.SchemaFile = schemaFile
+		options.ProviderAlias = recorded.ProviderAlias
+		options.SelectedResources = recorded.SelectedResources
+		if err := validateOptions(options); err != nil {
+			return err
+		}
+		if options.ProviderAliases {
+			if aliased == nil {
+				aliased = newAliasedImport()
+			}
+			options.AliasedImport = aliased
+		}
+
+		provider, err := importedProvider(snapshot.Provider, recorded.Args)
+		if err != nil {
+			return err
+		}
+		setSchemaFile(provider, options.SchemaFile)
+		providerMapping, err := snapshotProvidersMapping(provider, options, recorded)
+		if err != nil {
+			return err
+		}
+
+		providerMapping.ConvertTFStates(providerWrapper)
+		providerMapping.CleanupProviders()
+
+		if terraformoutput.IsCdktfOutput(options.Output) {
+			options.Schema = schema
+		}
+		snapshotProvider := snapshotProvider{ProviderGenerator: provider, data: recorded.ProviderData}
+		if err := importFromPlan(ctx, snapshotProvider, providerMapping, options, recorded.Args); err != nil {
+			return err
+		}
+	}
+	return aliased.print()
+}
+
+// snapshotProvider prints provider data recorded by the snapshot, the provider isn't initialized by regenerate
+type snapshotProvider struct {
+	terraformutils.ProviderGenerator
+	data map[string]interface{}
+}
+
+func (p snapshotProvider) GetProviderData(arg ...string) map[string]interface{} {
+	data := make(map[string]interface{}, len(p.data))
+	for k, v := range p.data {
+		data[k] = v
+	}
+	return data
+}
+
+// snapshotProvidersMapping returns services of the recorded import with its resources, like after refresh.
+// Providers of services aren't initialized, path scopes of services are restored from the snapshot instead.
+func snapshotProvidersMapping(provider terraformutils.ProviderGenerator, options ImportOptions, recorded SnapshotImport) (*terraformutils.ProvidersMapping, error) {
+	providerMapping := terraformutils.NewProvidersMapping(provider)
+	services := make([]string, 0, len(recorded.Resources))
+	for service := range recorded.Resources {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		serviceProvider := providerMapping.AddServiceToProvider(service)
+		setSchemaFile(serviceProvider, options.SchemaFile)
+		if err := serviceProvider.InitService(service, options.Verbose); err != nil {
+			return nil, err
+		}
+		serviceProvider.GetService().SetArgs(restoreScope(serviceProvider.GetService().GetArgs(), recorded.PathScopes[service]))
+		// filters are applied again after conversion, like by cleanup after refresh
+		serviceProvider.GetService().ParseFilters(options.Filter)
+		if options.IDsFrom != "" {
+			serviceProvider.GetService().AddFilters(terraformutils.SelectedFilters(options.SelectedResources, service, provider.GetName()))
+		}
+		resources := make([]terraformutils.Resource, 0, len(recorded.Resources[service]))
+		for _, snapshotResource := range recorded.Resources[service] {
+			r, err := snapshotResource.GetResource()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read state of %s: %v", snapshotResource.InstanceInfo.Id, err)
+			}
+			resources = append(resources, r)
+		}
+		serviceProvider.GetService().SetResources(resources)
+	}
+	providerMapping.ProcessResources(false)
+	return providerMapping, nil
+}
+
+// restoreScope returns args of service with values of scope placeholders recorded by the snapshot,
+// which are otherwise set by initialization of the provider
+func restoreScope(args map[string]interface{}, scope terraformutils.PathScope) map[string]interface{} {
+	restored := make(map[string]interface{}, len(args)+len(scope))
+	for k, v := range args {
+		restored[k] = v
+	}
+	for key, value := range scope {
+		if region, ok := restored[key].(compute.Region); ok {
+			region.Name = value
+			restored[key] = region
+		} else {
+			restored[key] = value
+		}
+	}
+	return restored
+}
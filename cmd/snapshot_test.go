@@ -0,0 +1,101 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+func TestImportSnapshotRegenerate(t *testing.T) {
+	isolatePlugins(t)
+	if err := os.Setenv("TERRAFORMER_SCHEMA_CACHE_DIR", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("TERRAFORMER_SCHEMA_CACHE_DIR")
+
+	testdataPath := chdirTemp(t)
+
+	options := ImportOptions{
+		Resources:   []string{"vhosts", "exchanges", "queues"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Connect:     true,
+		Output:      "hcl",
+		Snapshot:    "snapshot",
+	}
+	runImport(t, testdataPath, options)
+	for _, file := range []string{snapshotFile, snapshotSchemaFile} {
+		if _, err := os.Stat(filepath.Join("snapshot", file)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshot, err := LoadSnapshot("snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Imports) != 1 || len(snapshot.Imports[0].Resources["queues"]) != 2 {
+		t.Fatalf("unexpected imports of snapshot: %+v", snapshot.Imports)
+	}
+	if queue := snapshot.Imports[0].Resources["queues"][0]; len(queue.Value) == 0 {
+		t.Errorf("state value of %s isn't recorded", queue.InstanceInfo.Id)
+	}
+	if err := os.RemoveAll(DefaultPathOutput); err != nil {
+		t.Fatal(err)
+	}
+
+	// no plugin is installed and no API is called, the snapshot has everything
+	cmd := newRegenerateCmd()
+	cmd.SetArgs([]string{"snapshot"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	compareTrees(t, "regenerated files", readTree(t, DefaultPathOutput), readTree(t, filepath.Join(testdataPath, "golden", "services")))
+
+	cmd = newRegenerateCmd()
+	cmd.SetArgs([]string{"snapshot", "--path-pattern={output}/{provider}/", "--path-output=compact", "--compact", "--collapse"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	compareTrees(t, "regenerated compact files", readTree(t, "compact"), readTree(t, filepath.Join(testdataPath, "golden", "compact")))
+
+	// provider isn't initialized again, init of rabbitmq would fail without its arguments,
+	// scopes of services recorded by the snapshot are used instead
+	snapshot.Imports[0].Args = nil
+	snapshot.Imports[0].PathScopes = map[string]terraformutils.PathScope{"queues": {"region": "eu-west-1"}}
+	f, err := os.Create(filepath.Join("snapshot", snapshotFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewEncoder(f).Encode(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	cmd = newRegenerateCmd()
+	cmd.SetArgs([]string{"snapshot", "--path-pattern={output}/{provider}/{region}/{service}/", "--path-output=scoped"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"scoped/rabbitmq/eu-west-1/queues", "scoped/rabbitmq/vhosts"} {
+		if _, err := os.Stat(filepath.Join(dir, "provider.tf")); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/telemetry"
+)
+
+var (
+	telemetryMu       sync.Mutex
+	telemetryShutdown func(context.Context) error
+)
+
+// setupTelemetry starts exporting telemetry on first import, imports of all regions or projects share it
+func setupTelemetry(options ImportOptions) error {
+	if options.Telemetry == "" {
+		return nil
+	}
+	telemetryMu.Lock()
+	defer telemetryMu.Unlock()
+	if telemetryShutdown != nil {
+		return nil
+	}
+	shutdown, err := telemetry.Setup(context.Background(), options.Telemetry, options.TelemetryFile, version)
+	if err != nil {
+		return fmt.Errorf("failed to set up telemetry: %v", err)
+	}
+	telemetryShutdown = shutdown
+	return nil
+}
+
+// shutdownTelemetry exports remaining spans and metrics
+func shutdownTelemetry() error {
+	telemetryMu.Lock()
+	defer telemetryMu.Unlock()
+	if telemetryShutdown == nil {
+		return nil
+	}
+	err := telemetryShutdown(context.Background())
+	telemetryShutdown = nil
+	return err
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformoutput"
+)
+
+var pathPlaceholder = regexp.MustCompile(`{[^}]+}`)
+
+// terragruntRoot returns directory of root terragrunt.hcl, the part of path pattern shared by all
+// directories of the run. Path pattern is cut before {service} and placeholders resolved by resources,
+// shared is true when all resources are printed to the root directory.
+func terragruntRoot(providerName string, options ImportOptions, scopes map[string]terraformutils.PathScope) (root string, shared bool) {
+	scope := commonScope(scopes)
+	pattern := options.PathPattern
+	shared = true
+	for _, placeholder := range pathPlaceholder.FindAllStringIndex(pattern, -1) {
+		name := strings.Trim(pattern[placeholder[0]:placeholder[1]], "{}")
+		if name == "output" || name == "provider" || scope[name] != "" {
+			continue
+		}
+		pattern = pattern[:strings.LastIndex(pattern[:placeholder[0]], "/")+1]
+		shared = false
+		break
+	}
+	pattern = terraformutils.ResolveResourcePath(pattern, terraformutils.Resource{}, scope)
+	root = Path(pattern, providerName, "", options.PathOutput)
+	if root == "" {
+		root = "./"
+	}
+	return root, shared
+}
+
+// commonScope returns scope values equal for all services
+func commonScope(scopes map[string]terraformutils.PathScope) terraformutils.PathScope {
+	scope := terraformutils.PathScope{}
+	first := true
+	for _, serviceScope := range scopes {
+		if first {
+			for key, value := range serviceScope {
+				scope[key] = value
+			}
+			first = false
+			continue
+		}
+		for key, value := range scope {
+			if serviceScope[key] != value {
+				delete(scope, key)
+			}
+		}
+	}
+	return scope
+}
+
+// printTerragruntRoot writes root terragrunt.hcl keeping state of each directory in the backend of --state
+// and generating provider.tf, unless resources are printed to the root directory
+func printTerragruntRoot(provider terraformutils.ProviderGenerator, options ImportOptions, scopes map[string]terraformutils.PathScope) (string, error) {
+	root, shared := terragruntRoot(provider.GetName(), options, scopes)
+	if shared {
+		return root, nil
+	}
+	config, err := terragruntRootConfig(provider, options, root, false)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return "", err
+	}
+	terraformoutput.PrintFile(filepath.Join(root, "terragrunt.hcl"), terraformutils.PrintTerragruntConfig(config))
+	return root, nil
+}
+
+func terragruntRootConfig(provider terraformutils.ProviderGenerator, options ImportOptions, root string, shared bool) (terraformutils.TerragruntConfig, error) {
+	providerFile, err := terraformoutput.ProviderFile(provider, options.Output)
+	if err != nil {
+		return terraformutils.TerragruntConfig{}, err
+	}
+	remoteState := &terraformutils.TerragruntRemoteState{
+		Backend: "local",
+		Config:  map[string]string{"path": "${get_terragrunt_dir()}/terraform.tfstate"},
+	}
+	if options.State == "bucket" {
+		bucket := terraformoutput.BucketState{
+			Name: options.Bucket,
+		}
+		prefix := bucket.BucketPrefix(root)
+		if !shared {
+			prefix += "/${path_relative_to_include()}"
+		}
+		remoteState = &terraformutils.TerragruntRemoteState{
+			Backend: "gcs",
+			Config: map[string]string{
+				"bucket": strings.ReplaceAll(options.Bucket, "gs://", ""),
+				"prefix": prefix,
+			},
+		}
+	}
+	return terraformutils.TerragruntConfig{
+		RemoteState:  remoteState,
+		ProviderFile: providerFile,
+	}, nil
+}
+
+// printTerragruntService writes terragrunt.hcl of directory of a service. Remote states of other
+// directories become dependencies, passing their outputs to variables referenced by resources instead.
+// It returns remote states still read by data sources and dependencies.
+func printTerragruntService(provider terraformutils.ProviderGenerator, options ImportOptions, path string, resources []terraformutils.Resource,
+	remoteStates map[string]string) (map[string]string, map[string]string, error) {
+	localStates := map[string]string{}
+	dependencies := map[string]string{}
+	for name, statePath := range remoteStates {
+		if filepath.Clean(statePath) == filepath.Clean(path) {
+			localStates[name] = statePath
+			continue
+		}
+		configPath, err := filepath.Rel(path, statePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		dependencies[name] = filepath.ToSlash(configPath)
+	}
+	terraformutils.UseTerragruntDependencies(resources, dependencies)
+
+	config := terraformutils.TerragruntConfig{Include: true}
+	if filepath.Clean(path) == filepath.Clean(options.TerragruntRoot) {
+		var err error
+		if config, err = terragruntRootConfig(provider, options, path, true); err != nil {
+			return nil, nil, err
+		}
+	}
+	config.Dependencies = dependencies
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return nil, nil, err
+	}
+	terraformoutput.PrintFile(filepath.Join(path, "terragrunt.hcl"), terraformutils.PrintTerragruntConfig(config))
+	return localStates, dependencies, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+func TestTerragruntRoot(t *testing.T) {
+	scopes := map[string]terraformutils.PathScope{
+		"vpc":    {"region": "eu-west-1", "account": "123"},
+		"subnet": {"region": "eu-west-1"},
+	}
+	testCases := []struct {
+		pathPattern string
+		root        string
+		shared      bool
+	}{
+		{DefaultPathPattern, "generated/aws/", false},
+		{"{output}/{provider}/{region}/{service}/", "generated/aws/eu-west-1/", false},
+		{"{output}/{provider}/{account}/{service}/", "generated/aws/", false},
+		{"{output}/{provider}/{region}/{tag:team}/{type}", "generated/aws/eu-west-1/", false},
+		{"{output}/{provider}/{region}/", "generated/aws/eu-west-1/", true},
+		{"{service}/", "./", false},
+	}
+	for _, testCase := range testCases {
+		root, shared := terragruntRoot("aws", ImportOptions{PathPattern: testCase.pathPattern, PathOutput: DefaultPathOutput}, scopes)
+		if root != testCase.root || shared != testCase.shared {
+			t.Errorf("%s: expected root %s shared %t, got %s %t", testCase.pathPattern, testCase.root, testCase.shared, root, shared)
+		}
+	}
+}
+
+func TestImportTerragruntCompact(t *testing.T) {
+	isolatePlugins(t)
+	testdataPath := chdirTemp(t)
+
+	options := ImportOptions{
+		Resources:   []string{"vhosts", "queues"},
+		PathPattern: "{output}/{provider}/",
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Connect:     true,
+		Compact:     true,
+		Output:      "hcl",
+		Layout:      "terragrunt",
+	}
+	runImport(t, testdataPath, options)
+
+	generated := readTree(t, DefaultPathOutput)
+	config := generated["rabbitmq/terragrunt.hcl"]
+	if !strings.Contains(config, "remote_state {") || !strings.Contains(config, `generate "provider" {`) ||
+		strings.Contains(config, "include") || strings.Contains(config, "dependency") {
+		t.Errorf("unexpected terragrunt.hcl of single directory:\n%s", config)
+	}
+	if _, exist := generated["rabbitmq/provider.tf"]; exist {
+		t.Error("provider.tf is generated by terragrunt.hcl")
+	}
+	if !strings.Contains(generated["rabbitmq/resources.tf"], "${data.terraform_remote_state.local.outputs.rabbitmq_vhost_tfer--vhost_prod_id}") {
+		t.Errorf("references to local state are changed:\n%s", generated["rabbitmq/resources.tf"])
+	}
+}
+
+func TestImportTerragruntOutput(t *testing.T) {
+	_, err := initOptions(nil, ImportOptions{Layout: "terragrunt", Output: "json"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "isn't supported with json output") {
+		t.Errorf("expected error of unsupported output, got %v", err)
+	}
+}
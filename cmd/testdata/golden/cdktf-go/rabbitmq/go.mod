@@ -0,0 +1,3 @@
+module cdk.tf/go/stack
+
+go 1.16
@@ -0,0 +1,45 @@
+// generated by terraformer
+
+package main
+
+import (
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/hashicorp/terraform-cdk-go/cdktf"
+
+	"cdk.tf/go/stack/generated/hashicorp/rabbitmq"
+)
+
+func NewImportedStack(scope constructs.Construct, name string) cdktf.TerraformStack {
+	stack := cdktf.NewTerraformStack(scope, jsii.String(name))
+	rabbitmq.NewRabbitmqProvider(stack, jsii.String("rabbitmq"), &rabbitmq.RabbitmqProviderConfig{})
+	cdktf.NewDataTerraformRemoteStateLocal(stack, jsii.String("local"), &cdktf.DataTerraformRemoteStateLocalConfig{
+		Path: jsii.String("../../../../generated/rabbitmq/terraform.tfstate"),
+	})
+	addResources(stack)
+	cdktf.NewTerraformOutput(stack, jsii.String("rabbitmq_exchange_tfer--exchange_prod_events_fanout_id"), &cdktf.TerraformOutputConfig{
+		Value: jsii.String("${rabbitmq_exchange.tfer--exchange_prod_events_fanout.id}"),
+	})
+	cdktf.NewTerraformOutput(stack, jsii.String("rabbitmq_exchange_tfer--exchange_slash_orders_topic_id"), &cdktf.TerraformOutputConfig{
+		Value: jsii.String("${rabbitmq_exchange.tfer--exchange_slash_orders_topic.id}"),
+	})
+	cdktf.NewTerraformOutput(stack, jsii.String("rabbitmq_queue_tfer--queue_prod_events_id"), &cdktf.TerraformOutputConfig{
+		Value: jsii.String("${rabbitmq_queue.tfer--queue_prod_events.id}"),
+	})
+	cdktf.NewTerraformOutput(stack, jsii.String("rabbitmq_queue_tfer--queue_slash_orders_id"), &cdktf.TerraformOutputConfig{
+		Value: jsii.String("${rabbitmq_queue.tfer--queue_slash_orders.id}"),
+	})
+	cdktf.NewTerraformOutput(stack, jsii.String("rabbitmq_vhost_tfer--vhost_prod_id"), &cdktf.TerraformOutputConfig{
+		Value: jsii.String("${rabbitmq_vhost.tfer--vhost_prod.id}"),
+	})
+	cdktf.NewTerraformOutput(stack, jsii.String("rabbitmq_vhost_tfer--vhost_slash_id"), &cdktf.TerraformOutputConfig{
+		Value: jsii.String("${rabbitmq_vhost.tfer--vhost_slash.id}"),
+	})
+	return stack
+}
+
+func main() {
+	app := cdktf.NewApp(nil)
+	NewImportedStack(app, "rabbitmq")
+	app.Synth()
+}
@@ -0,0 +1,61 @@
+// generated by terraformer
+
+package main
+
+import (
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+
+	"cdk.tf/go/stack/generated/hashicorp/rabbitmq"
+)
+
+func addResources(scope constructs.Construct) {
+	rabbitmq.NewExchange(scope, jsii.String("tfer--exchange_prod_events_fanout"), &rabbitmq.ExchangeConfig{
+		Name: jsii.String("events.fanout"),
+		Settings: &[]*rabbitmq.ExchangeSettings{
+			&rabbitmq.ExchangeSettings{
+				AutoDelete: jsii.Bool(false),
+				Durable:    jsii.Bool(true),
+				Type:       jsii.String("fanout"),
+			},
+		},
+		Vhost: jsii.String("${data.terraform_remote_state.local.outputs.rabbitmq_vhost_tfer--vhost_prod_id}"),
+	})
+	rabbitmq.NewExchange(scope, jsii.String("tfer--exchange_slash_orders_topic"), &rabbitmq.ExchangeConfig{
+		Name: jsii.String("orders.topic"),
+		Settings: &[]*rabbitmq.ExchangeSettings{
+			&rabbitmq.ExchangeSettings{
+				AutoDelete: jsii.Bool(false),
+				Durable:    jsii.Bool(true),
+				Type:       jsii.String("topic"),
+			},
+		},
+		Vhost: jsii.String("${data.terraform_remote_state.local.outputs.rabbitmq_vhost_tfer--vhost_slash_id}"),
+	})
+	rabbitmq.NewQueue(scope, jsii.String("tfer--queue_prod_events"), &rabbitmq.QueueConfig{
+		Name: jsii.String("events"),
+		Settings: &[]*rabbitmq.QueueSettings{
+			&rabbitmq.QueueSettings{
+				AutoDelete: jsii.Bool(false),
+				Durable:    jsii.Bool(true),
+			},
+		},
+		Vhost: jsii.String("${data.terraform_remote_state.local.outputs.rabbitmq_vhost_tfer--vhost_prod_id}"),
+	})
+	rabbitmq.NewQueue(scope, jsii.String("tfer--queue_slash_orders"), &rabbitmq.QueueConfig{
+		Name: jsii.String("orders"),
+		Settings: &[]*rabbitmq.QueueSettings{
+			&rabbitmq.QueueSettings{
+				AutoDelete: jsii.Bool(true),
+				Durable:    jsii.Bool(false),
+			},
+		},
+		Vhost: jsii.String("${data.terraform_remote_state.local.outputs.rabbitmq_vhost_tfer--vhost_slash_id}"),
+	})
+	rabbitmq.NewVhost(scope, jsii.String("tfer--vhost_prod"), &rabbitmq.VhostConfig{
+		Name: jsii.String("prod"),
+	})
+	rabbitmq.NewVhost(scope, jsii.String("tfer--vhost_slash"), &rabbitmq.VhostConfig{
+		Name: jsii.String("/"),
+	})
+}
@@ -0,0 +1,46 @@
+# generated by terraformer
+from constructs import Construct
+import cdktf
+import imports.rabbitmq as rabbitmq
+from resources.exchange import add_exchange
+
+
+class ImportedStack(cdktf.TerraformStack):
+    def __init__(self, scope: Construct, name: str):
+        super().__init__(scope, name)
+
+        rabbitmq.RabbitmqProvider(
+            self,
+            "rabbitmq",
+        )
+        cdktf.DataTerraformRemoteStateLocal(
+            self,
+            "vhosts",
+            path="../../../../../generated/rabbitmq/vhosts/terraform.tfstate",
+        )
+        add_exchange(self)
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_exchange_tfer--exchange_prod_events_fanout_id",
+            value="${rabbitmq_exchange.tfer--exchange_prod_events_fanout.id}",
+        )
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_exchange_tfer--exchange_prod_events_fanout_name",
+            value="${rabbitmq_exchange.tfer--exchange_prod_events_fanout.name}",
+        )
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_exchange_tfer--exchange_slash_orders_topic_id",
+            value="${rabbitmq_exchange.tfer--exchange_slash_orders_topic.id}",
+        )
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_exchange_tfer--exchange_slash_orders_topic_name",
+            value="${rabbitmq_exchange.tfer--exchange_slash_orders_topic.name}",
+        )
+
+
+app = cdktf.App()
+ImportedStack(app, "rabbitmq-exchanges")
+app.synth()
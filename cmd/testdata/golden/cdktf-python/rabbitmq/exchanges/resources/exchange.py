@@ -0,0 +1,32 @@
+# generated by terraformer
+from constructs import Construct
+import imports.rabbitmq as rabbitmq
+
+
+def add_exchange(scope: Construct):
+    rabbitmq.Exchange(
+        scope,
+        "tfer--exchange_prod_events_fanout",
+        name="events.fanout",
+        settings=[
+            rabbitmq.ExchangeSettings(
+                auto_delete=False,
+                durable=True,
+                type="fanout",
+            ),
+        ],
+        vhost="${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_prod_id}",
+    )
+    rabbitmq.Exchange(
+        scope,
+        "tfer--exchange_slash_orders_topic",
+        name="orders.topic",
+        settings=[
+            rabbitmq.ExchangeSettings(
+                auto_delete=False,
+                durable=True,
+                type="topic",
+            ),
+        ],
+        vhost="${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_slash_id}",
+    )
@@ -0,0 +1,46 @@
+# generated by terraformer
+from constructs import Construct
+import cdktf
+import imports.rabbitmq as rabbitmq
+from resources.queue import add_queue
+
+
+class ImportedStack(cdktf.TerraformStack):
+    def __init__(self, scope: Construct, name: str):
+        super().__init__(scope, name)
+
+        rabbitmq.RabbitmqProvider(
+            self,
+            "rabbitmq",
+        )
+        cdktf.DataTerraformRemoteStateLocal(
+            self,
+            "vhosts",
+            path="../../../../../generated/rabbitmq/vhosts/terraform.tfstate",
+        )
+        add_queue(self)
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_queue_tfer--queue_prod_events_id",
+            value="${rabbitmq_queue.tfer--queue_prod_events.id}",
+        )
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_queue_tfer--queue_prod_events_name",
+            value="${rabbitmq_queue.tfer--queue_prod_events.name}",
+        )
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_queue_tfer--queue_slash_orders_id",
+            value="${rabbitmq_queue.tfer--queue_slash_orders.id}",
+        )
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_queue_tfer--queue_slash_orders_name",
+            value="${rabbitmq_queue.tfer--queue_slash_orders.name}",
+        )
+
+
+app = cdktf.App()
+ImportedStack(app, "rabbitmq-queues")
+app.synth()
@@ -0,0 +1,30 @@
+# generated by terraformer
+from constructs import Construct
+import imports.rabbitmq as rabbitmq
+
+
+def add_queue(scope: Construct):
+    rabbitmq.Queue(
+        scope,
+        "tfer--queue_prod_events",
+        name="events",
+        settings=[
+            rabbitmq.QueueSettings(
+                auto_delete=False,
+                durable=True,
+            ),
+        ],
+        vhost="${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_prod_id}",
+    )
+    rabbitmq.Queue(
+        scope,
+        "tfer--queue_slash_orders",
+        name="orders",
+        settings=[
+            rabbitmq.QueueSettings(
+                auto_delete=True,
+                durable=False,
+            ),
+        ],
+        vhost="${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_slash_id}",
+    )
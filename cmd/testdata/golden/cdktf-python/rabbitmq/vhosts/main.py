@@ -0,0 +1,31 @@
+# generated by terraformer
+from constructs import Construct
+import cdktf
+import imports.rabbitmq as rabbitmq
+from resources.vhost import add_vhost
+
+
+class ImportedStack(cdktf.TerraformStack):
+    def __init__(self, scope: Construct, name: str):
+        super().__init__(scope, name)
+
+        rabbitmq.RabbitmqProvider(
+            self,
+            "rabbitmq",
+        )
+        add_vhost(self)
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_vhost_tfer--vhost_prod_id",
+            value="${rabbitmq_vhost.tfer--vhost_prod.id}",
+        )
+        cdktf.TerraformOutput(
+            self,
+            "rabbitmq_vhost_tfer--vhost_slash_id",
+            value="${rabbitmq_vhost.tfer--vhost_slash.id}",
+        )
+
+
+app = cdktf.App()
+ImportedStack(app, "rabbitmq-vhosts")
+app.synth()
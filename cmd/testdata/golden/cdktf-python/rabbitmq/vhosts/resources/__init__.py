@@ -0,0 +1 @@
+# generated by terraformer
@@ -0,0 +1,16 @@
+# generated by terraformer
+from constructs import Construct
+import imports.rabbitmq as rabbitmq
+
+
+def add_vhost(scope: Construct):
+    rabbitmq.Vhost(
+        scope,
+        "tfer--vhost_prod",
+        name="prod",
+    )
+    rabbitmq.Vhost(
+        scope,
+        "tfer--vhost_slash",
+        name="/",
+    )
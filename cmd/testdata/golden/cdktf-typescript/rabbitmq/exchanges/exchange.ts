@@ -0,0 +1,28 @@
+// generated by terraformer
+import { Construct } from "constructs";
+import * as rabbitmq from "./.gen/providers/rabbitmq";
+
+export function addExchange(scope: Construct) {
+  new rabbitmq.Exchange(scope, "tfer--exchange_prod_events_fanout", {
+    name: "events.fanout",
+    settings: [
+      {
+        autoDelete: false,
+        durable: true,
+        type: "fanout",
+      },
+    ],
+    vhost: "${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_prod_id}",
+  });
+  new rabbitmq.Exchange(scope, "tfer--exchange_slash_orders_topic", {
+    name: "orders.topic",
+    settings: [
+      {
+        autoDelete: false,
+        durable: true,
+        type: "topic",
+      },
+    ],
+    vhost: "${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_slash_id}",
+  });
+}
@@ -0,0 +1,33 @@
+// generated by terraformer
+import { Construct } from "constructs";
+import * as cdktf from "cdktf";
+import * as rabbitmq from "./.gen/providers/rabbitmq";
+import { addExchange } from "./exchange";
+
+class ImportedStack extends cdktf.TerraformStack {
+  constructor(scope: Construct, name: string) {
+    super(scope, name);
+
+    new rabbitmq.RabbitmqProvider(this, "rabbitmq", {});
+    new cdktf.DataTerraformRemoteStateLocal(this, "vhosts", {
+      path: "../../../../../generated/rabbitmq/vhosts/terraform.tfstate",
+    });
+    addExchange(this);
+    new cdktf.TerraformOutput(this, "rabbitmq_exchange_tfer--exchange_prod_events_fanout_id", {
+      value: "${rabbitmq_exchange.tfer--exchange_prod_events_fanout.id}",
+    });
+    new cdktf.TerraformOutput(this, "rabbitmq_exchange_tfer--exchange_prod_events_fanout_name", {
+      value: "${rabbitmq_exchange.tfer--exchange_prod_events_fanout.name}",
+    });
+    new cdktf.TerraformOutput(this, "rabbitmq_exchange_tfer--exchange_slash_orders_topic_id", {
+      value: "${rabbitmq_exchange.tfer--exchange_slash_orders_topic.id}",
+    });
+    new cdktf.TerraformOutput(this, "rabbitmq_exchange_tfer--exchange_slash_orders_topic_name", {
+      value: "${rabbitmq_exchange.tfer--exchange_slash_orders_topic.name}",
+    });
+  }
+}
+
+const app = new cdktf.App();
+new ImportedStack(app, "rabbitmq-exchanges");
+app.synth();
@@ -0,0 +1,33 @@
+// generated by terraformer
+import { Construct } from "constructs";
+import * as cdktf from "cdktf";
+import * as rabbitmq from "./.gen/providers/rabbitmq";
+import { addQueue } from "./queue";
+
+class ImportedStack extends cdktf.TerraformStack {
+  constructor(scope: Construct, name: string) {
+    super(scope, name);
+
+    new rabbitmq.RabbitmqProvider(this, "rabbitmq", {});
+    new cdktf.DataTerraformRemoteStateLocal(this, "vhosts", {
+      path: "../../../../../generated/rabbitmq/vhosts/terraform.tfstate",
+    });
+    addQueue(this);
+    new cdktf.TerraformOutput(this, "rabbitmq_queue_tfer--queue_prod_events_id", {
+      value: "${rabbitmq_queue.tfer--queue_prod_events.id}",
+    });
+    new cdktf.TerraformOutput(this, "rabbitmq_queue_tfer--queue_prod_events_name", {
+      value: "${rabbitmq_queue.tfer--queue_prod_events.name}",
+    });
+    new cdktf.TerraformOutput(this, "rabbitmq_queue_tfer--queue_slash_orders_id", {
+      value: "${rabbitmq_queue.tfer--queue_slash_orders.id}",
+    });
+    new cdktf.TerraformOutput(this, "rabbitmq_queue_tfer--queue_slash_orders_name", {
+      value: "${rabbitmq_queue.tfer--queue_slash_orders.name}",
+    });
+  }
+}
+
+const app = new cdktf.App();
+new ImportedStack(app, "rabbitmq-queues");
+app.synth();
@@ -0,0 +1,26 @@
+// generated by terraformer
+import { Construct } from "constructs";
+import * as rabbitmq from "./.gen/providers/rabbitmq";
+
+export function addQueue(scope: Construct) {
+  new rabbitmq.Queue(scope, "tfer--queue_prod_events", {
+    name: "events",
+    settings: [
+      {
+        autoDelete: false,
+        durable: true,
+      },
+    ],
+    vhost: "${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_prod_id}",
+  });
+  new rabbitmq.Queue(scope, "tfer--queue_slash_orders", {
+    name: "orders",
+    settings: [
+      {
+        autoDelete: true,
+        durable: false,
+      },
+    ],
+    vhost: "${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_slash_id}",
+  });
+}
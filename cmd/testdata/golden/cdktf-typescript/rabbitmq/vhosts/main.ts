@@ -0,0 +1,24 @@
+// generated by terraformer
+import { Construct } from "constructs";
+import * as cdktf from "cdktf";
+import * as rabbitmq from "./.gen/providers/rabbitmq";
+import { addVhost } from "./vhost";
+
+class ImportedStack extends cdktf.TerraformStack {
+  constructor(scope: Construct, name: string) {
+    super(scope, name);
+
+    new rabbitmq.RabbitmqProvider(this, "rabbitmq", {});
+    addVhost(this);
+    new cdktf.TerraformOutput(this, "rabbitmq_vhost_tfer--vhost_prod_id", {
+      value: "${rabbitmq_vhost.tfer--vhost_prod.id}",
+    });
+    new cdktf.TerraformOutput(this, "rabbitmq_vhost_tfer--vhost_slash_id", {
+      value: "${rabbitmq_vhost.tfer--vhost_slash.id}",
+    });
+  }
+}
+
+const app = new cdktf.App();
+new ImportedStack(app, "rabbitmq-vhosts");
+app.synth();
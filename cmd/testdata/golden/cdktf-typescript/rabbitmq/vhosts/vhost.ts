@@ -0,0 +1,12 @@
+// generated by terraformer
+import { Construct } from "constructs";
+import * as rabbitmq from "./.gen/providers/rabbitmq";
+
+export function addVhost(scope: Construct) {
+  new rabbitmq.Vhost(scope, "tfer--vhost_prod", {
+    name: "prod",
+  });
+  new rabbitmq.Vhost(scope, "tfer--vhost_slash", {
+    name: "/",
+  });
+}
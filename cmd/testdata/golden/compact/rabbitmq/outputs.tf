@@ -0,0 +1,23 @@
+output "rabbitmq_exchange_tfer--exchange_prod_events_fanout_id" {
+  value = "${rabbitmq_exchange.tfer--exchange_prod_events_fanout.id}"
+}
+
+output "rabbitmq_exchange_tfer--exchange_slash_orders_topic_id" {
+  value = "${rabbitmq_exchange.tfer--exchange_slash_orders_topic.id}"
+}
+
+output "rabbitmq_queue_tfer--queue_prod_events_id" {
+  value = "${rabbitmq_queue.tfer--queue_prod_events.id}"
+}
+
+output "rabbitmq_queue_tfer--queue_slash_orders_id" {
+  value = "${rabbitmq_queue.tfer--queue_slash_orders.id}"
+}
+
+output "rabbitmq_vhost_tfer--vhost_prod_id" {
+  value = "${rabbitmq_vhost.tfer--vhost["tfer--vhost_prod"].id}"
+}
+
+output "rabbitmq_vhost_tfer--vhost_slash_id" {
+  value = "${rabbitmq_vhost.tfer--vhost["tfer--vhost_slash"].id}"
+}
@@ -0,0 +1,61 @@
+locals {
+  tfer--vhost = {
+    tfer--vhost_prod = {
+      name = "prod"
+    }
+    tfer--vhost_slash = {
+      name = "/"
+    }
+  }
+}
+
+resource "rabbitmq_exchange" "tfer--exchange_prod_events_fanout" {
+  name = "events.fanout"
+
+  settings {
+    auto_delete = false
+    durable     = true
+    type        = "fanout"
+  }
+
+  vhost = "${data.terraform_remote_state.local.outputs.rabbitmq_vhost_tfer--vhost_prod_id}"
+}
+
+resource "rabbitmq_exchange" "tfer--exchange_slash_orders_topic" {
+  name = "orders.topic"
+
+  settings {
+    auto_delete = false
+    durable     = true
+    type        = "topic"
+  }
+
+  vhost = "${data.terraform_remote_state.local.outputs.rabbitmq_vhost_tfer--vhost_slash_id}"
+}
+
+resource "rabbitmq_queue" "tfer--queue_prod_events" {
+  name = "events"
+
+  settings {
+    auto_delete = false
+    durable     = true
+  }
+
+  vhost = "${data.terraform_remote_state.local.outputs.rabbitmq_vhost_tfer--vhost_prod_id}"
+}
+
+resource "rabbitmq_queue" "tfer--queue_slash_orders" {
+  name = "orders"
+
+  settings {
+    auto_delete = true
+    durable     = false
+  }
+
+  vhost = "${data.terraform_remote_state.local.outputs.rabbitmq_vhost_tfer--vhost_slash_id}"
+}
+
+resource "rabbitmq_vhost" "tfer--vhost" {
+  for_each = "${local.tfer--vhost}"
+  name     = "${each.value.name}"
+}
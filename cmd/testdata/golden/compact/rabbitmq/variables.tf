@@ -0,0 +1,7 @@
+data "terraform_remote_state" "local" {
+  backend = "local"
+
+  config = {
+    path = "terraform.tfstate"
+  }
+}
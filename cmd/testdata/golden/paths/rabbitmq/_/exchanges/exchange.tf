@@ -0,0 +1,11 @@
+resource "rabbitmq_exchange" "tfer--exchange_slash_orders_topic" {
+  name = "orders.topic"
+
+  settings {
+    auto_delete = false
+    durable     = true
+    type        = "topic"
+  }
+
+  vhost = "${data.terraform_remote_state.generated_rabbitmq_vhosts.outputs.rabbitmq_vhost_tfer--vhost_slash_id}"
+}
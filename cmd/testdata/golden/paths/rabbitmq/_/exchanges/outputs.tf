@@ -0,0 +1,7 @@
+output "rabbitmq_exchange_tfer--exchange_slash_orders_topic_id" {
+  value = "${rabbitmq_exchange.tfer--exchange_slash_orders_topic.id}"
+}
+
+output "rabbitmq_exchange_tfer--exchange_slash_orders_topic_name" {
+  value = "${rabbitmq_exchange.tfer--exchange_slash_orders_topic.name}"
+}
@@ -0,0 +1,7 @@
+output "rabbitmq_queue_tfer--queue_slash_orders_id" {
+  value = "${rabbitmq_queue.tfer--queue_slash_orders.id}"
+}
+
+output "rabbitmq_queue_tfer--queue_slash_orders_name" {
+  value = "${rabbitmq_queue.tfer--queue_slash_orders.name}"
+}
@@ -0,0 +1,10 @@
+resource "rabbitmq_queue" "tfer--queue_slash_orders" {
+  name = "orders"
+
+  settings {
+    auto_delete = true
+    durable     = false
+  }
+
+  vhost = "${data.terraform_remote_state.generated_rabbitmq_vhosts.outputs.rabbitmq_vhost_tfer--vhost_slash_id}"
+}
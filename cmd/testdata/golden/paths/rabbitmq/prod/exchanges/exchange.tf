@@ -0,0 +1,11 @@
+resource "rabbitmq_exchange" "tfer--exchange_prod_events_fanout" {
+  name = "events.fanout"
+
+  settings {
+    auto_delete = false
+    durable     = true
+    type        = "fanout"
+  }
+
+  vhost = "${data.terraform_remote_state.generated_rabbitmq_vhosts.outputs.rabbitmq_vhost_tfer--vhost_prod_id}"
+}
@@ -0,0 +1,7 @@
+output "rabbitmq_exchange_tfer--exchange_prod_events_fanout_id" {
+  value = "${rabbitmq_exchange.tfer--exchange_prod_events_fanout.id}"
+}
+
+output "rabbitmq_exchange_tfer--exchange_prod_events_fanout_name" {
+  value = "${rabbitmq_exchange.tfer--exchange_prod_events_fanout.name}"
+}
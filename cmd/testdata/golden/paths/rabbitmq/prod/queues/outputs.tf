@@ -0,0 +1,7 @@
+output "rabbitmq_queue_tfer--queue_prod_events_id" {
+  value = "${rabbitmq_queue.tfer--queue_prod_events.id}"
+}
+
+output "rabbitmq_queue_tfer--queue_prod_events_name" {
+  value = "${rabbitmq_queue.tfer--queue_prod_events.name}"
+}
@@ -0,0 +1,10 @@
+resource "rabbitmq_queue" "tfer--queue_prod_events" {
+  name = "events"
+
+  settings {
+    auto_delete = false
+    durable     = true
+  }
+
+  vhost = "${data.terraform_remote_state.generated_rabbitmq_vhosts.outputs.rabbitmq_vhost_tfer--vhost_prod_id}"
+}
@@ -0,0 +1,7 @@
+data "terraform_remote_state" "generated_rabbitmq_vhosts" {
+  backend = "local"
+
+  config = {
+    path = "../../../../generated/rabbitmq/vhosts/terraform.tfstate"
+  }
+}
@@ -0,0 +1,23 @@
+resource "rabbitmq_exchange" "tfer--exchange_prod_events_fanout" {
+  name = "events.fanout"
+
+  settings {
+    auto_delete = false
+    durable     = true
+    type        = "fanout"
+  }
+
+  vhost = "${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_prod_id}"
+}
+
+resource "rabbitmq_exchange" "tfer--exchange_slash_orders_topic" {
+  name = "orders.topic"
+
+  settings {
+    auto_delete = false
+    durable     = true
+    type        = "topic"
+  }
+
+  vhost = "${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_slash_id}"
+}
@@ -0,0 +1,15 @@
+output "rabbitmq_exchange_tfer--exchange_prod_events_fanout_id" {
+  value = "${rabbitmq_exchange.tfer--exchange_prod_events_fanout.id}"
+}
+
+output "rabbitmq_exchange_tfer--exchange_prod_events_fanout_name" {
+  value = "${rabbitmq_exchange.tfer--exchange_prod_events_fanout.name}"
+}
+
+output "rabbitmq_exchange_tfer--exchange_slash_orders_topic_id" {
+  value = "${rabbitmq_exchange.tfer--exchange_slash_orders_topic.id}"
+}
+
+output "rabbitmq_exchange_tfer--exchange_slash_orders_topic_name" {
+  value = "${rabbitmq_exchange.tfer--exchange_slash_orders_topic.name}"
+}
@@ -0,0 +1,15 @@
+output "rabbitmq_queue_tfer--queue_prod_events_id" {
+  value = "${rabbitmq_queue.tfer--queue_prod_events.id}"
+}
+
+output "rabbitmq_queue_tfer--queue_prod_events_name" {
+  value = "${rabbitmq_queue.tfer--queue_prod_events.name}"
+}
+
+output "rabbitmq_queue_tfer--queue_slash_orders_id" {
+  value = "${rabbitmq_queue.tfer--queue_slash_orders.id}"
+}
+
+output "rabbitmq_queue_tfer--queue_slash_orders_name" {
+  value = "${rabbitmq_queue.tfer--queue_slash_orders.name}"
+}
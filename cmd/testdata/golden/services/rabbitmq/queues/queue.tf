@@ -0,0 +1,21 @@
+resource "rabbitmq_queue" "tfer--queue_prod_events" {
+  name = "events"
+
+  settings {
+    auto_delete = false
+    durable     = true
+  }
+
+  vhost = "${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_prod_id}"
+}
+
+resource "rabbitmq_queue" "tfer--queue_slash_orders" {
+  name = "orders"
+
+  settings {
+    auto_delete = true
+    durable     = false
+  }
+
+  vhost = "${data.terraform_remote_state.vhosts.outputs.rabbitmq_vhost_tfer--vhost_slash_id}"
+}
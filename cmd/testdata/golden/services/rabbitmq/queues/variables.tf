@@ -0,0 +1,7 @@
+data "terraform_remote_state" "vhosts" {
+  backend = "local"
+
+  config = {
+    path = "../../../generated/rabbitmq/vhosts/terraform.tfstate"
+  }
+}
@@ -0,0 +1,3 @@
+variable "vhosts" {
+  type = map(string)
+}
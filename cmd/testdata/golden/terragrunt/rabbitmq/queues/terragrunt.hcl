@@ -0,0 +1,11 @@
+include "root" {
+  path = find_in_parent_folders()
+}
+
+dependency "vhosts" {
+  config_path = "../vhosts"
+}
+
+inputs = {
+  vhosts = dependency.vhosts.outputs
+}
@@ -0,0 +1,24 @@
+remote_state {
+  backend = "local"
+  config = {
+    path = "${get_terragrunt_dir()}/terraform.tfstate"
+  }
+  generate = {
+    path      = "backend.tf"
+    if_exists = "overwrite_terragrunt"
+  }
+}
+
+generate "provider" {
+  path      = "provider.tf"
+  if_exists = "overwrite_terragrunt"
+  contents  = <<EOF
+terraform {
+	required_providers {
+		rabbitmq = {
+	    version = ""
+		}
+  }
+}
+EOF
+}
@@ -0,0 +1,7 @@
+output "rabbitmq_vhost_tfer--vhost_prod_id" {
+  value = "${rabbitmq_vhost.tfer--vhost_prod.id}"
+}
+
+output "rabbitmq_vhost_tfer--vhost_slash_id" {
+  value = "${rabbitmq_vhost.tfer--vhost_slash.id}"
+}
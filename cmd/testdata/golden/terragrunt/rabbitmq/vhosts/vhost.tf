@@ -0,0 +1,7 @@
+resource "rabbitmq_vhost" "tfer--vhost_prod" {
+  name = "prod"
+}
+
+resource "rabbitmq_vhost" "tfer--vhost_slash" {
+  name = "/"
+}
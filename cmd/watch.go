@@ -0,0 +1,345 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const watchChangelogFile = "CHANGELOG.md"
+
+// WatchSummary is posted to webhooks when resources of an import differ from the previous import
+type WatchSummary struct {
+	Provider string    `json:"provider"`
+	Time     time.Time `json:"time"`
+	terraformutils.Drift
+}
+
+// watcher runs imports on an interval and reports changes of resources between snapshots of the imports.
+// The snapshot of the last import is kept in dir, so the next watch compares with it after a restart.
+type watcher struct {
+	dir           string
+	interval      time.Duration
+	runs          int
+	webhooks      []string
+	webhookFormat string
+	client        *http.Client
+	// snapshot is the directory of snapshot of the running import, set to options of imports by Import
+	snapshot string
+	// ctx of the watch stops the running import when the watch is interrupted
+	ctx context.Context
+}
+
+func newWatchCmd() *cobra.Command {
+	w := &watcher{client: &http.Client{Timeout: 30 * time.Second}}
+	options := ImportOptions{
+		Watch: w,
+	}
+	cmd := &cobra.Command{
+		Use:           "watch",
+		Short:         "Import on an interval and report changes of resources",
+		Long:          "Import on an interval and report changes of resources to a changelog and webhooks",
+		SilenceUsage:  true,
+		SilenceErrors: false,
+	}
+	flag := cmd.PersistentFlags()
+	flag.DurationVarP(&w.interval, "interval", "", time.Hour, "time between starts of imports")
+	flag.IntVarP(&w.runs, "runs", "", 0, "number of imports, 0 imports until interrupted")
+	flag.StringVarP(&w.dir, "watch-dir", "", "terraformer-watch", "directory of snapshot of the last import and of the changelog")
+	flag.StringSliceVarP(&w.webhooks, "webhook", "", []string{}, "https://hooks.example.com/drift - POST summary of changes to URLs")
+	flag.StringVarP(&w.webhookFormat, "webhook-format", "", "json", "json or slack")
+
+	for _, subcommand := range providerImporterSubcommands() {
+		cmd.AddCommand(newWatchProviderCmd(w, subcommand, options))
+	}
+	return cmd
+}
+
+// newWatchProviderCmd returns command of provider importing on the interval of w. Commands of providers may change
+// their options while importing, e.g. AWS keeps only regional resources after importing the global ones,
+// so each import runs a new command of the provider with the flags given to the watch.
+func newWatchProviderCmd(w *watcher, subcommand func(ImportOptions) *cobra.Command, options ImportOptions) *cobra.Command {
+	providerCommand := subcommand(options)
+	providerCommand.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return w.watch(ctx, func(snapshot string) error {
+			w.snapshot = snapshot
+			run := subcommand(options)
+			if err := copyChangedFlags(cmd.Flags(), run); err != nil {
+				return err
+			}
+			return run.RunE(run, args)
+		})
+	}
+	_ = providerCommand.MarkPersistentFlagRequired("resources")
+	// snapshots of imports are recorded to the watch directory
+	_ = providerCommand.PersistentFlags().MarkHidden("snapshot")
+	return providerCommand
+}
+
+// copyChangedFlags sets flags changed in flags to the same flags of cmd, flags cmd doesn't have, like flags of
+// the watch, are skipped
+func copyChangedFlags(flags *pflag.FlagSet, cmd *cobra.Command) error {
+	var err error
+	flags.Visit(func(flag *pflag.Flag) {
+		target := cmd.Flags().Lookup(flag.Name)
+		if target == nil {
+			target = cmd.PersistentFlags().Lookup(flag.Name)
+		}
+		if target == nil || err != nil {
+			return
+		}
+		source, isSlice := flag.Value.(pflag.SliceValue)
+		targetSlice, isTargetSlice := target.Value.(pflag.SliceValue)
+		if isSlice && isTargetSlice {
+			err = targetSlice.Replace(source.GetSlice())
+		} else {
+			err = target.Value.Set(flag.Value.String())
+		}
+		target.Changed = true
+	})
+	return err
+}
+
+// watch runs importSnapshot until ctx is done or runs are done, importSnapshot records snapshot to the given directory.
+// Failed imports are logged and retried on the next interval, error of the last run is returned.
+func (w *watcher) watch(ctx context.Context, importSnapshot func(snapshot string) error) error {
+	if w.interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if w.webhookFormat != "json" && w.webhookFormat != "slack" {
+		return fmt.Errorf("unknown webhook format %s, use json or slack", w.webhookFormat)
+	}
+	w.ctx = ctx
+	for run := 1; ; run++ {
+		started := time.Now()
+		err := w.run(importSnapshot)
+		if ctx.Err() != nil {
+			log.Printf("watch interrupted")
+			return nil
+		}
+		if err != nil {
+			log.Printf("watch import failed: %v", err)
+		}
+		if w.runs > 0 && run >= w.runs {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(started.Add(w.interval))):
+		}
+	}
+}
+
+// run imports a new snapshot and compares it with the previous one, which it replaces before webhooks are
+// called, so a failed webhook doesn't report the same changes again on the next run
+func (w *watcher) run(importSnapshot func(snapshot string) error) error {
+	previousDir := filepath.Join(w.dir, "snapshot")
+	currentDir := filepath.Join(w.dir, "snapshot.next")
+	if err := os.RemoveAll(currentDir); err != nil {
+		return err
+	}
+	err := importSnapshot(currentDir)
+	forgetSnapshot(currentDir)
+	if err != nil {
+		return err
+	}
+	current, err := LoadSnapshot(currentDir)
+	if err != nil {
+		return err
+	}
+
+	var changes *WatchSummary
+	previous, err := LoadSnapshot(previousDir)
+	switch {
+	case os.IsNotExist(err):
+		log.Printf("watch recorded first snapshot of %s to %s", current.Provider, previousDir)
+	case err != nil:
+		return err
+	default:
+		summary := WatchSummary{
+			Provider: current.Provider,
+			Time:     importTime().UTC(),
+			Drift:    terraformutils.DiffResources(snapshotResources(previous), snapshotResources(current)),
+		}
+		if summary.Empty() {
+			log.Printf("watch found no changes of %s", current.Provider)
+			break
+		}
+		log.Printf("watch found %d added, %d removed and %d changed resources of %s",
+			len(summary.Added), len(summary.Removed), len(summary.Changed), current.Provider)
+		if err := appendChangelog(filepath.Join(w.dir, watchChangelogFile), summary); err != nil {
+			return err
+		}
+		changes = &summary
+	}
+
+	if err := os.RemoveAll(previousDir); err != nil {
+		return err
+	}
+	if err := os.Rename(currentDir, previousDir); err != nil {
+		return err
+	}
+	if changes != nil {
+		// changes are in the changelog, webhooks aren't retried
+		if err := w.notify(*changes); err != nil {
+			log.Printf("WARN: watch failed to notify webhooks: %v", err)
+		}
+	}
+	return nil
+}
+
+// snapshotResources returns resources of all imports of snapshot with alias of provider configuration of their import
+func snapshotResources(snapshot *Snapshot) []terraformutils.Resource {
+	var resources []terraformutils.Resource
+	for _, recorded := range snapshot.Imports {
+		for _, serviceResources := range recorded.Resources {
+			for _, snapshotResource := range serviceResources {
+				r := snapshotResource.Resource
+				r.ProviderAlias = recorded.ProviderAlias
+				resources = append(resources, r)
+			}
+		}
+	}
+	return resources
+}
+
+func appendChangelog(path string, summary WatchSummary) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(changelogEntry(summary))
+	return err
+}
+
+// changelogEntry returns markdown section of changes
+func changelogEntry(summary WatchSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s %s\n", summary.Provider, summary.Time.Format(time.RFC3339))
+	for _, section := range []struct {
+		title   string
+		changes []terraformutils.ResourceChange
+	}{
+		{"Added", summary.Added},
+		{"Removed", summary.Removed},
+		{"Changed", summary.Changed},
+	} {
+		if len(section.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section.title)
+		for _, change := range section.changes {
+			fmt.Fprintf(&b, "* %s\n", describeChange(change, "`"))
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// describeChange describes resource and its changed attributes, code is quote of address and attributes
+func describeChange(change terraformutils.ResourceChange, code string) string {
+	description := code + change.Address + code + " " + change.ID
+	if strings.Contains(change.Provider, ".") {
+		description += " of " + change.Provider
+	}
+	if len(change.Attributes) > 0 {
+		description += ": " + code + strings.Join(change.Attributes, code+", "+code) + code
+	}
+	return description
+}
+
+// slackMessage returns summary as Slack incoming webhook message
+func slackMessage(summary WatchSummary) map[string]string {
+	lines := []string{fmt.Sprintf("*Terraformer detected changes of %s resources* at %s",
+		summary.Provider, summary.Time.Format(time.RFC3339))}
+	for _, section := range []struct {
+		title   string
+		changes []terraformutils.ResourceChange
+	}{
+		{"added", summary.Added},
+		{"removed", summary.Removed},
+		{"changed", summary.Changed},
+	} {
+		for _, change := range section.changes {
+			lines = append(lines, fmt.Sprintf("• %s %s", section.title, describeChange(change, "`")))
+		}
+	}
+	return map[string]string{"text": strings.Join(lines, "\n")}
+}
+
+// notify posts summary to all webhooks, a failed webhook doesn't stop posting to others
+func (w *watcher) notify(summary WatchSummary) error {
+	var payload interface{} = summary
+	if w.webhookFormat == "slack" {
+		payload = slackMessage(summary)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	// URLs of webhooks like of Slack are secrets, only their hosts are logged
+	var failed []string
+	for _, webhook := range w.webhooks {
+		if err := w.post(webhook, body); err != nil {
+			log.Printf("watch failed to notify webhook: %v", err)
+			failed = append(failed, webhookHost(webhook))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to notify webhooks %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (w *watcher) post(webhook string, body []byte) error {
+	resp, err := w.client.Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook %s: %v", webhookHost(webhook), errors.Unwrap(err))
+	}
+	defer resp.Body.Close()
+	_, _ = ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded %s", webhookHost(webhook), resp.Status)
+	}
+	return nil
+}
+
+func webhookHost(webhook string) string {
+	if u, err := url.Parse(webhook); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "with invalid URL"
+}
@@ -0,0 +1,341 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/spf13/cobra"
+)
+
+// webhookReceiver records bodies of requests
+type webhookReceiver struct {
+	*httptest.Server
+	mutex  sync.Mutex
+	bodies [][]byte
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	receiver := &webhookReceiver{}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil || r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		receiver.mutex.Lock()
+		defer receiver.mutex.Unlock()
+		receiver.bodies = append(receiver.bodies, body)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func (r *webhookReceiver) received() [][]byte {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([][]byte{}, r.bodies...)
+}
+
+func TestWatch(t *testing.T) {
+	testdataPath := chdirTemp(t)
+	defer func() {
+		importTime = time.Now
+	}()
+	importTime = func() time.Time {
+		return time.Date(2021, 5, 6, 7, 8, 9, 0, time.UTC)
+	}
+
+	receiver := newWebhookReceiver(t)
+	w := &watcher{
+		dir:           "watch",
+		interval:      time.Millisecond,
+		runs:          3,
+		webhooks:      []string{receiver.URL},
+		webhookFormat: "json",
+		client:        receiver.Client(),
+	}
+	options := ImportOptions{
+		Resources:   []string{"vhosts", "queues"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Connect:     true,
+		Output:      "hcl",
+	}
+	run := 0
+	err := w.watch(context.Background(), func(snapshot string) error {
+		run++
+		if run == 2 {
+			// resources of the cassette don't change, so the previous snapshot is changed instead
+			changePreviousSnapshot(t, filepath.Join("watch", "snapshot"))
+		}
+		options.Snapshot = snapshot
+		runImport(t, testdataPath, options)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first import is the baseline and the third doesn't differ from the second
+	bodies := receiver.received()
+	if len(bodies) != 1 {
+		t.Fatalf("expected 1 webhook request, got %d", len(bodies))
+	}
+	var summary WatchSummary
+	if err := json.Unmarshal(bodies[0], &summary); err != nil {
+		t.Fatal(err)
+	}
+	expected := WatchSummary{
+		Provider: "rabbitmq",
+		Time:     importTime(),
+		Drift: terraformutils.Drift{
+			Added: []terraformutils.ResourceChange{
+				{Address: "rabbitmq_queue.tfer--queue_prod_events", Type: "rabbitmq_queue", ID: "events@prod", Provider: "rabbitmq"},
+			},
+			Removed: []terraformutils.ResourceChange{
+				{Address: "rabbitmq_vhost.tfer--vhost_staging", Type: "rabbitmq_vhost", ID: "staging", Provider: "rabbitmq"},
+			},
+			Changed: []terraformutils.ResourceChange{
+				{Address: "rabbitmq_queue.tfer--queue_slash_orders", Type: "rabbitmq_queue", ID: "orders@/", Provider: "rabbitmq",
+					Attributes: []string{"settings.0.durable"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("unexpected summary:\n%s", bodies[0])
+	}
+
+	changelog, err := ioutil.ReadFile(filepath.Join("watch", watchChangelogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedChangelog := "## rabbitmq 2021-05-06T07:08:09Z\n" +
+		"\n### Added\n\n* `rabbitmq_queue.tfer--queue_prod_events` events@prod\n" +
+		"\n### Removed\n\n* `rabbitmq_vhost.tfer--vhost_staging` staging\n" +
+		"\n### Changed\n\n* `rabbitmq_queue.tfer--queue_slash_orders` orders@/: `settings.0.durable`\n\n"
+	if string(changelog) != expectedChangelog {
+		t.Errorf("unexpected changelog:\n%s", changelog)
+	}
+	if _, err := os.Stat(filepath.Join("watch", "snapshot.next")); !os.IsNotExist(err) {
+		t.Errorf("snapshot of the last import isn't kept as the previous one: %v", err)
+	}
+}
+
+// changePreviousSnapshot removes queue events@prod, adds vhost staging and changes durability of queue orders@/
+func changePreviousSnapshot(t *testing.T, dir string) {
+	t.Helper()
+	snapshot, err := LoadSnapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := snapshot.Imports[0].Resources
+	var queues []terraformutils.SnapshotResource
+	for _, queue := range resources["queues"] {
+		switch queue.InstanceState.ID {
+		case "events@prod":
+			continue
+		case "orders@/":
+			queue.InstanceState.Attributes["settings.0.durable"] = "true"
+		}
+		queues = append(queues, queue)
+	}
+	resources["queues"] = queues
+	staging := terraformutils.NewSimpleResource("staging", "vhost_staging", "rabbitmq_vhost", "rabbitmq", nil)
+	resources["vhosts"] = append(resources["vhosts"], terraformutils.SnapshotResource{Resource: staging})
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, snapshotFile), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchSlackWebhook(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	w := &watcher{webhooks: []string{receiver.URL}, webhookFormat: "slack", client: receiver.Client()}
+	summary := WatchSummary{
+		Provider: "aws",
+		Time:     time.Date(2021, 5, 6, 7, 8, 9, 0, time.UTC),
+		Drift: terraformutils.Drift{
+			Added: []terraformutils.ResourceChange{
+				{Address: "aws_vpc.tfer--vpc-0123", Type: "aws_vpc", ID: "vpc-0123", Provider: "aws.eu_west_1"},
+			},
+			Changed: []terraformutils.ResourceChange{
+				{Address: "aws_subnet.tfer--subnet-0123", Type: "aws_subnet", ID: "subnet-0123", Provider: "aws",
+					Attributes: []string{"tags.Name", "map_public_ip_on_launch"}},
+			},
+		},
+	}
+	if err := w.notify(summary); err != nil {
+		t.Fatal(err)
+	}
+	bodies := receiver.received()
+	if len(bodies) != 1 {
+		t.Fatalf("expected 1 webhook request, got %d", len(bodies))
+	}
+	var message map[string]string
+	if err := json.Unmarshal(bodies[0], &message); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"*Terraformer detected changes of aws resources* at 2021-05-06T07:08:09Z",
+		"• added `aws_vpc.tfer--vpc-0123` vpc-0123 of aws.eu_west_1",
+		"• changed `aws_subnet.tfer--subnet-0123` subnet-0123: `tags.Name`, `map_public_ip_on_launch`",
+	}, "\n")
+	if message["text"] != expected {
+		t.Errorf("unexpected message:\n%s", message["text"])
+	}
+}
+
+func TestWatchFailedWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	receiver := newWebhookReceiver(t)
+	w := &watcher{webhooks: []string{server.URL + "/secret-token", receiver.URL}, webhookFormat: "json", client: server.Client()}
+
+	err := w.notify(WatchSummary{Provider: "aws"})
+	if err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// other webhooks are notified
+	if len(receiver.received()) != 1 {
+		t.Errorf("webhook after the failed one isn't notified")
+	}
+}
+
+func TestWatchFailedWebhookRun(t *testing.T) {
+	testdataPath := chdirTemp(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	w := &watcher{
+		dir:           "watch",
+		interval:      time.Millisecond,
+		runs:          3,
+		webhooks:      []string{server.URL},
+		webhookFormat: "json",
+		client:        server.Client(),
+	}
+	options := ImportOptions{
+		Resources:   []string{"vhosts", "queues"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Output:      "hcl",
+	}
+	run := 0
+	err := w.watch(context.Background(), func(snapshot string) error {
+		run++
+		if run == 2 {
+			changePreviousSnapshot(t, filepath.Join("watch", "snapshot"))
+		}
+		options.Snapshot = snapshot
+		runImport(t, testdataPath, options)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed webhook failed the watch: %v", err)
+	}
+	// the snapshot is replaced although the webhook failed, so the third run finds no changes
+	changelog, err := ioutil.ReadFile(filepath.Join("watch", watchChangelogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := strings.Count(string(changelog), "## rabbitmq"); entries != 1 {
+		t.Errorf("expected 1 changelog entry, got %d:\n%s", entries, changelog)
+	}
+}
+
+func TestWatchInterrupted(t *testing.T) {
+	testdataPath := chdirTemp(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &watcher{dir: "watch", interval: time.Hour, webhookFormat: "json"}
+	options := ImportOptions{
+		Resources:   []string{"vhosts", "queues"},
+		PathPattern: DefaultPathPattern,
+		PathOutput:  DefaultPathOutput,
+		State:       DefaultState,
+		Output:      "hcl",
+	}
+	var importErr error
+	err := w.watch(ctx, func(snapshot string) error {
+		// interrupted while the import runs
+		cancel()
+		options.Snapshot = snapshot
+		importErr = runImportContext(w.ctx, t, testdataPath, options)
+		return importErr
+	})
+	if err != nil {
+		t.Fatalf("interrupted watch failed: %v", err)
+	}
+	if !errors.Is(importErr, context.Canceled) {
+		t.Errorf("import wasn't stopped, got %v", importErr)
+	}
+	if _, err := os.Stat(filepath.Join("watch", "snapshot")); !os.IsNotExist(err) {
+		t.Errorf("snapshot of interrupted import was recorded")
+	}
+}
+
+func TestWatchProviderOptionsOfEachRun(t *testing.T) {
+	chdirTemp(t)
+	var imported [][]string
+	// like AWS, the command of the provider keeps only regional resources after importing the global ones
+	subcommand := func(options ImportOptions) *cobra.Command {
+		cmd := &cobra.Command{
+			Use: "aws",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				imported = append(imported, parseGlobalResources(options.Resources))
+				options.Resources = parseRegionalResources(options.Resources)
+				imported = append(imported, options.Resources)
+				return errors.New("no import in test")
+			},
+		}
+		baseProviderFlags(cmd.PersistentFlags(), &options, "vpc", "")
+		return cmd
+	}
+	w := &watcher{dir: "watch", interval: time.Millisecond, runs: 2, webhookFormat: "json"}
+	cmd := newWatchProviderCmd(w, subcommand, ImportOptions{Watch: w})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetArgs([]string{"--resources=iam,vpc"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("error of the last run wasn't returned")
+	}
+	expected := [][]string{{"iam"}, {"vpc"}, {"iam"}, {"vpc"}}
+	if !reflect.DeepEqual(imported, expected) {
+		t.Errorf("expected resources %v of runs, got %v", expected, imported)
+	}
+}
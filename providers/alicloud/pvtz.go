@@ -15,6 +15,7 @@
 package alicloud
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/GoogleCloudPlatform/terraformer/providers/alicloud/connectivity"
@@ -167,7 +168,7 @@ func (g *PvtzGenerator) PostConvertHook() error {
 	for _, r := range g.Resources {
 		if r.InstanceInfo.Type == "alicloud_pvtz_zone_record" {
 			// https://www.terraform.io/docs/providers/alicloud/r/pvtz_zone_record.html#priority
-			v, e := strconv.Atoi(r.Item["priority"].(string))
+			v, e := strconv.Atoi(fmt.Sprint(r.Item["priority"]))
 			if v < 1 || v > 50 || e != nil {
 				delete(r.Item, "priority")
 			}
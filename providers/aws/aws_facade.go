@@ -24,6 +24,10 @@ func (s *AwsFacade) ParseFilters(rawFilters []string) {
 	s.service.ParseFilters(rawFilters)
 }
 
+func (s *AwsFacade) AddFilters(filters []terraformutils.ResourceFilter) {
+	s.service.AddFilters(filters)
+}
+
 func (s *AwsFacade) ParseFilter(rawFilter string) []terraformutils.ResourceFilter {
 	return s.service.ParseFilter(rawFilter)
 }
@@ -17,6 +17,7 @@ package aws
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
 	"github.com/pkg/errors"
@@ -205,6 +206,24 @@ func (p *AWSProvider) Init(args []string) error {
 	return nil
 }
 
+func (p AWSProvider) GetDataSourceReferences() map[string]terraformutils.DataSourceReference {
+	return map[string]terraformutils.DataSourceReference{
+		"customer_gateway": terraformutils.ArgumentReference("aws_customer_gateway", "id"),
+		"route_table":      terraformutils.ArgumentReference("aws_route_table", "route_table_id"),
+		"sg":               terraformutils.ArgumentReference("aws_security_group", "id"),
+		"sqs": {
+			DataSource: "aws_sqs_queue",
+			Arguments: func(arn string) map[string]interface{} {
+				return map[string]interface{}{"name": arn[strings.LastIndex(arn, ":")+1:]}
+			},
+		},
+		"subnet":          terraformutils.ArgumentReference("aws_subnet", "id"),
+		"transit_gateway": terraformutils.ArgumentReference("aws_ec2_transit_gateway", "id"),
+		"vpc":             terraformutils.ArgumentReference("aws_vpc", "id"),
+		"vpn_gateway":     terraformutils.ArgumentReference("aws_vpn_gateway", "id"),
+	}
+}
+
 func (p *AWSProvider) GetName() string {
 	return "aws"
 }
@@ -45,6 +45,13 @@ func (g *Ec2Generator) InitResources() error {
 				Values: filter.AcceptableValues,
 			})
 		}
+		// filters of IDs, like of --ids-from, list only the instances, API accepts up to 200 values of a filter
+		if filter.FieldPath == "id" && filter.IsApplicable("instance") && len(filter.AcceptableValues) <= 200 {
+			filters = append(filters, types.Filter{
+				Name:   aws.String("instance-id"),
+				Values: filter.AcceptableValues,
+			})
+		}
 	}
 	p := ec2.NewDescribeInstancesPaginator(svc, &ec2.DescribeInstancesInput{
 		Filters: filters,
@@ -16,6 +16,7 @@ package aws
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
@@ -77,7 +78,7 @@ func (g *LogsGenerator) InitResources() error {
 // remove retention_in_days if it is 0 (it gets added by the "refresh" stage)
 func (g *LogsGenerator) PostConvertHook() error {
 	for _, resource := range g.Resources {
-		if resource.Item["retention_in_days"] == "0" {
+		if fmt.Sprint(resource.Item["retention_in_days"]) == "0" {
 			delete(resource.Item, "retention_in_days")
 		}
 	}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
 
@@ -62,14 +63,19 @@ func (g *S3Generator) createResources(config aws.Config, buckets *s3.ListBuckets
 			if err == nil && policy.Policy != nil {
 				attributes["policy"] = *policy.Policy
 			}
-			resources = append(resources, terraformutils.NewResource(
+			resource := terraformutils.NewResource(
 				resourceName,
 				resourceName,
 				"aws_s3_bucket",
 				"aws",
 				attributes,
 				S3AllowEmptyValues,
-				S3AdditionalFields))
+				S3AdditionalFields)
+			// creation date isn't an attribute of the bucket, so it's kept for --annotate
+			if bucket.CreationDate != nil {
+				resource.Metadata = map[string]string{"created": bucket.CreationDate.UTC().Format(time.RFC3339)}
+			}
+			resources = append(resources, resource)
 		}
 	}
 	return resources
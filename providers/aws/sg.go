@@ -265,7 +265,7 @@ func (g *SecurityGenerator) InitResources() error {
 func (g *SecurityGenerator) PostConvertHook() error {
 	for _, resource := range g.Resources {
 		if resource.InstanceInfo.Type == "aws_security_group_rule" {
-			if resource.Item["self"] == "true" {
+			if fmt.Sprint(resource.Item["self"]) == "true" {
 				delete(resource.Item, "source_security_group_id")
 			}
 		} else if resource.InstanceInfo.Type == "aws_security_group" {
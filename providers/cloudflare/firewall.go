@@ -263,7 +263,7 @@ func (g *FirewallGenerator) PostConvertHook() error {
 		}
 
 		if resourceRecord.InstanceInfo.Type == "cloudflare_firewall_rule" {
-			if resourceRecord.Item["priority"].(string) == "0" {
+			if fmt.Sprint(resourceRecord.Item["priority"]) == "0" {
 				delete(g.Resources[i].Item, "priority")
 			}
 		}
@@ -16,6 +16,7 @@ package gcp
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strings"
 
@@ -111,10 +112,8 @@ func (g *BigQueryGenerator) PostConvertHook() error {
 		if dataset.InstanceInfo.Type != "google_bigquery_dataset" {
 			continue
 		}
-		if val, ok := dataset.Item["default_table_expiration_ms"].(string); ok { // TODO zero int issue
-			if val == "0" {
-				delete(g.Resources[i].Item, "default_table_expiration_ms")
-			}
+		if fmt.Sprint(dataset.Item["default_table_expiration_ms"]) == "0" {
+			delete(g.Resources[i].Item, "default_table_expiration_ms")
 		}
 		for j, table := range g.Resources {
 			if table.InstanceInfo.Type != "google_bigquery_table" {
@@ -22,6 +22,10 @@ func (s *GCPFacade) ParseFilters(rawFilters []string) {
 	s.service.ParseFilters(rawFilters)
 }
 
+func (s *GCPFacade) AddFilters(filters []terraformutils.ResourceFilter) {
+	s.service.AddFilters(filters)
+}
+
 func (s *GCPFacade) ParseFilter(rawFilter string) []terraformutils.ResourceFilter {
 	return s.service.ParseFilter(rawFilter)
 }
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
 	"google.golang.org/api/compute/v1"
@@ -179,6 +180,26 @@ func (GCPProvider) GetResourceConnections() map[string]map[string][]string {
 		},
 	}
 }
+func (GCPProvider) GetDataSourceReferences() map[string]terraformutils.DataSourceReference {
+	return map[string]terraformutils.DataSourceReference{
+		"networks": {
+			DataSource: "google_compute_network",
+			Arguments: func(selfLink string) map[string]interface{} {
+				// https://www.googleapis.com/compute/v1/projects/{project}/global/networks/{name}
+				parts := strings.Split(selfLink, "/")
+				arguments := map[string]interface{}{"name": parts[len(parts)-1]}
+				for i := 0; i < len(parts)-1; i++ {
+					if parts[i] == "projects" {
+						arguments["project"] = parts[i+1]
+					}
+				}
+				return arguments
+			},
+		},
+		"subnetworks": terraformutils.ArgumentReference("google_compute_subnetwork", "self_link"),
+	}
+}
+
 func (p GCPProvider) GetProviderData(arg ...string) map[string]interface{} {
 	return map[string]interface{}{
 		"provider": map[string]interface{}{
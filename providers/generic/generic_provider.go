@@ -0,0 +1,153 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// GenericProvider imports resources of any Terraform provider listed in a file of IDs,
+// resources are imported by ID through the provider plugin like `terraform import` does
+type GenericProvider struct { //nolint
+	terraformutils.Provider
+	name      string
+	idsPath   string
+	config    map[string]string
+	resources map[string][]ResourceID
+}
+
+// ResourceID is a row of the IDs file, `type,id[,name]`
+type ResourceID struct {
+	Type string
+	ID   string
+	Name string
+}
+
+// Init reads provider name, path of the IDs file and key=value pairs of provider configuration
+func (p *GenericProvider) Init(args []string) error {
+	if len(args) < 2 || args[0] == "" || args[1] == "" {
+		return errors.New("generic: provider name and file of IDs are required")
+	}
+	p.name = args[0]
+	p.idsPath = args[1]
+	p.config = map[string]string{}
+	for _, arg := range args[2:] {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("generic: invalid provider config %s, expected key=value", arg)
+		}
+		p.config[parts[0]] = parts[1]
+	}
+	f, err := os.Open(p.idsPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p.resources, err = ReadIDs(f)
+	return err
+}
+
+// ReadIDs reads `type,id[,name]` rows by resource type. Lines starting with # are comments.
+func ReadIDs(r io.Reader) (map[string][]ResourceID, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	resources := map[string][]ResourceID{}
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("row %d: expected type,id[,name], got %d fields", row, len(record))
+		}
+		resource := ResourceID{Type: strings.TrimSpace(record[0]), ID: strings.TrimSpace(record[1])}
+		if len(record) == 3 {
+			resource.Name = strings.TrimSpace(record[2])
+		}
+		if resource.Type == "" || resource.ID == "" {
+			return nil, fmt.Errorf("row %d: type and id are required", row)
+		}
+		if resource.Name == "" {
+			resource.Name = resource.ID
+		}
+		resources[resource.Type] = append(resources[resource.Type], resource)
+	}
+	return resources, nil
+}
+
+func (p *GenericProvider) GetName() string {
+	return p.name
+}
+
+func (p *GenericProvider) GetProviderData(arg ...string) map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (p *GenericProvider) GetConfig() cty.Value {
+	if len(p.config) == 0 {
+		return cty.EmptyObjectVal
+	}
+	config := map[string]cty.Value{}
+	for key, value := range p.config {
+		config[key] = cty.StringVal(value)
+	}
+	return cty.ObjectVal(config)
+}
+
+func (p *GenericProvider) GetBasicConfig() cty.Value {
+	return p.GetConfig()
+}
+
+func (p *GenericProvider) GetResourceConnections() map[string]map[string][]string {
+	return map[string]map[string][]string{}
+}
+
+func (p *GenericProvider) InitService(serviceName string, verbose bool) error {
+	var isSupported bool
+	if _, isSupported = p.GetSupportedService()[serviceName]; !isSupported {
+		return errors.New(p.GetName() + ": " + serviceName + " not listed in " + p.idsPath)
+	}
+	p.Service = p.GetSupportedService()[serviceName]
+	p.Service.SetName(serviceName)
+	p.Service.SetVerbose(verbose)
+	p.Service.SetProviderName(p.GetName())
+	return nil
+}
+
+// GetSupportedService returns a service for each resource type of the IDs file,
+// named like types of other providers without the provider prefix
+func (p *GenericProvider) GetSupportedService() map[string]terraformutils.ServiceGenerator {
+	services := map[string]terraformutils.ServiceGenerator{}
+	for resourceType, ids := range p.resources {
+		services[strings.TrimPrefix(resourceType, p.name+"_")] = &ResourceGenerator{
+			resourceType: resourceType,
+			ids:          ids,
+		}
+	}
+	return services
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformertest"
+)
+
+func TestGenericProvider(t *testing.T) {
+	fake, err := terraformertest.NewFakeProvider("rabbitmq", "testdata/schema.json", "testdata/resources.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	providerWrapper := providerwrapper.NewProviderWrapperWithProvider("rabbitmq", fake, map[string]int{"retryCount": 1, "retrySleepMs": 0})
+
+	resources, err := terraformertest.RunService(&GenericProvider{}, "queue", []string{"rabbitmq", "testdata/ids.csv"}, nil, providerWrapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := map[string]map[string]interface{}{}
+	for _, r := range resources {
+		items[r.ResourceName] = r.Item
+	}
+	expected := map[string]map[string]interface{}{
+		"tfer--events": {
+			"name":  "events",
+			"vhost": "prod",
+			"settings": []interface{}{map[string]interface{}{
+				"auto_delete": false,
+				"durable":     true,
+			}},
+		},
+		"tfer--orders-0040--002F-": {
+			"name":  "orders",
+			"vhost": "/",
+			"settings": []interface{}{map[string]interface{}{
+				"auto_delete": true,
+				"durable":     false,
+			}},
+		},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("failed to import queues, got %v", items)
+	}
+}
+
+func TestReadIDs(t *testing.T) {
+	resources, err := ReadIDs(strings.NewReader("# comment\nrandom_pet,pet-1\nrandom_pet, pet-2, second\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]ResourceID{
+		"random_pet": {
+			{Type: "random_pet", ID: "pet-1", Name: "pet-1"},
+			{Type: "random_pet", ID: "pet-2", Name: "second"},
+		},
+	}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("failed to read IDs, got %v", resources)
+	}
+
+	for _, invalid := range []string{"random_pet\n", "random_pet,pet-1,name,extra\n", ",pet-1\n"} {
+		if _, err := ReadIDs(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
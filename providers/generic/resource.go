@@ -0,0 +1,41 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+// ResourceGenerator lists resources of one type from the IDs file
+type ResourceGenerator struct {
+	terraformutils.Service
+	resourceType string
+	ids          []ResourceID
+}
+
+func (g *ResourceGenerator) InitResources() error {
+	for _, id := range g.ids {
+		resource := terraformutils.NewSimpleResource(
+			id.ID,
+			id.Name,
+			g.resourceType,
+			g.ProviderName,
+			[]string{},
+		)
+		resource.ImportRequired = true
+		g.Resources = append(g.Resources, resource)
+	}
+	return nil
+}
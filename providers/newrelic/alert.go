@@ -111,7 +111,7 @@ func (g *AlertGenerator) InitResources() error {
 func (g *AlertGenerator) PostConvertHook() error {
 	for i, resource := range g.Resources {
 		if resource.InstanceInfo.Type == "newrelic_alert_condition" {
-			if resource.Item["violation_close_timer"] == "0" {
+			if fmt.Sprint(resource.Item["violation_close_timer"]) == "0" {
 				delete(g.Resources[i].Item, "violation_close_timer")
 			}
 		}
@@ -690,40 +690,40 @@ func (g *FirewallNetworkingGenerator) PostConvertHook() error {
 		}
 
 		if r.InstanceInfo.Type == "panos_virtual_router" {
-			if r.Item["ospfv3_ext_dist"].(string) == "0" {
-				r.Item["ospfv3_ext_dist"] = "110"
+			if fmt.Sprint(r.Item["ospfv3_ext_dist"]) == "0" {
+				r.Item["ospfv3_ext_dist"] = 110
 			}
 
-			if r.Item["ebgp_dist"].(string) == "0" {
-				r.Item["ebgp_dist"] = "20"
+			if fmt.Sprint(r.Item["ebgp_dist"]) == "0" {
+				r.Item["ebgp_dist"] = 20
 			}
 
-			if r.Item["rip_dist"].(string) == "0" {
-				r.Item["rip_dist"] = "120"
+			if fmt.Sprint(r.Item["rip_dist"]) == "0" {
+				r.Item["rip_dist"] = 120
 			}
 
-			if r.Item["ibgp_dist"].(string) == "0" {
-				r.Item["ibgp_dist"] = "200"
+			if fmt.Sprint(r.Item["ibgp_dist"]) == "0" {
+				r.Item["ibgp_dist"] = 200
 			}
 
-			if r.Item["static_dist"].(string) == "0" {
-				r.Item["static_dist"] = "10"
+			if fmt.Sprint(r.Item["static_dist"]) == "0" {
+				r.Item["static_dist"] = 10
 			}
 
-			if r.Item["ospf_int_dist"].(string) == "0" {
-				r.Item["ospf_int_dist"] = "30"
+			if fmt.Sprint(r.Item["ospf_int_dist"]) == "0" {
+				r.Item["ospf_int_dist"] = 30
 			}
 
-			if r.Item["static_ipv6_dist"].(string) == "0" {
-				r.Item["static_ipv6_dist"] = "10"
+			if fmt.Sprint(r.Item["static_ipv6_dist"]) == "0" {
+				r.Item["static_ipv6_dist"] = 10
 			}
 
-			if r.Item["ospf_ext_dist"].(string) == "0" {
-				r.Item["ospf_ext_dist"] = "110"
+			if fmt.Sprint(r.Item["ospf_ext_dist"]) == "0" {
+				r.Item["ospf_ext_dist"] = 110
 			}
 
-			if r.Item["ospfv3_int_dist"].(string) == "0" {
-				r.Item["ospfv3_int_dist"] = "30"
+			if fmt.Sprint(r.Item["ospfv3_int_dist"]) == "0" {
+				r.Item["ospfv3_int_dist"] = 30
 			}
 		}
 
@@ -0,0 +1,67 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rabbitmq
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformertest"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestQueueGenerator(t *testing.T) {
+	endpoint := os.Getenv("RABBITMQ_SERVER_URL")
+	username := os.Getenv("RABBITMQ_USERNAME")
+	password := os.Getenv("RABBITMQ_PASSWORD")
+	server := terraformertest.NewServer(t, "testdata/queues_cassette.json", endpoint)
+	providerWrapper := terraformertest.NewProviderWrapper(t, "rabbitmq", cty.ObjectVal(map[string]cty.Value{
+		"endpoint": cty.StringVal(endpoint),
+		"username": cty.StringVal(username),
+		"password": cty.StringVal(password),
+	}), "testdata/schema.json", "testdata/queues_resources.json")
+
+	resources, err := terraformertest.RunService(&RBTProvider{}, "queues", []string{server.URL, username, password}, nil, providerWrapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := map[string]map[string]interface{}{}
+	for _, r := range resources {
+		items[r.ResourceName] = r.Item
+	}
+	expected := map[string]map[string]interface{}{
+		"tfer--queue_slash_orders": {
+			"name":  "orders",
+			"vhost": "/",
+			"settings": []interface{}{map[string]interface{}{
+				"auto_delete": true,
+				"durable":     false,
+			}},
+		},
+		"tfer--queue_prod_events": {
+			"name":  "events",
+			"vhost": "prod",
+			"settings": []interface{}{map[string]interface{}{
+				"auto_delete": false,
+				"durable":     true,
+			}},
+		},
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("failed to generate queues, got %v", items)
+	}
+}
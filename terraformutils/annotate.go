@@ -0,0 +1,92 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+var createdAttributes = []string{"creation_date", "created_at", "create_time", "creation_time", "creation_timestamp",
+	"created_time", "created_date", "create_date", "date_created", "created"}
+
+var creatorAttributes = []string{"created_by", "creator", "creator_id"}
+
+// Annotate returns comments describing where resource came from: its ID, scope, time of the import,
+// Terraformer version and metadata like creation time, creator or console URL
+func Annotate(r Resource, scope PathScope, importedAt time.Time, version string) []string {
+	var comments []string
+	if r.InstanceState != nil {
+		comments = append(comments, "id: "+r.InstanceState.ID)
+	}
+	var scopes []string
+	for _, key := range []string{"region", "project", "account", "resource_group"} {
+		if value := resourcePathValue(key, r, scope); value != "" {
+			scopes = append(scopes, key+": "+value)
+		}
+	}
+	if len(scopes) > 0 {
+		comments = append(comments, strings.Join(scopes, ", "))
+	}
+	comments = append(comments, "imported: "+importedAt.UTC().Format(time.RFC3339)+", terraformer "+version)
+	metadata := ResourceMetadata(r)
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		comments = append(comments, key+": "+metadata[key])
+	}
+	for i, comment := range comments {
+		comments[i] = strings.Join(strings.Fields(comment), " ")
+	}
+	return comments
+}
+
+// ResourceMetadata returns Metadata set by the provider, completed with creation time and creator
+// read from common attributes and console URL derived from AWS ARNs and Azure IDs
+func ResourceMetadata(r Resource) map[string]string {
+	metadata := map[string]string{}
+	var attributes map[string]string
+	if r.InstanceState != nil {
+		attributes = r.InstanceState.Attributes
+	}
+	if value := firstAttribute(attributes, createdAttributes...); value != "" {
+		metadata["created"] = value
+	}
+	if value := firstAttribute(attributes, creatorAttributes...); value != "" {
+		metadata["creator"] = value
+	}
+	if console := consoleURL(attributes); console != "" {
+		metadata["console"] = console
+	}
+	for key, value := range r.Metadata {
+		metadata[key] = value
+	}
+	return metadata
+}
+
+func consoleURL(attributes map[string]string) string {
+	if arn := attributes["arn"]; strings.HasPrefix(arn, "arn:") {
+		return "https://console.aws.amazon.com/go/view?arn=" + url.QueryEscape(arn)
+	}
+	if id := attributes["id"]; strings.HasPrefix(strings.ToLower(id), "/subscriptions/") {
+		return "https://portal.azure.com/#resource" + id
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package terraformutils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnnotate(t *testing.T) {
+	r := prepare("sg-1", "aws_security_group", map[string]string{
+		"arn":        "arn:aws:ec2:eu-west-1:123456789012:security-group/sg-1",
+		"created_at": "2021-03-04T05:06:07Z",
+	}, map[string]interface{}{})
+	r.Metadata = map[string]string{"creator": "ops\nteam"}
+	importedAt := time.Date(2021, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	comments := Annotate(r, PathScope{"region": "eu-west-1"}, importedAt, "v0.8.15")
+	expected := []string{
+		"id: sg-1",
+		"region: eu-west-1, account: 123456789012",
+		"imported: 2021-05-06T07:08:09Z, terraformer v0.8.15",
+		"console: https://console.aws.amazon.com/go/view?arn=arn%3Aaws%3Aec2%3Aeu-west-1%3A123456789012%3Asecurity-group%2Fsg-1",
+		"created: 2021-03-04T05:06:07Z",
+		"creator: ops team",
+	}
+	if !reflect.DeepEqual(comments, expected) {
+		t.Errorf("expected %q, got %q", expected, comments)
+	}
+}
+
+func TestResourceMetadataAzure(t *testing.T) {
+	id := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+	r := prepare(id, "azurerm_virtual_network", map[string]string{}, map[string]interface{}{})
+	metadata := ResourceMetadata(r)
+	if metadata["console"] != "https://portal.azure.com/#resource"+id {
+		t.Errorf("unexpected console URL %q", metadata["console"])
+	}
+}
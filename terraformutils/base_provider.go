@@ -32,9 +32,16 @@ type ProviderGenerator interface {
 	GetResourceConnections() map[string]map[string][]string
 }
 
+// SchemaFileSetter is implemented by providers embedding Provider
+type SchemaFileSetter interface {
+	SetSchemaFile(path string)
+}
+
 type Provider struct {
 	Service ServiceGenerator
 	Config  cty.Value
+	// SchemaFile is read by providers needing their Terraform schema to list services, instead of starting the plugin
+	SchemaFile string
 }
 
 func (p *Provider) Init(args []string) error {
@@ -45,6 +52,10 @@ func (p *Provider) GetConfig() cty.Value {
 	return p.Config
 }
 
+func (p *Provider) SetSchemaFile(path string) {
+	p.SchemaFile = path
+}
+
 func (p *Provider) GetName() string {
 	panic("implement me")
 }
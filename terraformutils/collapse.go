@@ -0,0 +1,169 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maximum number of attributes which may differ between resources collapsed into one for_each block
+const collapseMaxVaryingAttributes = 4
+
+// CollapseResources checks if resources of one type differ only in a few top level attributes
+// and marks them as instances of a single for_each resource. It returns false if resources can't be collapsed.
+func CollapseResources(resources []*Resource) bool {
+	if len(resources) < 2 {
+		return false
+	}
+	keys := itemKeys(resources[0].Item)
+	for _, r := range resources[1:] {
+		// provider of for_each resource can't vary by instance
+		if r.InstanceInfo.Type != resources[0].InstanceInfo.Type || r.ProviderAlias != resources[0].ProviderAlias ||
+			!reflect.DeepEqual(keys, itemKeys(r.Item)) {
+			return false
+		}
+	}
+	var varyingKeys []string
+	for _, key := range keys {
+		for _, r := range resources[1:] {
+			if !reflect.DeepEqual(resources[0].Item[key], r.Item[key]) {
+				varyingKeys = append(varyingKeys, key)
+				break
+			}
+		}
+	}
+	if len(varyingKeys) == 0 || len(varyingKeys) > collapseMaxVaryingAttributes {
+		return false
+	}
+	for _, key := range varyingKeys {
+		for _, r := range resources {
+			if !isCollapsibleValue(r.Item[key]) {
+				return false
+			}
+		}
+	}
+	name := TfSanitize(strings.TrimPrefix(resources[0].InstanceInfo.Type, resources[0].Provider+"_"))
+	for _, r := range resources {
+		r.CollapsedName = name
+		r.CollapsedKeys = varyingKeys
+	}
+	return true
+}
+
+// resourceReference matches type.name. of references, but not of data.type.name.
+var resourceReference = regexp.MustCompile(`(^|[^\w.-])([\w-]+\.[\w-]+)\.`)
+
+// UpdateCollapsedReferences rewrites references to collapsed resources, like ${aws_vpc.tfer--a.id},
+// to their for_each instances, like ${aws_vpc.vpc["tfer--a"].id}
+func UpdateCollapsedReferences(resources []Resource) {
+	addresses := map[string]string{}
+	for _, r := range resources {
+		if r.CollapsedName != "" {
+			addresses[r.InstanceInfo.Type+"."+r.ResourceName] = r.Address()
+		}
+	}
+	UpdateReferences(resources, addresses)
+}
+
+// UpdateReferences rewrites references to resources, like ${aws_vpc.tfer--a.id}, by addresses
+// mapping type.name of resources to their new addresses
+func UpdateReferences(resources []Resource, addresses map[string]string) {
+	if len(addresses) == 0 {
+		return
+	}
+	for i := range resources {
+		replaceStrings(resources[i].Item, func(value string) string {
+			return resourceReference.ReplaceAllStringFunc(value, func(match string) string {
+				groups := resourceReference.FindStringSubmatch(match)
+				if address, exist := addresses[groups[2]]; exist {
+					return groups[1] + address + "."
+				}
+				return match
+			})
+		})
+	}
+}
+
+// replaceStrings replaces all strings of maps and lists of data by replace
+func replaceStrings(data interface{}, replace func(string) string) {
+	switch data := data.(type) {
+	case []interface{}:
+		for i, element := range data {
+			if value, ok := element.(string); ok {
+				data[i] = replace(value)
+				continue
+			}
+			replaceStrings(element, replace)
+		}
+	case map[string]interface{}:
+		for key, element := range data {
+			if value, ok := element.(string); ok {
+				data[key] = replace(value)
+				continue
+			}
+			replaceStrings(element, replace)
+		}
+	}
+}
+
+// for_each can only pass expressions, so nested blocks have to be the same for all collapsed resources
+func isCollapsibleValue(value interface{}) bool {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if !isCollapsibleValue(item) || reflect.ValueOf(item).Kind() == reflect.Slice {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}, []map[string]interface{}:
+		return false
+	default:
+		return true
+	}
+}
+
+func itemKeys(item map[string]interface{}) []string {
+	keys := []string{}
+	for k := range item {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// collapsedResourceData builds resource block with for_each and locals for resources collapsed with CollapseResources
+func collapsedResourceData(resources []Resource) (map[string]interface{}, map[string]interface{}) {
+	item := map[string]interface{}{}
+	values := map[string]interface{}{}
+	for _, r := range resources {
+		value := map[string]interface{}{}
+		for k, v := range r.Item {
+			item[k] = v
+		}
+		for _, k := range r.CollapsedKeys {
+			value[k] = r.Item[k]
+		}
+		values[r.ResourceName] = value
+	}
+	for _, k := range resources[0].CollapsedKeys {
+		item[k] = "${each.value." + k + "}"
+	}
+	item["for_each"] = "${local." + resources[0].CollapsedName + "}"
+	return item, values
+}
@@ -0,0 +1,181 @@
+package terraformutils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func newCollapseTestResource(name, queue, durable string) Resource {
+	r := NewSimpleResource(name, name, "rabbitmq_queue", "rabbitmq", []string{})
+	r.Item = map[string]interface{}{
+		"name":  queue,
+		"vhost": "/",
+		"settings": []interface{}{map[string]interface{}{
+			"durable": durable,
+		}},
+	}
+	return r
+}
+
+func TestCollapseResources(t *testing.T) {
+	resources := []Resource{
+		newCollapseTestResource("q1", "queue1", "true"),
+		newCollapseTestResource("q2", "queue2", "true"),
+	}
+	if !CollapseResources([]*Resource{&resources[0], &resources[1]}) {
+		t.Fatal("failed to collapse resources")
+	}
+	if resources[1].Address() != `rabbitmq_queue.tfer--queue["tfer--q2"]` {
+		t.Errorf("wrong address %s", resources[1].Address())
+	}
+
+	hcl, err := HclPrintResource(resources, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`for_each = "${local.tfer--queue}"`,
+		`name     = "${each.value.name}"`,
+		`tfer--q1 = {`,
+		`name = "queue1"`,
+	} {
+		if !strings.Contains(string(hcl), expected) {
+			t.Errorf("expected %q in output:\n%s", expected, hcl)
+		}
+	}
+
+	state, err := PrintTfState(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(state), `"index_key": "tfer--q1"`) {
+		t.Errorf("expected for_each instance in state:\n%s", state)
+	}
+}
+
+func TestCollapseResourcesComments(t *testing.T) {
+	resources := []Resource{
+		newCollapseTestResource("q1", "queue1", "true"),
+		newCollapseTestResource("q2", "queue2", "true"),
+	}
+	resources[0].Comments = []string{"id: q1", "created: 2021-05-06T07:08:09Z"}
+	if !CollapseResources([]*Resource{&resources[0], &resources[1]}) {
+		t.Fatal("failed to collapse resources")
+	}
+
+	hcl, err := HclPrintResource(resources, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(hcl), "    # id: q1\n    # created: 2021-05-06T07:08:09Z\n    tfer--q1 = {") {
+		t.Errorf("expected comments above entry of collapsed resource:\n%s", hcl)
+	}
+	if strings.Count(string(hcl), "#") != 2 {
+		t.Errorf("expected no comments of second resource:\n%s", hcl)
+	}
+}
+
+func TestCollapseResourcesWithDifferentBlocks(t *testing.T) {
+	resources := []Resource{
+		newCollapseTestResource("q1", "queue1", "true"),
+		newCollapseTestResource("q2", "queue2", "false"),
+	}
+	if CollapseResources([]*Resource{&resources[0], &resources[1]}) {
+		t.Error("resources with different nested blocks must not be collapsed")
+	}
+}
+
+func TestCollapseResourcesWithDifferentProviders(t *testing.T) {
+	resources := []Resource{
+		newCollapseTestResource("q1", "queue1", "true"),
+		newCollapseTestResource("q2", "queue2", "true"),
+	}
+	resources[1].ProviderAlias = "eu_west_1"
+	if CollapseResources([]*Resource{&resources[0], &resources[1]}) {
+		t.Error("resources of different provider configurations must not be collapsed")
+	}
+}
+
+func TestUpdateCollapsedReferences(t *testing.T) {
+	resources := []Resource{
+		newCollapseTestResource("q1", "queue1", "true"),
+		newCollapseTestResource("q2", "queue2", "true"),
+		NewSimpleResource("b1", "b1", "rabbitmq_binding", "rabbitmq", []string{}),
+	}
+	resources[2].Item = map[string]interface{}{
+		"source":      "${rabbitmq_queue.tfer--q1.name}",
+		"destination": "${data.rabbitmq_queue.tfer--q2.name}/${rabbitmq_queue.tfer--q2.vhost}",
+		"arguments":   []interface{}{"${rabbitmq_queue.tfer--q10.name}"},
+	}
+	if !CollapseResources([]*Resource{&resources[0], &resources[1]}) {
+		t.Fatal("failed to collapse resources")
+	}
+	UpdateCollapsedReferences(resources)
+
+	item := resources[2].Item
+	if item["source"] != `${rabbitmq_queue.tfer--queue["tfer--q1"].name}` {
+		t.Errorf("unexpected reference %s", item["source"])
+	}
+	// data sources and other resources with the same prefix of name are kept
+	if item["destination"] != `${data.rabbitmq_queue.tfer--q2.name}/${rabbitmq_queue.tfer--queue["tfer--q2"].vhost}` {
+		t.Errorf("unexpected reference %s", item["destination"])
+	}
+	if item["arguments"].([]interface{})[0] != "${rabbitmq_queue.tfer--q10.name}" {
+		t.Errorf("unexpected reference %s", item["arguments"])
+	}
+}
+
+func TestCollapseResourcesEscapedReferences(t *testing.T) {
+	resources := []Resource{
+		newCollapseTestResource("q1", "queue1", "true"),
+		newCollapseTestResource("q2", "queue2", "true"),
+		NewSimpleResource("b1", "b1", "rabbitmq_binding", "rabbitmq", []string{}),
+	}
+	resources[2].Item = map[string]interface{}{
+		"source":    "${rabbitmq_queue.tfer--q1.name}",
+		"arguments": `${rabbitmq_exchange.tfer--e1["key"].name}`,
+	}
+	if !CollapseResources([]*Resource{&resources[0], &resources[1]}) {
+		t.Fatal("failed to collapse resources")
+	}
+	UpdateCollapsedReferences(resources)
+
+	hcl, err := HclPrintResource(resources, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(hcl), `"${rabbitmq_queue.tfer--queue["tfer--q1"].name}"`) {
+		t.Errorf("expected unescaped reference to collapsed resource:\n%s", hcl)
+	}
+	// quotes of other strings stay escaped
+	if !strings.Contains(string(hcl), `"${rabbitmq_exchange.tfer--e1[\"key\"].name}"`) {
+		t.Errorf("expected escaped quotes of string:\n%s", hcl)
+	}
+}
+
+func TestPrintLocalsKeys(t *testing.T) {
+	locals := map[string]interface{}{
+		"tfer--queue": map[string]interface{}{
+			"kubernetes.io/cluster/x": map[string]interface{}{"aws:cloudformation:stack-name": "stack"},
+			"tfer--q1":                map[string]interface{}{"name": "queue1"},
+			"0a":                      "value",
+		},
+	}
+	printed := string(hclPrintLocals(locals, map[string]map[string][]string{}))
+	for _, expected := range []string{
+		`"kubernetes.io/cluster/x" = {`,
+		`"aws:cloudformation:stack-name" = "stack"`,
+		`tfer--q1 = {`,
+		`"0a"`,
+	} {
+		if !strings.Contains(printed, expected) {
+			t.Errorf("expected %q in locals:\n%s", expected, printed)
+		}
+	}
+	if _, diags := hclsyntax.ParseConfig([]byte(printed), "locals.tf", hcl.Pos{Line: 1, Column: 1}); diags.HasErrors() {
+		t.Errorf("invalid HCL of locals: %s\n%s", diags, printed)
+	}
+}
@@ -14,6 +14,11 @@
 
 package terraformutils
 
+import (
+	"regexp"
+	"strings"
+)
+
 func ConnectServices(importResources map[string][]Resource, isServicePath bool, resourceConnections map[string]map[string][]string) map[string][]Resource {
 	for resource, connection := range resourceConnections {
 		if _, exist := importResources[resource]; exist {
@@ -55,3 +60,64 @@ func mapResource(importResources map[string][]Resource, resource string, connect
 		}
 	}
 }
+
+var remoteStateNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// RemoteStateName returns name of terraform_remote_state data source reading state in path
+func RemoteStateName(path string) string {
+	return strings.Trim(remoteStateNameInvalid.ReplaceAllString(path, "_"), "_")
+}
+
+// ConnectServicesByPath links resources like ConnectServices when resources are printed by paths,
+// paths[service][i] is path of importResources[service][i]. Resources in the same path are linked
+// to the local state, others to remote state of the path of connected resource.
+// It returns paths of remote states read by resources of each path by names of remote states.
+func ConnectServicesByPath(importResources map[string][]Resource, paths map[string][]string, resourceConnections map[string]map[string][]string) map[string]map[string]string {
+	remoteStates := map[string]map[string]string{}
+	for resource, connection := range resourceConnections {
+		if _, exist := importResources[resource]; !exist {
+			continue
+		}
+		for k, connectionPairs := range connection {
+			cc, ok := importResources[k]
+			if !ok || len(connectionPairs)%2 == 1 {
+				continue
+			}
+			for i := 0; i < len(connectionPairs)/2; i++ {
+				connectionPair := []string{connectionPairs[i*2], connectionPairs[i*2+1]}
+				for j, ccc := range cc {
+					for l := range importResources[resource] {
+						path, connectedPath := paths[resource][l], paths[k][j]
+						name := "local"
+						if path != connectedPath {
+							name = RemoteStateName(connectedPath)
+						}
+						if linkResource(&importResources[resource][l], connectionPair, ccc, name) {
+							if remoteStates[path] == nil {
+								remoteStates[path] = map[string]string{}
+							}
+							remoteStates[path][name] = connectedPath
+						}
+					}
+				}
+			}
+		}
+	}
+	return remoteStates
+}
+
+// linkResource replaces references of resource to resourceToMap and returns true if any was found
+func linkResource(resource *Resource, connectionPair []string, resourceToMap Resource, k string) bool {
+	key := connectionPair[1]
+	if connectionPair[1] == "self_link" || connectionPair[1] == "id" {
+		key = resourceToMap.GetIDKey()
+	}
+	mappingResourceAttr := WalkAndGet(key, resourceToMap.InstanceState.Attributes)
+	if len(mappingResourceAttr) != 1 {
+		return false
+	}
+	resourceIdentifier := mappingResourceAttr[0].(string)
+	keyValue := resourceToMap.InstanceInfo.Type + "_" + resourceToMap.ResourceName + "_" + key
+	linkValue := "${data.terraform_remote_state." + k + ".outputs." + keyValue + "}"
+	return walkAndOverride(strings.Split(connectionPair[0], "."), resourceIdentifier, linkValue, resource.Item)
+}
@@ -133,6 +133,46 @@ func TestNestedReference(t *testing.T) {
 	}
 }
 
+func TestReferencesByPath(t *testing.T) {
+	importResources := map[string][]Resource{
+		"type1": {
+			prepare("ID1", "type1", map[string]string{"type2_ref": "ID2"}, map[string]interface{}{"type2_ref": "ID2"}),
+			prepare("ID3", "type1", map[string]string{"type2_ref": "ID2"}, map[string]interface{}{"type2_ref": "ID2"}),
+			prepare("ID4", "type1", map[string]string{"type2_ref": "ID5"}, map[string]interface{}{"type2_ref": "ID5"}),
+		},
+		"type2": {prepareNoAttrs("ID2", "type2")},
+	}
+	paths := map[string][]string{
+		"type1": {"generated/eu/", "generated/us/", "generated/us/"},
+		"type2": {"generated/us/"},
+	}
+
+	resourceConnections := map[string]map[string][]string{
+		"type1": {
+			"type2": {"type2_ref", "id"},
+		},
+	}
+	remoteStates := ConnectServicesByPath(importResources, paths, resourceConnections)
+
+	if !reflect.DeepEqual(importResources["type1"][0].Item, map[string]interface{}{
+		"type2_ref": "${data.terraform_remote_state.generated_us.outputs.type2_tfer--name-002D-type2_id}",
+	}) {
+		t.Errorf("failed to connect to other path %v", importResources["type1"][0].Item)
+	}
+	if !reflect.DeepEqual(importResources["type1"][1].Item, map[string]interface{}{
+		"type2_ref": "${data.terraform_remote_state.local.outputs.type2_tfer--name-002D-type2_id}",
+	}) {
+		t.Errorf("failed to connect in the same path %v", importResources["type1"][1].Item)
+	}
+	expected := map[string]map[string]string{
+		"generated/eu/": {"generated_us": "generated/us/"},
+		"generated/us/": {"local": "generated/us/"},
+	}
+	if !reflect.DeepEqual(remoteStates, expected) {
+		t.Errorf("unexpected remote states %v", remoteStates)
+	}
+}
+
 func prepareNoAttrs(id, resourceType string) Resource {
 	return prepare(id, resourceType, map[string]string{}, map[string]interface{}{})
 }
@@ -0,0 +1,126 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"sort"
+	"strings"
+)
+
+// ResourceChange is a resource added, removed or changed between two imports
+type ResourceChange struct {
+	Address  string `json:"address"`
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	// Attributes are flatmap keys of changed attributes, like tags.Name
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+// Drift are changes of resources between two imports
+type Drift struct {
+	Added   []ResourceChange `json:"added"`
+	Removed []ResourceChange `json:"removed"`
+	Changed []ResourceChange `json:"changed"`
+}
+
+func (d Drift) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffResources compares refreshed resources of two imports. Resources are matched by type, ID and provider
+// configuration, not by name, so renamed resources aren't reported.
+func DiffResources(previous, current []Resource) Drift {
+	drift := Drift{Added: []ResourceChange{}, Removed: []ResourceChange{}, Changed: []ResourceChange{}}
+	previousResources := map[string]Resource{}
+	for _, r := range previous {
+		previousResources[driftKey(r)] = r
+	}
+	currentResources := map[string]bool{}
+	for _, r := range current {
+		key := driftKey(r)
+		currentResources[key] = true
+		previousResource, exist := previousResources[key]
+		if !exist {
+			drift.Added = append(drift.Added, resourceChange(r, nil))
+			continue
+		}
+		if attributes := changedAttributes(previousResource, r); len(attributes) > 0 {
+			drift.Changed = append(drift.Changed, resourceChange(r, attributes))
+		}
+	}
+	for _, r := range previous {
+		if !currentResources[driftKey(r)] {
+			drift.Removed = append(drift.Removed, resourceChange(r, nil))
+		}
+	}
+	for _, changes := range [][]ResourceChange{drift.Added, drift.Removed, drift.Changed} {
+		changes := changes
+		sort.SliceStable(changes, func(i, j int) bool {
+			if changes[i].Address != changes[j].Address {
+				return changes[i].Address < changes[j].Address
+			}
+			return changes[i].Provider < changes[j].Provider
+		})
+	}
+	return drift
+}
+
+func driftKey(r Resource) string {
+	return r.InstanceInfo.Type + "\x00" + resourceID(&r) + "\x00" + r.ProviderAddress()
+}
+
+func resourceChange(r Resource, attributes []string) ResourceChange {
+	return ResourceChange{
+		Address:    r.Address(),
+		Type:       r.InstanceInfo.Type,
+		ID:         resourceID(&r),
+		Provider:   r.ProviderAddress(),
+		Attributes: attributes,
+	}
+}
+
+// changedAttributes returns sorted keys of attributes with different values, counts of lists
+// and maps are skipped as changes of their elements are reported
+func changedAttributes(previous, current Resource) []string {
+	previousAttributes := map[string]string{}
+	if previous.InstanceState != nil {
+		previousAttributes = previous.InstanceState.Attributes
+	}
+	currentAttributes := map[string]string{}
+	if current.InstanceState != nil {
+		currentAttributes = current.InstanceState.Attributes
+	}
+	changed := map[string]bool{}
+	for key, value := range currentAttributes {
+		if previousValue, exist := previousAttributes[key]; !exist || previousValue != value {
+			changed[key] = true
+		}
+	}
+	for key := range previousAttributes {
+		if _, exist := currentAttributes[key]; !exist {
+			changed[key] = true
+		}
+	}
+	attributes := []string{}
+	for key := range changed {
+		if key == "id" || strings.HasSuffix(key, ".#") || strings.HasSuffix(key, ".%") {
+			continue
+		}
+		attributes = append(attributes, key)
+	}
+	sort.Strings(attributes)
+	return attributes
+}
@@ -0,0 +1,47 @@
+package terraformutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffResources(t *testing.T) {
+	queue := func(id string, attributes map[string]string) Resource {
+		return NewResource(id, "queue_"+id, "rabbitmq_queue", "rabbitmq", attributes, nil, nil)
+	}
+	previous := []Resource{
+		queue("events", map[string]string{"id": "events", "settings.#": "1", "settings.0.durable": "true", "settings.0.arguments.%": "0"}),
+		queue("orders", map[string]string{"id": "orders", "vhost": "/"}),
+		queue("removed", map[string]string{"id": "removed"}),
+	}
+	current := []Resource{
+		queue("events", map[string]string{"id": "events", "settings.#": "1", "settings.0.durable": "false", "settings.0.arguments.%": "1", "settings.0.arguments.x-max-length": "10"}),
+		queue("orders", map[string]string{"id": "orders", "vhost": "/"}),
+		queue("added", map[string]string{"id": "added"}),
+	}
+	// the same queue of another configuration of provider is another resource
+	aliased := queue("orders", map[string]string{"id": "orders"})
+	aliased.ProviderAlias = "eu"
+	current = append(current, aliased)
+
+	drift := DiffResources(previous, current)
+	expected := Drift{
+		Added: []ResourceChange{
+			{Address: "rabbitmq_queue.tfer--queue_added", Type: "rabbitmq_queue", ID: "added", Provider: "rabbitmq"},
+			{Address: "rabbitmq_queue.tfer--queue_orders", Type: "rabbitmq_queue", ID: "orders", Provider: "rabbitmq.eu"},
+		},
+		Removed: []ResourceChange{
+			{Address: "rabbitmq_queue.tfer--queue_removed", Type: "rabbitmq_queue", ID: "removed", Provider: "rabbitmq"},
+		},
+		Changed: []ResourceChange{
+			{Address: "rabbitmq_queue.tfer--queue_events", Type: "rabbitmq_queue", ID: "events", Provider: "rabbitmq",
+				Attributes: []string{"settings.0.arguments.x-max-length", "settings.0.durable"}},
+		},
+	}
+	if !reflect.DeepEqual(drift, expected) {
+		t.Errorf("unexpected drift:\n%+v\nexpected:\n%+v", drift, expected)
+	}
+	if !DiffResources(current, current).Empty() {
+		t.Errorf("unexpected drift of the same resources")
+	}
+}
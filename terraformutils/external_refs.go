@@ -0,0 +1,114 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"sort"
+	"strings"
+)
+
+// DataSourceReference describes how to look up a resource of a service with a data source
+type DataSourceReference struct {
+	DataSource string
+	// Arguments returns data source arguments matching referenced value
+	Arguments func(value string) map[string]interface{}
+}
+
+// DataSourceReferencesProvider is implemented by providers which can look up resources
+// of services, keyed by service name, outside of the import with data sources
+type DataSourceReferencesProvider interface {
+	GetDataSourceReferences() map[string]DataSourceReference
+}
+
+// ArgumentReference looks up referenced value by single data source argument
+func ArgumentReference(dataSource, argument string) DataSourceReference {
+	return DataSourceReference{
+		DataSource: dataSource,
+		Arguments: func(value string) map[string]interface{} {
+			return map[string]interface{}{argument: value}
+		},
+	}
+}
+
+// ConnectExternalServices replaces references to services which weren't imported with data sources.
+// Returns data blocks to print by referencing service.
+func ConnectExternalServices(importResources map[string][]Resource, resourceConnections map[string]map[string][]string,
+	dataSourceReferences map[string]DataSourceReference) map[string]map[string]map[string]interface{} {
+	dataSourcesByService := map[string]map[string]map[string]interface{}{}
+	var services []string
+	for service := range importResources {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	names := map[string]string{}
+	for _, service := range services {
+		connection, exist := resourceConnections[service]
+		if !exist {
+			continue
+		}
+		var connectedServices []string
+		for k := range connection {
+			connectedServices = append(connectedServices, k)
+		}
+		sort.Strings(connectedServices)
+		dataSources := map[string]map[string]interface{}{}
+		for _, k := range connectedServices {
+			connectionPairs := connection[k]
+			reference, hasReference := dataSourceReferences[k]
+			if _, imported := importResources[k]; imported || !hasReference || len(connectionPairs)%2 == 1 {
+				continue
+			}
+			for i := 0; i < len(connectionPairs)/2; i++ {
+				attribute, targetAttribute := connectionPairs[i*2], connectionPairs[i*2+1]
+				for _, resource := range importResources[service] {
+					for _, value := range WalkAndGet(attribute, resource.Item) {
+						identifier := value.(string)
+						if identifier == "" || strings.Contains(identifier, "${") {
+							continue
+						}
+						name := dataSourceName(reference.DataSource, identifier, names)
+						if dataSources[reference.DataSource] == nil {
+							dataSources[reference.DataSource] = map[string]interface{}{}
+						}
+						dataSources[reference.DataSource][name] = reference.Arguments(identifier)
+						linkValue := "${data." + reference.DataSource + "." + name + "." + targetAttribute + "}"
+						WalkAndOverride(attribute, identifier, linkValue, resource.Item)
+					}
+				}
+			}
+		}
+		if len(dataSources) > 0 {
+			dataSourcesByService[service] = dataSources
+		}
+	}
+	return dataSourcesByService
+}
+
+// dataSourceName names data block by last segment of referenced ID, ARN or self link, full value is used on conflicts
+func dataSourceName(dataSource, identifier string, names map[string]string) string {
+	key := dataSource + "." + identifier
+	if name, exist := names[key]; exist {
+		return name
+	}
+	name := TfSanitize(identifier[strings.LastIndexAny(identifier, "/:")+1:])
+	for _, usedName := range names {
+		if usedName == name {
+			name = TfSanitize(identifier)
+			break
+		}
+	}
+	names[key] = name
+	return name
+}
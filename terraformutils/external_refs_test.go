@@ -0,0 +1,64 @@
+package terraformutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConnectExternalServices(t *testing.T) {
+	importResources := map[string][]Resource{
+		"subnet": {prepare("subnet-1", "aws_subnet", map[string]string{
+			"vpc_id": "vpc-1",
+		}, map[string]interface{}{
+			"vpc_id": "vpc-1",
+		})},
+		"sg": {prepare("sg-1", "aws_security_group", map[string]string{
+			"vpc_id": "vpc-1",
+		}, map[string]interface{}{
+			"vpc_id": "${data.terraform_remote_state.vpc.outputs.aws_vpc_tfer--vpc-002D-1_id}",
+		})},
+	}
+	resourceConnections := map[string]map[string][]string{
+		"subnet": {"vpc": {"vpc_id", "id"}},
+		"sg":     {"vpc": {"vpc_id", "id"}},
+	}
+	dataSourceReferences := map[string]DataSourceReference{
+		"vpc": ArgumentReference("aws_vpc", "id"),
+	}
+
+	dataSources := ConnectExternalServices(importResources, resourceConnections, dataSourceReferences)
+
+	if !reflect.DeepEqual(importResources["subnet"][0].Item, map[string]interface{}{
+		"vpc_id": "${data.aws_vpc.tfer--vpc-002D-1.id}",
+	}) {
+		t.Errorf("failed to reference data source %v", importResources["subnet"][0].Item)
+	}
+	if !reflect.DeepEqual(dataSources, map[string]map[string]map[string]interface{}{
+		"subnet": {"aws_vpc": {"tfer--vpc-002D-1": map[string]interface{}{"id": "vpc-1"}}},
+	}) {
+		t.Errorf("wrong data sources %v", dataSources)
+	}
+}
+
+func TestConnectExternalServicesSkipsImported(t *testing.T) {
+	importResources := map[string][]Resource{
+		"subnet": {prepare("subnet-1", "aws_subnet", map[string]string{
+			"vpc_id": "vpc-1",
+		}, map[string]interface{}{
+			"vpc_id": "vpc-1",
+		})},
+		"vpc": {prepareNoAttrs("vpc-2", "aws_vpc")},
+	}
+	resourceConnections := map[string]map[string][]string{
+		"subnet": {"vpc": {"vpc_id", "id"}},
+	}
+	dataSourceReferences := map[string]DataSourceReference{
+		"vpc": ArgumentReference("aws_vpc", "id"),
+	}
+
+	dataSources := ConnectExternalServices(importResources, resourceConnections, dataSourceReferences)
+
+	if len(dataSources) > 0 || importResources["subnet"][0].Item["vpc_id"] != "vpc-1" {
+		t.Errorf("imported service must not be looked up with data source %v", dataSources)
+	}
+}
@@ -21,11 +21,14 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/hcl/ast"
 	hclPrinter "github.com/hashicorp/hcl/hcl/printer"
 	hclParser "github.com/hashicorp/hcl/json/parser"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 )
 
 // Copy code from https://github.com/kubernetes/kops project with few changes for support many provider and heredoc
@@ -34,6 +37,8 @@ const safeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ012345678
 
 var unsafeChars = regexp.MustCompile(`[^0-9A-Za-z_]`)
 
+var hclStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
 // sanitizer fixes up an invalid HCL AST, as produced by the HCL parser for JSON
 type astSanitizer struct{}
 
@@ -127,14 +132,14 @@ func (v *astSanitizer) visitObjectItem(o *ast.ObjectItem) {
 func Print(data interface{}, mapsObjects map[string]struct{}, format string) ([]byte, error) {
 	switch format {
 	case "hcl":
-		return hclPrint(data, mapsObjects)
+		return hclPrint(data, mapsObjects, nil)
 	case "json":
 		return jsonPrint(data)
 	}
 	return []byte{}, errors.New("error: unknown output format")
 }
 
-func hclPrint(data interface{}, mapsObjects map[string]struct{}) ([]byte, error) {
+func hclPrint(data interface{}, mapsObjects map[string]struct{}, comments map[string][]string) ([]byte, error) {
 	dataBytesJSON, err := jsonPrint(data)
 	if err != nil {
 		return dataBytesJSON, err
@@ -147,6 +152,7 @@ func hclPrint(data interface{}, mapsObjects map[string]struct{}) ([]byte, error)
 	}
 	var sanitizer astSanitizer
 	sanitizer.visit(nodes)
+	addLeadComments(nodes, comments)
 
 	var b bytes.Buffer
 	err = hclPrinter.Fprint(&b, nodes)
@@ -160,6 +166,9 @@ func hclPrint(data interface{}, mapsObjects map[string]struct{}) ([]byte, error)
 
 	// ...but leave whitespace between resources
 	s = strings.ReplaceAll(s, "}\nresource", "}\n\nresource")
+	if len(comments) > 0 {
+		s = strings.ReplaceAll(s, "}\n#", "}\n\n#")
+	}
 
 	// Apply Terraform style (alignment etc.)
 	formatted, err := hclPrinter.Format([]byte(s))
@@ -181,6 +190,29 @@ func hclPrint(data interface{}, mapsObjects map[string]struct{}) ([]byte, error)
 	return formatted, nil
 }
 
+// addLeadComments puts comments above top level blocks, comments are keyed by block labels joined by dots
+func addLeadComments(nodes *ast.File, comments map[string][]string) {
+	list, ok := nodes.Node.(*ast.ObjectList)
+	if !ok || len(comments) == 0 {
+		return
+	}
+	for _, item := range list.Items {
+		keys := make([]string, len(item.Keys))
+		for i, key := range item.Keys {
+			keys[i] = strings.Trim(key.Token.Text, `"`)
+		}
+		lines := comments[strings.Join(keys, ".")]
+		if len(lines) == 0 {
+			continue
+		}
+		group := &ast.CommentGroup{}
+		for _, line := range lines {
+			group.List = append(group.List, &ast.Comment{Text: "# " + line})
+		}
+		item.LeadComment = group
+	}
+}
+
 func terraform12Adjustments(formatted []byte, mapsObjects map[string]struct{}) []byte {
 	singletonListFix := regexp.MustCompile(`^\s*\w+ = {`)
 	singletonListFixEnd := regexp.MustCompile(`^\s*}`)
@@ -243,6 +275,9 @@ func TfSanitize(name string) string {
 func HclPrintResource(resources []Resource, providerData map[string]interface{}, output string) ([]byte, error) {
 	resourcesByType := map[string]map[string]interface{}{}
 	mapsObjects := map[string]struct{}{}
+	collapsedResources := map[string][]Resource{}
+	comments := map[string][]string{}
+	providerAddresses := map[string]struct{}{}
 	indexRe := regexp.MustCompile(`\.[0-9]+`)
 	for _, res := range resources {
 		r := resourcesByType[res.InstanceInfo.Type]
@@ -251,6 +286,11 @@ func HclPrintResource(resources []Resource, providerData map[string]interface{},
 			resourcesByType[res.InstanceInfo.Type] = r
 		}
 
+		if res.CollapsedName != "" {
+			collapsedResources[res.InstanceInfo.Type+"."+res.CollapsedName] = append(collapsedResources[res.InstanceInfo.Type+"."+res.CollapsedName], res)
+			continue
+		}
+
 		if r[res.ResourceName] != nil {
 			log.Println(resources)
 			log.Printf("[ERR]: duplicate resource found: %s.%s", res.InstanceInfo.Type, res.ResourceName)
@@ -258,6 +298,24 @@ func HclPrintResource(resources []Resource, providerData map[string]interface{},
 		}
 
 		r[res.ResourceName] = res.Item
+		if len(res.Comments) > 0 {
+			comments["resource."+res.InstanceInfo.Type+"."+res.ResourceName] = res.Comments
+		}
+		if res.ProviderAlias != "" || (len(res.Comments) > 0 && output == "json") {
+			item := map[string]interface{}{}
+			for k, v := range res.Item {
+				item[k] = v
+			}
+			if res.ProviderAlias != "" {
+				item["provider"] = res.ProviderAddress()
+				providerAddresses[res.ProviderAddress()] = struct{}{}
+			}
+			if len(res.Comments) > 0 && output == "json" {
+				// Terraform JSON syntax ignores "//" properties of blocks
+				item["//"] = strings.Join(res.Comments, "\n")
+			}
+			r[res.ResourceName] = item
+		}
 
 		for k := range res.InstanceState.Attributes {
 			if strings.HasSuffix(k, ".%") {
@@ -267,7 +325,31 @@ func HclPrintResource(resources []Resource, providerData map[string]interface{},
 		}
 	}
 
+	locals := map[string]interface{}{}
+	localComments := map[string]map[string][]string{}
+	for _, collapsed := range collapsedResources {
+		item, values := collapsedResourceData(collapsed)
+		for _, res := range collapsed {
+			if len(res.Comments) == 0 {
+				continue
+			}
+			if localComments[res.CollapsedName] == nil {
+				localComments[res.CollapsedName] = map[string][]string{}
+			}
+			localComments[res.CollapsedName][res.ResourceName] = res.Comments
+		}
+		if collapsed[0].ProviderAlias != "" {
+			item["provider"] = collapsed[0].ProviderAddress()
+			providerAddresses[collapsed[0].ProviderAddress()] = struct{}{}
+		}
+		resourcesByType[collapsed[0].InstanceInfo.Type][collapsed[0].CollapsedName] = item
+		locals[collapsed[0].CollapsedName] = values
+	}
+
 	data := map[string]interface{}{}
+	if len(locals) > 0 && output != "hcl" {
+		data["locals"] = locals
+	}
 	if len(resourcesByType) > 0 {
 		data["resource"] = resourcesByType
 	}
@@ -276,9 +358,123 @@ func HclPrintResource(resources []Resource, providerData map[string]interface{},
 	}
 	var err error
 
-	hclBytes, err := Print(data, mapsObjects, output)
+	var hclBytes []byte
+	if output == "hcl" {
+		hclBytes, err = hclPrint(data, mapsObjects, comments)
+	} else {
+		hclBytes, err = Print(data, mapsObjects, output)
+	}
 	if err != nil {
 		return []byte{}, err
 	}
+	if output == "hcl" {
+		hclBytes = unquoteProviderReferences(hclBytes, providerAddresses)
+		hclBytes = UnescapeCollapsedReferences(hclBytes, resources)
+	}
+	if len(locals) > 0 && output == "hcl" {
+		// HCL parser for JSON flattens nested objects, so locals are printed separately
+		hclBytes = append(hclPrintLocals(locals, localComments), hclBytes...)
+	}
 	return hclBytes, nil
 }
+
+// unquoteProviderReferences turns provider meta-arguments of resources to references,
+// HCL printer can only print them as strings
+func unquoteProviderReferences(hclBytes []byte, providerAddresses map[string]struct{}) []byte {
+	for address := range providerAddresses {
+		providerRe := regexp.MustCompile(`(?m)^(  provider\s*= )"` + regexp.QuoteMeta(address) + `"$`)
+		hclBytes = providerRe.ReplaceAll(hclBytes, []byte("${1}"+address))
+	}
+	return hclBytes
+}
+
+// UnescapeCollapsedReferences removes escaping of quotes in references to for_each instances
+// of collapsed resources, like ${aws_vpc.vpc[\"tfer--a\"].id}, HCL printer escapes them as
+// any other quotes of strings
+func UnescapeCollapsedReferences(hclBytes []byte, resources []Resource) []byte {
+	collapsedAddresses := map[string]struct{}{}
+	for _, r := range resources {
+		if r.CollapsedName != "" {
+			collapsedAddresses[r.InstanceInfo.Type+"."+r.CollapsedName] = struct{}{}
+		}
+	}
+	for address := range collapsedAddresses {
+		referenceRe := regexp.MustCompile(`\$\{` + regexp.QuoteMeta(address) + `\[\\"[0-9A-Za-z_\-]+\\"\][0-9A-Za-z_.\-]*\}`)
+		hclBytes = referenceRe.ReplaceAllFunc(hclBytes, func(match []byte) []byte {
+			return bytes.ReplaceAll(match, []byte(`\"`), []byte(`"`))
+		})
+	}
+	return hclBytes
+}
+
+// hclPrintLocals prints locals of collapsed resources, comments of resources are written above their entries
+func hclPrintLocals(locals map[string]interface{}, comments map[string]map[string][]string) []byte {
+	var b bytes.Buffer
+	b.WriteString("locals {\n")
+	for _, name := range sortedKeys(locals) {
+		b.WriteString(hclKey(name) + " = ")
+		values, ok := locals[name].(map[string]interface{})
+		if !ok || len(comments[name]) == 0 {
+			hclWriteValue(&b, locals[name])
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString("{\n")
+		for _, k := range sortedKeys(values) {
+			for _, line := range comments[name][k] {
+				b.WriteString("# " + line + "\n")
+			}
+			b.WriteString(hclKey(k) + " = ")
+			hclWriteValue(&b, values[k])
+			b.WriteString("\n")
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteString("}\n\n")
+	return hclwrite.Format(b.Bytes())
+}
+
+func hclWriteValue(b *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		b.WriteString("{\n")
+		for _, k := range sortedKeys(v) {
+			b.WriteString(hclKey(k) + " = ")
+			hclWriteValue(b, v[k])
+			b.WriteString("\n")
+		}
+		b.WriteString("}")
+	case []interface{}:
+		b.WriteString("[")
+		for i, item := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			hclWriteValue(b, item)
+		}
+		b.WriteString("]")
+	case string:
+		b.WriteString(`"` + hclStringEscaper.Replace(v) + `"`)
+	case nil:
+		b.WriteString("null")
+	default:
+		b.WriteString(fmt.Sprint(v))
+	}
+}
+
+// hclKey quotes keys of objects which aren't identifiers, like kubernetes.io/cluster/name
+func hclKey(key string) string {
+	if hclsyntax.ValidIdentifier(key) {
+		return key
+	}
+	return `"` + hclStringEscaper.Replace(key) + `"`
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -52,3 +52,57 @@ func TestPrintResource(t *testing.T) {
 		t.Errorf("failed to parse data %s", string(data))
 	}
 }
+
+func TestPrintResourceComments(t *testing.T) {
+	first := prepare("ID1", "type1", map[string]string{}, mapI("field1", "egg"))
+	first.Comments = []string{"id: ID1", "region: us-east-1"}
+	second := prepare("ID2", "type2", map[string]string{}, mapI("field1", "spam"))
+	third := prepare("ID3", "type3", map[string]string{}, mapI("field1", "ham"))
+	third.Comments = []string{"id: ID3"}
+
+	data, err := HclPrintResource([]Resource{first, second, third}, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# id: ID1\n# region: us-east-1\nresource \"type1\" \"tfer--name-002D-type1\" {"
+	if !strings.Contains(string(data), expected) {
+		t.Errorf("expected comments above resource, got %s", string(data))
+	}
+	if !strings.Contains(string(data), "}\n\n# id: ID3\nresource \"type3\"") {
+		t.Errorf("expected blank line before comments, got %s", string(data))
+	}
+	if strings.Count(string(data), "#") != 3 {
+		t.Errorf("expected no comments above second resource, got %s", string(data))
+	}
+
+	data, err = HclPrintResource([]Resource{first}, map[string]interface{}{}, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"//": "id: ID1\nregion: us-east-1"`) {
+		t.Errorf("expected comment property, got %s", string(data))
+	}
+}
+
+func TestPrintResourceProviderAlias(t *testing.T) {
+	first := prepare("ID1", "type1", map[string]string{}, mapI("field1", "egg"))
+	second := prepare("ID2", "type1", map[string]string{}, mapI("field1", "spam"))
+	second.ResourceName = "second"
+	second.ProviderAlias = "eu_west_1"
+	second.Provider = "aws"
+
+	data, err := HclPrintResource([]Resource{first, second}, map[string]interface{}{}, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "resource \"type1\" \"second\" {\n  field1   = \"spam\"\n  provider = aws.eu_west_1\n}"
+	if !strings.Contains(string(data), expected) {
+		t.Errorf("expected provider reference, got %s", string(data))
+	}
+	if strings.Count(string(data), "provider") != 1 {
+		t.Errorf("expected provider only for aliased resource, got %s", string(data))
+	}
+	if _, exist := second.Item["provider"]; exist {
+		t.Error("provider is added to item of resource")
+	}
+}
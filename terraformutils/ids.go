@@ -0,0 +1,199 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// SelectedResource is ID of a resource to import, optionally with its service and type
+type SelectedResource struct {
+	ID      string `json:"id"`
+	Service string `json:"service,omitempty"`
+	Type    string `json:"type,omitempty"`
+}
+
+// ReadSelectedResources reads IDs from a JSON array of IDs or objects with id, service and type,
+// CSV with header of id, service and type columns, or lines of IDs. Lines starting with # are skipped.
+func ReadSelectedResources(r io.Reader) ([]SelectedResource, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		return readSelectedJSON(trimmed)
+	}
+	firstLine := trimmed
+	if i := bytes.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+	for _, column := range strings.Split(string(firstLine), ",") {
+		if strings.EqualFold(strings.TrimSpace(column), "id") {
+			return readSelectedCSV(trimmed)
+		}
+	}
+	var selected []SelectedResource
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		selected = append(selected, SelectedResource{ID: line})
+	}
+	return selected, scanner.Err()
+}
+
+func readSelectedJSON(data []byte) ([]SelectedResource, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	selected := make([]SelectedResource, 0, len(items))
+	for i, item := range items {
+		var resource SelectedResource
+		if err := json.Unmarshal(item, &resource.ID); err != nil {
+			if err := json.Unmarshal(item, &resource); err != nil {
+				return nil, fmt.Errorf("item %d: %v", i+1, err)
+			}
+		}
+		if resource.ID == "" {
+			return nil, fmt.Errorf("item %d: id is required", i+1)
+		}
+		selected = append(selected, resource)
+	}
+	return selected, nil
+}
+
+func readSelectedCSV(data []byte) ([]SelectedResource, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	value := func(record []string, column string) string {
+		if i, exist := columns[column]; exist && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	var selected []SelectedResource
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		resource := SelectedResource{
+			ID:      value(record, "id"),
+			Service: value(record, "service"),
+			Type:    value(record, "type"),
+		}
+		if resource.ID == "" {
+			return nil, fmt.Errorf("row %d: id is required", row)
+		}
+		selected = append(selected, resource)
+	}
+	return selected, nil
+}
+
+// SelectedServices returns services of selected resources, all is true if some resources have no service
+func SelectedServices(selected []SelectedResource) (services []string, all bool) {
+	unique := map[string]struct{}{}
+	for _, resource := range selected {
+		if resource.Service == "" {
+			all = true
+			continue
+		}
+		unique[resource.Service] = struct{}{}
+	}
+	for service := range unique {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services, all
+}
+
+// SelectedFilters returns filters of IDs, like --filter=type=id1:id2, of selected resources of service and
+// resources without service. Resources of the service must have one of the IDs, and entries with a type
+// also narrow IDs of their type. AWS ARNs also match by the ID at their end, like i-0123 of an instance ARN.
+func SelectedFilters(selected []SelectedResource, service, providerName string) []ResourceFilter {
+	idsByType := map[string][]string{}
+	untyped := false
+	for _, resource := range selected {
+		if resource.Service != "" && resource.Service != service {
+			continue
+		}
+		resourceType := strings.TrimPrefix(resource.Type, providerName+"_")
+		untyped = untyped || resourceType == ""
+		idsByType[resourceType] = append(idsByType[resourceType], resource.ID)
+		if id := arnResourceID(resource.ID); id != "" {
+			idsByType[resourceType] = append(idsByType[resourceType], id)
+		}
+	}
+	types := make([]string, 0, len(idsByType))
+	for resourceType := range idsByType {
+		types = append(types, resourceType)
+	}
+	sort.Strings(types)
+	// filters of all types must pass, so resources of types without entries are left out by IDs of all types
+	var ids []string
+	for _, resourceType := range types {
+		ids = append(ids, idsByType[resourceType]...)
+	}
+	filters := []ResourceFilter{{
+		FieldPath:        "id",
+		AcceptableValues: ids,
+	}}
+	if untyped {
+		// IDs of types can't be narrowed if some IDs have no type
+		return filters
+	}
+	for _, resourceType := range types {
+		filters = append(filters, ResourceFilter{
+			ServiceName:      resourceType,
+			FieldPath:        "id",
+			AcceptableValues: idsByType[resourceType],
+		})
+	}
+	return filters
+}
+
+// arnResourceID returns last part of resource of ARN arn:partition:service:region:account:resource
+func arnResourceID(arn string) string {
+	resource := arnPart(arn, 5)
+	if i := strings.LastIndexAny(resource, "/:"); i >= 0 {
+		return resource[i+1:]
+	}
+	return resource
+}
@@ -0,0 +1,68 @@
+package terraformutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSelectedResources(t *testing.T) {
+	testCases := map[string]string{
+		"lines": "# instances\ni-1\n\narn:aws:ec2:eu-west-1:123456789012:instance/i-2\n",
+		"csv":   "Name,Service,ID,Type\nweb,ec2_instance,i-1,aws_instance\n,,arn:aws:ec2:eu-west-1:123456789012:instance/i-2,\n",
+		"json":  `[{"id": "i-1", "service": "ec2_instance", "type": "aws_instance"}, "arn:aws:ec2:eu-west-1:123456789012:instance/i-2"]`,
+	}
+	for name, content := range testCases {
+		selected, err := ReadSelectedResources(strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		expected := []SelectedResource{
+			{ID: "i-1", Service: "ec2_instance", Type: "aws_instance"},
+			{ID: "arn:aws:ec2:eu-west-1:123456789012:instance/i-2"},
+		}
+		if name == "lines" {
+			expected[0] = SelectedResource{ID: "i-1"}
+		}
+		if !reflect.DeepEqual(selected, expected) {
+			t.Errorf("%s: expected %v, got %v", name, expected, selected)
+		}
+	}
+
+	if _, err := ReadSelectedResources(strings.NewReader("service,id\nec2_instance,\n")); err == nil || err.Error() != "row 2: id is required" {
+		t.Errorf("expected error of missing id, got %v", err)
+	}
+}
+
+func TestSelectedFilters(t *testing.T) {
+	selected := []SelectedResource{
+		{ID: "i-1", Service: "ec2_instance", Type: "aws_instance"},
+		{ID: "vol-1", Service: "ebs", Type: "ebs_volume"},
+		{ID: "sg-1", Service: "sg"},
+	}
+	filters := SelectedFilters(selected, "ec2_instance", "aws")
+	expected := []ResourceFilter{
+		{FieldPath: "id", AcceptableValues: []string{"i-1"}},
+		{ServiceName: "instance", FieldPath: "id", AcceptableValues: []string{"i-1"}},
+	}
+	if !reflect.DeepEqual(filters, expected) {
+		t.Errorf("expected %v, got %v", expected, filters)
+	}
+	// resources are kept only if all filters pass
+	volume := NewSimpleResource("vol-1", "data", "aws_ebs_volume", "aws", []string{})
+	if filters[0].Filter(volume) && filters[1].Filter(volume) {
+		t.Errorf("resource of type without entries wasn't filtered out")
+	}
+
+	selected = append(selected, SelectedResource{ID: "arn:aws:ec2:eu-west-1:123456789012:instance/i-2"})
+	filters = SelectedFilters(selected, "ec2_instance", "aws")
+	expected = []ResourceFilter{{ServiceName: "", FieldPath: "id", AcceptableValues: []string{"arn:aws:ec2:eu-west-1:123456789012:instance/i-2", "i-2", "i-1"}}}
+	if !reflect.DeepEqual(filters, expected) {
+		t.Errorf("expected %v, got %v", expected, filters)
+	}
+
+	services, all := SelectedServices(selected)
+	if !reflect.DeepEqual(services, []string{"ebs", "ec2_instance", "sg"}) || !all {
+		t.Errorf("unexpected services %v, all %t", services, all)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// InventoryItem is a resource found by InitResources, before refresh
+type InventoryItem struct {
+	Provider string `json:"provider"`
+	// Scope is region, project or resource group the resource was listed in
+	Scope   string `json:"scope,omitempty"`
+	Service string `json:"service"`
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+}
+
+// InventoryCount is number of resources of one type in a service
+type InventoryCount struct {
+	Provider string `json:"provider"`
+	Scope    string `json:"scope,omitempty"`
+	Service  string `json:"service"`
+	Type     string `json:"type"`
+	Count    int    `json:"count"`
+}
+
+// Inventory collects resources of several imports, e.g. of all regions or projects
+type Inventory struct {
+	mu       sync.Mutex
+	items    []InventoryItem
+	failures []string
+}
+
+func NewInventory() *Inventory {
+	return &Inventory{}
+}
+
+func (i *Inventory) Add(provider, scope, service string, resources []Resource) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, resource := range resources {
+		i.items = append(i.items, InventoryItem{
+			Provider: provider,
+			Scope:    scope,
+			Service:  service,
+			Type:     resource.InstanceInfo.Type,
+			ID:       resource.InstanceState.ID,
+			Name:     resource.ResourceName,
+		})
+	}
+}
+
+// AddFailure records service which failed to list its resources
+func (i *Inventory) AddFailure(provider, scope, service string, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	name := provider + " " + service
+	if scope != "" {
+		name = provider + " " + scope + " " + service
+	}
+	i.failures = append(i.failures, fmt.Sprintf("%s: %v", name, err))
+}
+
+// Err returns error of services which failed to list their resources, the inventory is incomplete then
+func (i *Inventory) Err() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if len(i.failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("inventory is incomplete, failed to list %d services: %s", len(i.failures), strings.Join(i.failures, "; "))
+}
+
+// Items returns collected resources sorted by provider, scope, service, type and ID
+func (i *Inventory) Items() []InventoryItem {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	items := append([]InventoryItem{}, i.items...)
+	sort.SliceStable(items, func(a, b int) bool {
+		return inventoryKey(items[a]) < inventoryKey(items[b])
+	})
+	return items
+}
+
+// Counts returns number of resources by provider, scope, service and type
+func (i *Inventory) Counts() []InventoryCount {
+	counts := []InventoryCount{}
+	for _, item := range i.Items() {
+		last := len(counts) - 1
+		if last >= 0 && counts[last].Provider == item.Provider && counts[last].Scope == item.Scope &&
+			counts[last].Service == item.Service && counts[last].Type == item.Type {
+			counts[last].Count++
+			continue
+		}
+		counts = append(counts, InventoryCount{
+			Provider: item.Provider,
+			Scope:    item.Scope,
+			Service:  item.Service,
+			Type:     item.Type,
+			Count:    1,
+		})
+	}
+	return counts
+}
+
+// Write prints inventory in table, csv or json format
+func (i *Inventory) Write(w io.Writer, format string) error {
+	switch format {
+	case "table", "":
+		return i.writeTable(w)
+	case "csv":
+		return i.writeCSV(w)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"counts":    i.Counts(),
+			"resources": i.Items(),
+		})
+	default:
+		return fmt.Errorf("unsupported inventory format %s", format)
+	}
+}
+
+func (i *Inventory) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tSCOPE\tSERVICE\tTYPE\tCOUNT")
+	total := 0
+	for _, count := range i.Counts() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", count.Provider, count.Scope, count.Service, count.Type, count.Count)
+		total += count.Count
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t\t%d\n", total)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "PROVIDER\tSCOPE\tSERVICE\tTYPE\tID\tNAME")
+	for _, item := range i.Items() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", item.Provider, item.Scope, item.Service, item.Type, item.ID, item.Name)
+	}
+	return tw.Flush()
+}
+
+func (i *Inventory) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"provider", "scope", "service", "type", "id", "name"}); err != nil {
+		return err
+	}
+	for _, item := range i.Items() {
+		if err := cw.Write([]string{item.Provider, item.Scope, item.Service, item.Type, item.ID, item.Name}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func inventoryKey(item InventoryItem) string {
+	return item.Provider + "\x00" + item.Scope + "\x00" + item.Service + "\x00" + item.Type + "\x00" + item.ID
+}
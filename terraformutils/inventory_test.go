@@ -0,0 +1,96 @@
+package terraformutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestInventory(t *testing.T) {
+	inventory := NewInventory()
+	inventory.Add("aws", "us-east-1", "vpc", []Resource{
+		NewSimpleResource("vpc-2", "main", "aws_vpc", "aws", []string{}),
+		NewSimpleResource("vpc-1", "default", "aws_vpc", "aws", []string{}),
+	})
+	inventory.Add("aws", "eu-west-1", "vpc", []Resource{
+		NewSimpleResource("vpc-3", "main", "aws_vpc", "aws", []string{}),
+	})
+	inventory.Add("aws", "us-east-1", "sg", []Resource{{
+		InstanceInfo:  &terraform.InstanceInfo{Type: "aws_security_group"},
+		InstanceState: &terraform.InstanceState{ID: "sg-1"},
+		ResourceName:  "web",
+	}})
+
+	expectedCounts := []InventoryCount{
+		{Provider: "aws", Scope: "eu-west-1", Service: "vpc", Type: "aws_vpc", Count: 1},
+		{Provider: "aws", Scope: "us-east-1", Service: "sg", Type: "aws_security_group", Count: 1},
+		{Provider: "aws", Scope: "us-east-1", Service: "vpc", Type: "aws_vpc", Count: 2},
+	}
+	counts := inventory.Counts()
+	if len(counts) != len(expectedCounts) {
+		t.Fatalf("wrong counts %v", counts)
+	}
+	for i := range expectedCounts {
+		if counts[i] != expectedCounts[i] {
+			t.Errorf("wrong count %v, expected %v", counts[i], expectedCounts[i])
+		}
+	}
+
+	csv := &bytes.Buffer{}
+	if err := inventory.Write(csv, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	expectedCSV := `provider,scope,service,type,id,name
+aws,eu-west-1,vpc,aws_vpc,vpc-3,tfer--main
+aws,us-east-1,sg,aws_security_group,sg-1,web
+aws,us-east-1,vpc,aws_vpc,vpc-1,tfer--default
+aws,us-east-1,vpc,aws_vpc,vpc-2,tfer--main
+`
+	if csv.String() != expectedCSV {
+		t.Errorf("wrong csv output:\n%s", csv.String())
+	}
+
+	output := &bytes.Buffer{}
+	if err := inventory.Write(output, "json"); err != nil {
+		t.Fatal(err)
+	}
+	parsed := struct {
+		Counts    []InventoryCount
+		Resources []InventoryItem
+	}{}
+	if err := json.Unmarshal(output.Bytes(), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Counts) != 3 || len(parsed.Resources) != 4 {
+		t.Errorf("wrong json output:\n%s", output.String())
+	}
+
+	if err := inventory.Write(output, "xml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestInventoryFailures(t *testing.T) {
+	inventory := NewInventory()
+	if err := inventory.Err(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	inventory.Add("aws", "us-east-1", "vpc", []Resource{
+		NewSimpleResource("vpc-1", "main", "aws_vpc", "aws", []string{}),
+	})
+	inventory.AddFailure("aws", "eu-west-1", "vpc", errors.New("access denied"))
+	err := inventory.Err()
+	if err == nil {
+		t.Fatal("expected error of failed service")
+	}
+	if !strings.Contains(err.Error(), "aws eu-west-1 vpc: access denied") {
+		t.Errorf("failed service is not reported %v", err)
+	}
+	if len(inventory.Items()) != 1 {
+		t.Errorf("resources of other services are not kept %v", inventory.Items())
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManagedResources holds IDs of resources already present in existing Terraform state, grouped by resource type
+type ManagedResources map[string]map[string]bool
+
+type managedStateV3 struct {
+	Modules []struct {
+		Resources map[string]struct {
+			Type    string `json:"type"`
+			Primary struct {
+				ID string `json:"id"`
+			} `json:"primary"`
+		} `json:"resources"`
+	} `json:"modules"`
+}
+
+type managedStateV4 struct {
+	Resources []struct {
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Instances []struct {
+			Attributes     map[string]interface{} `json:"attributes"`
+			AttributesFlat map[string]string      `json:"attributes_flat"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+func NewManagedResources() ManagedResources {
+	return ManagedResources{}
+}
+
+func (m ManagedResources) Add(resourceType, id string) {
+	if id == "" {
+		return
+	}
+	if m[resourceType] == nil {
+		m[resourceType] = map[string]bool{}
+	}
+	m[resourceType][id] = true
+}
+
+func (m ManagedResources) Contains(resourceType, id string) bool {
+	return m[resourceType][id]
+}
+
+// ParseState adds all managed resources of a v3 or v4 state file to the set
+func (m ManagedResources) ParseState(data []byte) error {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("error parsing state: %v", err)
+	}
+	switch {
+	case header.Version >= 4:
+		state := managedStateV4{}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("error parsing state: %v", err)
+		}
+		for _, resource := range state.Resources {
+			if resource.Mode != "" && resource.Mode != "managed" {
+				continue
+			}
+			for _, instance := range resource.Instances {
+				if id, ok := instance.Attributes["id"].(string); ok {
+					m.Add(resource.Type, id)
+				} else {
+					m.Add(resource.Type, instance.AttributesFlat["id"])
+				}
+			}
+		}
+	default:
+		state := managedStateV3{}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("error parsing state: %v", err)
+		}
+		for _, module := range state.Modules {
+			for key, resource := range module.Resources {
+				if strings.HasPrefix(key, "data.") {
+					continue
+				}
+				m.Add(resource.Type, resource.Primary.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFile adds managed resources from a local state file or from all *.tfstate files inside a directory
+func (m ManagedResources) LoadFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return m.ParseState(data)
+	}
+	return filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".tfstate") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		return m.ParseState(data)
+	})
+}
+
+// Filter returns resources not present in the managed set
+func (m ManagedResources) Filter(resources []Resource) []Resource {
+	var unmanaged []Resource
+	for _, resource := range resources {
+		if m.Contains(resource.InstanceInfo.Type, resource.InstanceState.ID) {
+			continue
+		}
+		unmanaged = append(unmanaged, resource)
+	}
+	return unmanaged
+}
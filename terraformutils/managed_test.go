@@ -0,0 +1,64 @@
+package terraformutils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const managedStateV3Data = `{
+	"version": 3,
+	"modules": [{
+		"path": ["root"],
+		"resources": {
+			"aws_vpc.main": {"type": "aws_vpc", "primary": {"id": "vpc-1"}},
+			"data.aws_vpc.other": {"type": "aws_vpc", "primary": {"id": "vpc-3"}}
+		}
+	}]
+}`
+
+const managedStateV4Data = `{
+	"version": 4,
+	"resources": [
+		{"mode": "managed", "type": "aws_subnet", "name": "a", "instances": [
+			{"attributes": {"id": "subnet-1"}},
+			{"attributes_flat": {"id": "subnet-2"}}
+		]},
+		{"mode": "data", "type": "aws_vpc", "name": "b", "instances": [{"attributes": {"id": "vpc-2"}}]}
+	]
+}`
+
+func TestManagedResourcesParseState(t *testing.T) {
+	managed := NewManagedResources()
+	if err := managed.ParseState([]byte(managedStateV3Data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := managed.ParseState([]byte(managedStateV4Data)); err != nil {
+		t.Fatal(err)
+	}
+	expected := ManagedResources{
+		"aws_vpc":    {"vpc-1": true},
+		"aws_subnet": {"subnet-1": true, "subnet-2": true},
+	}
+	if !reflect.DeepEqual(managed, expected) {
+		t.Errorf("failed to parse, got %v", managed)
+	}
+}
+
+func TestManagedResourcesFilter(t *testing.T) {
+	managed := NewManagedResources()
+	managed.Add("aws_vpc", "vpc-1")
+	resources := []Resource{
+		NewSimpleResource("vpc-1", "vpc-1", "aws_vpc", "aws", []string{}),
+		NewSimpleResource("vpc-2", "vpc-2", "aws_vpc", "aws", []string{}),
+		NewSimpleResource("vpc-1", "vpc-1", "aws_subnet", "aws", []string{}),
+	}
+	unmanaged := managed.Filter(resources)
+	if len(unmanaged) != 2 {
+		t.Fatalf("failed to filter, got %d resources", len(unmanaged))
+	}
+	if !reflect.DeepEqual(unmanaged[0].InstanceState, &terraform.InstanceState{ID: "vpc-2", Attributes: map[string]string{}}) {
+		t.Errorf("failed to filter, got %v", unmanaged[0].InstanceState)
+	}
+}
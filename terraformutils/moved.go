@@ -0,0 +1,258 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PreviousResource is a resource found in state of a previous run
+type PreviousResource struct {
+	Address string
+	// Dir is directory the resource would be generated in by this run
+	Dir string
+	// StatePath is state file the resource was read from
+	StatePath string
+}
+
+// PreviousResources holds resources of a previous output tree or state by type and ID
+type PreviousResources map[string]map[string]PreviousResource
+
+// ResourceMove is a resource with the same type and ID generated under a new address or directory
+type ResourceMove struct {
+	From      string
+	To        string
+	FromState string
+	ToState   string
+}
+
+type previousStateV4 struct {
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey       interface{}            `json:"index_key"`
+			Attributes     map[string]interface{} `json:"attributes"`
+			AttributesFlat map[string]string      `json:"attributes_flat"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+var stateV3CountSuffix = regexp.MustCompile(`\.([0-9]+)$`)
+
+func NewPreviousResources() PreviousResources {
+	return PreviousResources{}
+}
+
+// LoadPreviousResources reads a state file or all *.tfstate files of a previous output tree.
+// Directories of a tree are mapped to the same relative directories under pathOutput.
+func LoadPreviousResources(path, pathOutput string) (PreviousResources, error) {
+	previous := NewPreviousResources()
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return previous, previous.ParseState(data, path, filepath.Dir(path))
+	}
+	err = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".tfstate") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		relativeDir, err := filepath.Rel(path, filepath.Dir(filePath))
+		if err != nil {
+			return err
+		}
+		return previous.ParseState(data, filePath, filepath.Join(pathOutput, relativeDir))
+	})
+	return previous, err
+}
+
+func (p PreviousResources) Add(resourceType, id string, resource PreviousResource) {
+	if id == "" {
+		return
+	}
+	if p[resourceType] == nil {
+		p[resourceType] = map[string]PreviousResource{}
+	}
+	resource.Dir = filepath.Clean(resource.Dir)
+	p[resourceType][id] = resource
+}
+
+// ParseState adds managed resources of a v3 or v4 state file read from statePath
+func (p PreviousResources) ParseState(data []byte, statePath, dir string) error {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("error parsing state %s: %v", statePath, err)
+	}
+	if header.Version < 4 {
+		state := managedStateV3{}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("error parsing state %s: %v", statePath, err)
+		}
+		for _, module := range state.Modules {
+			for key, resource := range module.Resources {
+				if strings.HasPrefix(key, "data.") {
+					continue
+				}
+				p.Add(resource.Type, resource.Primary.ID, PreviousResource{
+					Address:   stateV3CountSuffix.ReplaceAllString(key, "[$1]"),
+					Dir:       dir,
+					StatePath: statePath,
+				})
+			}
+		}
+		return nil
+	}
+	state := previousStateV4{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("error parsing state %s: %v", statePath, err)
+	}
+	for _, resource := range state.Resources {
+		if resource.Mode != "" && resource.Mode != "managed" {
+			continue
+		}
+		address := resource.Type + "." + resource.Name
+		if resource.Module != "" {
+			address = resource.Module + "." + address
+		}
+		for _, instance := range resource.Instances {
+			id, ok := instance.Attributes["id"].(string)
+			if !ok {
+				id = instance.AttributesFlat["id"]
+			}
+			instanceAddress := address
+			switch key := instance.IndexKey.(type) {
+			case string:
+				instanceAddress += fmt.Sprintf("[%q]", key)
+			case float64:
+				instanceAddress += fmt.Sprintf("[%d]", int(key))
+			}
+			p.Add(resource.Type, id, PreviousResource{
+				Address:   instanceAddress,
+				Dir:       dir,
+				StatePath: statePath,
+			})
+		}
+	}
+	return nil
+}
+
+// Moves matches resources generated in dir with previous resources by type and ID.
+// Resources staying in dir under a new address are returned as moved, resources found
+// in another directory are returned as stateMoves.
+func (p PreviousResources) Moves(resources []Resource, dir string) (moved []ResourceMove, stateMoves []ResourceMove) {
+	dir = filepath.Clean(dir)
+	for _, resource := range resources {
+		previous, exist := p[resource.InstanceInfo.Type][resource.InstanceState.ID]
+		if !exist {
+			continue
+		}
+		move := ResourceMove{
+			From:      previous.Address,
+			To:        resource.Address(),
+			FromState: previous.StatePath,
+			ToState:   filepath.Join(dir, "terraform.tfstate"),
+		}
+		switch {
+		case previous.Dir != dir:
+			stateMoves = append(stateMoves, move)
+		case previous.Address != move.To:
+			moved = append(moved, move)
+		}
+	}
+	sortMoves(moved)
+	sortMoves(stateMoves)
+	return moved, stateMoves
+}
+
+// PrintMovedBlocks prints moved blocks in hcl or json
+func PrintMovedBlocks(moves []ResourceMove, output string) ([]byte, error) {
+	if output == "json" {
+		blocks := []map[string]string{}
+		for _, move := range moves {
+			blocks = append(blocks, map[string]string{"from": move.From, "to": move.To})
+		}
+		return json.MarshalIndent(map[string]interface{}{"moved": blocks}, "", "  ")
+	}
+	// addresses are references, hcl printer would quote them
+	var buf bytes.Buffer
+	for i, move := range moves {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "moved {\n  from = %s\n  to   = %s\n}\n", move.From, move.To)
+	}
+	return buf.Bytes(), nil
+}
+
+// PrintStateMvScript prints terraform state mv commands moving resources between state files.
+// State paths are relative to dir the script is written to.
+func PrintStateMvScript(moves []ResourceMove, dir string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#!/bin/sh\n")
+	buf.WriteString("# moves resources generated in other directories by a previous run\n")
+	buf.WriteString("set -e\n")
+	buf.WriteString("cd \"$(dirname \"$0\")\"\n")
+	for _, move := range moves {
+		fromState := move.FromState
+		if relativePath, err := filepath.Rel(dir, fromState); err == nil {
+			fromState = relativePath
+		}
+		toState := move.ToState
+		if relativePath, err := filepath.Rel(dir, toState); err == nil {
+			toState = relativePath
+		}
+		fmt.Fprintf(&buf, "terraform state mv -state=%s -state-out=%s %s %s\n",
+			shellQuote(filepath.ToSlash(fromState)), shellQuote(filepath.ToSlash(toState)), shellQuote(move.From), shellQuote(move.To))
+	}
+	return buf.Bytes()
+}
+
+func sortMoves(moves []ResourceMove) {
+	sort.Slice(moves, func(i, j int) bool {
+		if moves[i].From != moves[j].From {
+			return moves[i].From < moves[j].From
+		}
+		return moves[i].FromState < moves[j].FromState
+	})
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
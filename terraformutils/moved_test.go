@@ -0,0 +1,151 @@
+package terraformutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const previousStateV4Data = `{
+	"version": 4,
+	"resources": [
+		{"mode": "managed", "type": "aws_vpc", "name": "tfer--old", "instances": [{"attributes": {"id": "vpc-1"}}]},
+		{"mode": "managed", "type": "aws_vpc", "name": "tfer--same", "instances": [{"attributes": {"id": "vpc-2"}}]},
+		{"mode": "managed", "type": "aws_subnet", "name": "subnets", "instances": [
+			{"index_key": "a", "attributes": {"id": "subnet-1"}},
+			{"index_key": 1, "attributes_flat": {"id": "subnet-2"}}
+		]},
+		{"mode": "data", "type": "aws_vpc", "name": "other", "instances": [{"attributes": {"id": "vpc-3"}}]}
+	]
+}`
+
+func TestPreviousResourcesParseState(t *testing.T) {
+	previous := NewPreviousResources()
+	if err := previous.ParseState([]byte(managedStateV3Data), "v3/terraform.tfstate", "v3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := previous.ParseState([]byte(previousStateV4Data), "v4/terraform.tfstate", "v4/"); err != nil {
+		t.Fatal(err)
+	}
+	expected := PreviousResources{
+		"aws_vpc": {
+			"vpc-1": {Address: "aws_vpc.tfer--old", Dir: "v4", StatePath: "v4/terraform.tfstate"},
+			"vpc-2": {Address: "aws_vpc.tfer--same", Dir: "v4", StatePath: "v4/terraform.tfstate"},
+		},
+		"aws_subnet": {
+			"subnet-1": {Address: `aws_subnet.subnets["a"]`, Dir: "v4", StatePath: "v4/terraform.tfstate"},
+			"subnet-2": {Address: "aws_subnet.subnets[1]", Dir: "v4", StatePath: "v4/terraform.tfstate"},
+		},
+	}
+	// vpc-1 of v3 state is replaced by v4 state
+	if !reflect.DeepEqual(previous, expected) {
+		t.Errorf("failed to parse, got %v", previous)
+	}
+}
+
+func TestPreviousResourcesMoves(t *testing.T) {
+	previous := NewPreviousResources()
+	if err := previous.ParseState([]byte(previousStateV4Data), "old/aws/vpc/terraform.tfstate", "generated/aws/vpc"); err != nil {
+		t.Fatal(err)
+	}
+	resources := []Resource{
+		NewSimpleResource("vpc-1", "new", "aws_vpc", "aws", []string{}),
+		NewSimpleResource("vpc-2", "same", "aws_vpc", "aws", []string{}),
+		NewSimpleResource("vpc-4", "added", "aws_vpc", "aws", []string{}),
+	}
+	moved, stateMoves := previous.Moves(resources, "generated/aws/vpc/")
+	expectedMoved := []ResourceMove{{
+		From:      "aws_vpc.tfer--old",
+		To:        "aws_vpc.tfer--new",
+		FromState: "old/aws/vpc/terraform.tfstate",
+		ToState:   "generated/aws/vpc/terraform.tfstate",
+	}}
+	if !reflect.DeepEqual(moved, expectedMoved) || len(stateMoves) != 0 {
+		t.Errorf("failed to match moves in same directory, got %v and %v", moved, stateMoves)
+	}
+
+	subnets := []Resource{
+		NewSimpleResource("subnet-1", "a", "aws_subnet", "aws", []string{}),
+	}
+	moved, stateMoves = previous.Moves(subnets, "generated/aws/subnet")
+	expectedStateMoves := []ResourceMove{{
+		From:      `aws_subnet.subnets["a"]`,
+		To:        "aws_subnet.tfer--a",
+		FromState: "old/aws/vpc/terraform.tfstate",
+		ToState:   "generated/aws/subnet/terraform.tfstate",
+	}}
+	if len(moved) != 0 || !reflect.DeepEqual(stateMoves, expectedStateMoves) {
+		t.Errorf("failed to match moves across directories, got %v and %v", moved, stateMoves)
+	}
+
+	script := string(PrintStateMvScript(stateMoves, "generated/aws/subnet"))
+	expectedScript := `#!/bin/sh
+# moves resources generated in other directories by a previous run
+set -e
+cd "$(dirname "$0")"
+terraform state mv -state='../../../old/aws/vpc/terraform.tfstate' -state-out='terraform.tfstate' 'aws_subnet.subnets["a"]' 'aws_subnet.tfer--a'
+`
+	if script != expectedScript {
+		t.Errorf("failed to print script, got:\n%s", script)
+	}
+}
+
+func TestPrintMovedBlocks(t *testing.T) {
+	moves := []ResourceMove{
+		{From: "aws_vpc.tfer--old", To: "aws_vpc.tfer--new"},
+		{From: `aws_subnet.subnets["a"]`, To: "aws_subnet.tfer--a"},
+	}
+	hcl, err := PrintMovedBlocks(moves, "hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedHcl := `moved {
+  from = aws_vpc.tfer--old
+  to   = aws_vpc.tfer--new
+}
+
+moved {
+  from = aws_subnet.subnets["a"]
+  to   = aws_subnet.tfer--a
+}
+`
+	if string(hcl) != expectedHcl {
+		t.Errorf("failed to print hcl, got:\n%s", hcl)
+	}
+	json, err := PrintMovedBlocks(moves[:1], "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJSON := `{
+  "moved": [
+    {
+      "from": "aws_vpc.tfer--old",
+      "to": "aws_vpc.tfer--new"
+    }
+  ]
+}`
+	if string(json) != expectedJSON {
+		t.Errorf("failed to print json, got:\n%s", json)
+	}
+}
+
+func TestLoadPreviousResourcesFromTree(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "aws", "vpc")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "terraform.tfstate"), []byte(previousStateV4Data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := LoadPreviousResources(root, "generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource := previous["aws_vpc"]["vpc-1"]
+	if resource.Dir != filepath.Join("generated", "aws", "vpc") || resource.StatePath != filepath.Join(dir, "terraform.tfstate") {
+		t.Errorf("failed to map directory of previous tree, got %v", resource)
+	}
+}
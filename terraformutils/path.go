@@ -0,0 +1,124 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PathScope holds values of scope placeholders, like region or project, of a service
+type PathScope map[string]string
+
+var resourcePathPlaceholder = regexp.MustCompile(`{(region|account|project|resource_group|type|tag:[^}]+|attr:[^}]+)}`)
+var repeatedSlashes = regexp.MustCompile(`/{2,}`)
+
+// HasResourcePathPlaceholders returns true if path pattern has placeholders resolved by resource
+// or scope of its service, so resources of a service may be printed to different paths
+func HasResourcePathPlaceholders(pathPattern string) bool {
+	return resourcePathPlaceholder.MatchString(pathPattern)
+}
+
+// ResolveResourcePath replaces resource placeholders of path pattern, values of scope of the service
+// take precedence over attributes of the resource and placeholders without value are dropped
+func ResolveResourcePath(pathPattern string, r Resource, scope PathScope) string {
+	path := resourcePathPlaceholder.ReplaceAllStringFunc(pathPattern, func(placeholder string) string {
+		return pathSegment(resourcePathValue(strings.Trim(placeholder, "{}"), r, scope))
+	})
+	return repeatedSlashes.ReplaceAllString(path, "/")
+}
+
+func resourcePathValue(placeholder string, r Resource, scope PathScope) string {
+	if placeholder == "type" {
+		if r.InstanceInfo == nil {
+			return ""
+		}
+		return r.InstanceInfo.Type
+	}
+	if value := scope[placeholder]; value != "" {
+		return value
+	}
+	var attributes map[string]string
+	if r.InstanceState != nil {
+		attributes = r.InstanceState.Attributes
+	}
+	if strings.HasPrefix(placeholder, "tag:") {
+		tag := strings.TrimPrefix(placeholder, "tag:")
+		return firstAttribute(attributes, "tags."+tag, "labels."+tag)
+	}
+	if strings.HasPrefix(placeholder, "attr:") {
+		return attributes[strings.TrimPrefix(placeholder, "attr:")]
+	}
+	switch placeholder {
+	case "region":
+		if value := firstAttribute(attributes, "region", "location"); value != "" {
+			return value
+		}
+		return arnPart(attributes["arn"], 3)
+	case "account":
+		if value := firstAttribute(attributes, "account_id", "owner_id"); value != "" {
+			return value
+		}
+		if value := arnPart(attributes["arn"], 4); value != "" {
+			return value
+		}
+		return azureIDPart(attributes["id"], "subscriptions")
+	case "project":
+		return attributes["project"]
+	case "resource_group":
+		if value := firstAttribute(attributes, "resource_group_name", "resource_group"); value != "" {
+			return value
+		}
+		return azureIDPart(attributes["id"], "resourcegroups")
+	}
+	return ""
+}
+
+func firstAttribute(attributes map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := attributes[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// arnPart returns part of AWS ARN arn:partition:service:region:account:resource
+func arnPart(arn string, i int) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[i]
+}
+
+// azureIDPart returns value of segment of Azure resource ID like /subscriptions/{id}/resourceGroups/{name}
+func azureIDPart(id, segment string) string {
+	parts := strings.Split(strings.Trim(id, "/"), "/")
+	for i := 0; i+1 < len(parts); i += 2 {
+		if strings.EqualFold(parts[i], segment) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// pathSegment keeps a value in a single directory of path
+func pathSegment(value string) string {
+	if value == "." || value == ".." {
+		return "_"
+	}
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(value)
+}
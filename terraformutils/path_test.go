@@ -0,0 +1,76 @@
+package terraformutils
+
+import (
+	"testing"
+)
+
+func TestResolveResourcePath(t *testing.T) {
+	testCases := []struct {
+		name       string
+		pattern    string
+		attributes map[string]string
+		scope      PathScope
+		expected   string
+	}{
+		{
+			name:     "scope",
+			pattern:  "{output}/{provider}/{project}/{region}/{service}/",
+			scope:    PathScope{"project": "my-project", "region": "europe-west1"},
+			expected: "{output}/{provider}/my-project/europe-west1/{service}/",
+		},
+		{
+			name:       "scope before attributes",
+			pattern:    "{region}/",
+			attributes: map[string]string{"region": "us-east-1"},
+			scope:      PathScope{"region": "eu-west-1"},
+			expected:   "eu-west-1/",
+		},
+		{
+			name:       "arn",
+			pattern:    "{account}/{region}/{type}/",
+			attributes: map[string]string{"arn": "arn:aws:sqs:us-east-1:123456789012:queue"},
+			expected:   "123456789012/us-east-1/aws_sqs_queue/",
+		},
+		{
+			name:       "azure id",
+			pattern:    "{account}/{resource_group}/",
+			attributes: map[string]string{"id": "/subscriptions/0000/resourceGroups/rg-web/providers/Microsoft.Network/virtualNetworks/vnet"},
+			expected:   "0000/rg-web/",
+		},
+		{
+			name:       "tags and attributes",
+			pattern:    "{tag:team}/{attr:vhost}/",
+			attributes: map[string]string{"tags.team": "payments", "vhost": "/"},
+			expected:   "payments/_/",
+		},
+		{
+			name:       "labels",
+			pattern:    "{tag:team}/",
+			attributes: map[string]string{"labels.team": "search"},
+			expected:   "search/",
+		},
+		{
+			name:     "missing values",
+			pattern:  "{output}/{region}/{tag:team}/{service}/",
+			expected: "{output}/{service}/",
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			r := NewResource("id", "name", "aws_sqs_queue", "aws", testCase.attributes, []string{}, map[string]interface{}{})
+			if path := ResolveResourcePath(testCase.pattern, r, testCase.scope); path != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, path)
+			}
+		})
+	}
+}
+
+func TestHasResourcePathPlaceholders(t *testing.T) {
+	if HasResourcePathPlaceholders("{output}/{provider}/{service}/") {
+		t.Errorf("default path pattern has no resource placeholders")
+	}
+	if !HasResourcePathPlaceholders("{output}/{provider}/{tag:team}/") {
+		t.Errorf("tag placeholder isn't detected")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy evaluates Rego policies against refreshed resources of an import run.
+// Policies are packages under terraformer, e.g. terraformer.s3, with deny or warn rules
+// returning a message (or an object with msg) for each violation of the resource in input.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+const query = `level := ["deny", "warn"][_]; message := data.terraformer[policy][level][_]`
+
+// Finding is a violation of a policy by a resource
+type Finding struct {
+	// Policy is name of the package under terraformer
+	Policy string `json:"policy"`
+	// Level is deny or warn
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Service string `json:"service"`
+	Address string `json:"address"`
+	ID      string `json:"id"`
+}
+
+// Evaluator evaluates policies of a directory
+type Evaluator struct {
+	query rego.PreparedEvalQuery
+}
+
+// Load compiles .rego policies and JSON or YAML data files of dir, tests of policies are skipped
+func Load(ctx context.Context, dir string) (*Evaluator, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	preparedQuery, err := rego.New(
+		rego.Query(query),
+		rego.Load([]string{dir}, func(abspath string, info os.FileInfo, depth int) bool {
+			return !info.IsDir() && strings.HasSuffix(info.Name(), "_test.rego")
+		}),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Evaluator{query: preparedQuery}, nil
+}
+
+// Evaluate returns findings of resources of a service, input of policies is a resource with
+// its refreshed flatmap attributes and item of generated code
+func (e *Evaluator) Evaluate(ctx context.Context, service string, resources []terraformutils.Resource) ([]Finding, error) {
+	var findings []Finding
+	for _, r := range resources {
+		results, err := e.query.Eval(ctx, rego.EvalInput(input(service, r)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate policies for %s: %v", r.Address(), err)
+		}
+		for _, result := range results {
+			findings = append(findings, Finding{
+				Policy:  fmt.Sprint(result.Bindings["policy"]),
+				Level:   fmt.Sprint(result.Bindings["level"]),
+				Message: message(result.Bindings["message"]),
+				Service: service,
+				Address: r.Address(),
+				ID:      r.InstanceState.ID,
+			})
+		}
+	}
+	SortFindings(findings)
+	return findings, nil
+}
+
+func input(service string, r terraformutils.Resource) map[string]interface{} {
+	return map[string]interface{}{
+		"provider":   r.Provider,
+		"service":    service,
+		"type":       r.InstanceInfo.Type,
+		"name":       r.ResourceName,
+		"address":    r.Address(),
+		"id":         r.InstanceState.ID,
+		"attributes": r.InstanceState.Attributes,
+		"item":       r.Item,
+	}
+}
+
+func message(value interface{}) string {
+	if object, ok := value.(map[string]interface{}); ok {
+		if msg, exist := object["msg"]; exist {
+			return fmt.Sprint(msg)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// SortFindings sorts findings by service, address, policy and message
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		if a.Policy != b.Policy {
+			return a.Policy < b.Policy
+		}
+		return a.Message < b.Message
+	})
+}
+
+// Write writes findings as a JSON report
+func Write(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{"findings": findings})
+}
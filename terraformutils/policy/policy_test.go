@@ -0,0 +1,97 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+)
+
+const bucketsPolicy = `package terraformer.buckets
+
+deny[msg] {
+	input.type == "aws_s3_bucket"
+	input.item.acl == "public-read"
+	msg := sprintf("bucket %s is public", [input.id])
+}
+
+warn[{"msg": "bucket isn't versioned"}] {
+	input.type == "aws_s3_bucket"
+	input.attributes["versioning.0.enabled"] != "true"
+}
+`
+
+func writePolicies(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func bucket(id, acl, versioning string) terraformutils.Resource {
+	r := terraformutils.NewResource(id, id, "aws_s3_bucket", "aws", map[string]string{
+		"acl":                  acl,
+		"versioning.#":         "1",
+		"versioning.0.enabled": versioning,
+	}, []string{}, map[string]interface{}{})
+	r.Item = map[string]interface{}{"acl": acl}
+	return r
+}
+
+func TestEvaluate(t *testing.T) {
+	dir := writePolicies(t, map[string]string{
+		"buckets.rego": bucketsPolicy,
+		// tests of policies aren't loaded
+		"buckets_test.rego": "package terraformer.buckets\n\ntest_broken {",
+	})
+	evaluator, err := Load(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := evaluator.Evaluate(context.Background(), "s3", []terraformutils.Resource{
+		bucket("public", "public-read", "true"),
+		bucket("private", "private", "false"),
+		bucket("audited", "private", "true"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Finding{
+		{Policy: "buckets", Level: "warn", Message: "bucket isn't versioned", Service: "s3", Address: "aws_s3_bucket.tfer--private", ID: "private"},
+		{Policy: "buckets", Level: "deny", Message: "bucket public is public", Service: "s3", Address: "aws_s3_bucket.tfer--public", ID: "public"},
+	}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("expected %v, got %v", expected, findings)
+	}
+}
+
+func TestLoadInvalidPolicy(t *testing.T) {
+	dir := writePolicies(t, map[string]string{"broken.rego": "package terraformer.broken\n\ndeny[msg] {"})
+	if _, err := Load(context.Background(), dir); err == nil {
+		t.Errorf("expected error of invalid policy")
+	}
+	if _, err := Load(context.Background(), filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected error of missing directory")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(buf.String()) != `{
+  "findings": []
+}` {
+		t.Errorf("unexpected report without findings %s", buf.String())
+	}
+}
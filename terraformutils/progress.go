@@ -0,0 +1,41 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+// Stages of an import run reported by ProgressEvent
+const (
+	ProgressListing    = "listing"
+	ProgressListed     = "listed"
+	ProgressRefreshing = "refreshing"
+	ProgressRefreshed  = "refreshed"
+	ProgressEvaluated  = "evaluated"
+	ProgressWritten    = "written"
+)
+
+// ProgressEvent reports a step of an import run
+type ProgressEvent struct {
+	Stage   string
+	Service string
+	// Resources is number of listed, refreshing, refreshed or written resources of the service
+	Resources int
+	// Retries is number of failed reads of a refreshed resource
+	Retries int
+	// Findings is number of policy findings of resources of the service
+	Findings int
+	Err      error
+}
+
+// ProgressFunc receives events of an import run, it may be called from several goroutines
+type ProgressFunc func(event ProgressEvent)
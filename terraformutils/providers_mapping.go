@@ -4,6 +4,7 @@ import (
 	"log"
 	"math/rand"
 	"reflect"
+	"sort"
 	"time"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
@@ -52,10 +53,11 @@ func (p *ProvidersMapping) AddServiceToProvider(service string) ProviderGenerato
 }
 
 func (p *ProvidersMapping) GetServices() []string {
-	services := make([]string, len(p.Services))
+	services := make([]string, 0, len(p.Services))
 	for service := range p.Services {
 		services = append(services, service)
 	}
+	sort.Strings(services)
 
 	return services
 }
@@ -71,6 +73,7 @@ func (p *ProvidersMapping) RemoveServices(services []string) {
 	}
 }
 
+// ShuffleResources spreads refresh of resources of the same service over time, order of generated files doesn't depend on it
 func (p *ProvidersMapping) ShuffleResources() []*Resource {
 	resources := []*Resource{}
 	for resource := range p.Resources {
@@ -113,11 +116,24 @@ func (p *ProvidersMapping) MatchProvider(resource *Resource) ProviderGenerator {
 	return p.resourceToProvider[resource]
 }
 
+// ServiceOf returns name of the service resource was listed in
+func (p *ProvidersMapping) ServiceOf(resource *Resource) string {
+	return p.providerToService[p.resourceToProvider[resource]]
+}
+
+// ServiceArgs returns args of service set by its provider
+func (p *ProvidersMapping) ServiceArgs(service string) map[string]interface{} {
+	provider, exist := p.serviceToProvider[service]
+	if !exist || provider.GetService() == nil {
+		return nil
+	}
+	return provider.GetService().GetArgs()
+}
+
 func (p *ProvidersMapping) SetResources(resourceToKeep []*Resource) {
 	p.Resources = map[*Resource]bool{}
 	resourcesGroupsByProviders := map[ProviderGenerator][]Resource{}
-	for i := range resourceToKeep {
-		resource := resourceToKeep[i]
+	for _, resource := range sortedResources(resourceToKeep) {
 		provider := p.resourceToProvider[resource]
 		if resourcesGroupsByProviders[provider] == nil {
 			resourcesGroupsByProviders[provider] = []Resource{}
@@ -137,7 +153,7 @@ func (p *ProvidersMapping) GetResourcesByService() map[string][]Resource {
 		mapping[service] = []Resource{}
 	}
 
-	for resource := range p.Resources {
+	for _, resource := range p.sortedResources() {
 		provider := p.resourceToProvider[resource]
 		service := p.providerToService[provider]
 		mapping[service] = append(mapping[service], *resource)
@@ -146,6 +162,46 @@ func (p *ProvidersMapping) GetResourcesByService() map[string][]Resource {
 	return mapping
 }
 
+func (p *ProvidersMapping) sortedResources() []*Resource {
+	resources := make([]*Resource, 0, len(p.Resources))
+	for resource := range p.Resources {
+		resources = append(resources, resource)
+	}
+	return sortedResources(resources)
+}
+
+func sortedResources(resources []*Resource) []*Resource {
+	sorted := append([]*Resource{}, resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return resourceLess(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// SortResources orders resources by type, name and ID, so generated files don't depend on listing or refresh order
+func SortResources(resources []Resource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resourceLess(&resources[i], &resources[j])
+	})
+}
+
+func resourceLess(a, b *Resource) bool {
+	if a.InstanceInfo.Type != b.InstanceInfo.Type {
+		return a.InstanceInfo.Type < b.InstanceInfo.Type
+	}
+	if a.ResourceName != b.ResourceName {
+		return a.ResourceName < b.ResourceName
+	}
+	return resourceID(a) < resourceID(b)
+}
+
+func resourceID(r *Resource) string {
+	if r.InstanceState == nil {
+		return ""
+	}
+	return r.InstanceState.ID
+}
+
 func (p *ProvidersMapping) ConvertTFStates(providerWrapper *providerwrapper.ProviderWrapper) {
 	for resource := range p.Resources {
 		err := resource.ConvertTFstate(providerWrapper)
@@ -155,7 +211,7 @@ func (p *ProvidersMapping) ConvertTFStates(providerWrapper *providerwrapper.Prov
 	}
 
 	resourcesGroupsByProviders := map[ProviderGenerator][]Resource{}
-	for resource := range p.Resources {
+	for _, resource := range p.sortedResources() {
 		provider := p.resourceToProvider[resource]
 		if resourcesGroupsByProviders[provider] == nil {
 			resourcesGroupsByProviders[provider] = []Resource{}
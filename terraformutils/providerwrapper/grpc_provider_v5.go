@@ -0,0 +1,63 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providerwrapper //nolint
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"unsafe"
+
+	"github.com/hashicorp/go-plugin"
+	tfplugin "github.com/hashicorp/terraform/plugin"
+	"github.com/hashicorp/terraform/providers"
+	"google.golang.org/grpc"
+)
+
+// GRPCProviderPluginV5 implements plugin.GRPCPlugin for providers speaking plugin protocol 5,
+// it dispenses the client of terraform wrapped in GRPCProviderV5
+type GRPCProviderPluginV5 struct {
+	tfplugin.GRPCProviderPlugin
+}
+
+func (p *GRPCProviderPluginV5) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	raw, err := p.GRPCProviderPlugin.GRPCClient(ctx, broker, c)
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := raw.(*tfplugin.GRPCProvider)
+	if !ok {
+		return nil, fmt.Errorf("unexpected client %T of plugin protocol 5", raw)
+	}
+	return &GRPCProviderV5{GRPCProvider: provider}, nil
+}
+
+// GRPCProviderV5 is the protocol 5 client of terraform 0.12 which can be seeded with a schema
+type GRPCProviderV5 struct {
+	*tfplugin.GRPCProvider
+}
+
+// SetSchema seeds the schema, e.g. from the schema cache, so refresh doesn't request it from the plugin.
+// The client of terraform keeps the schema in an unexported field and requests it while the field is
+// empty, so the field is set directly. It has to be called before the client is used.
+func (p *GRPCProviderV5) SetSchema(schema providers.GetSchemaResponse, _ map[string]map[string]*NestedType) {
+	field := reflect.ValueOf(p.GRPCProvider).Elem().FieldByName("schemas")
+	if !field.IsValid() || field.Type() != reflect.TypeOf(schema) {
+		log.Println("WARN: schema can't be seeded to client of plugin protocol 5, it's requested from the plugin")
+		return
+	}
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Set(reflect.ValueOf(schema))
+}
@@ -0,0 +1,93 @@
+package providerwrapper //nolint
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/zclconf/go-cty/cty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestProviderV5 dispenses client of protocol 5 connected to a server counting requests
+// and answering none of them
+func newTestProviderV5(t *testing.T, requests *int32) *GRPCProviderV5 {
+	listener := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(grpc.UnknownServiceHandler(func(interface{}, grpc.ServerStream) error {
+		atomic.AddInt32(requests, 1)
+		return status.Error(codes.Unimplemented, "not implemented")
+	}))
+	go func() {
+		_ = s.Serve(listener)
+	}()
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	raw, err := (&GRPCProviderPluginV5{}).GRPCClient(context.Background(), nil, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw.(*GRPCProviderV5)
+}
+
+func TestGRPCProviderV5CachedSchema(t *testing.T) {
+	if err := os.Setenv(SchemaCacheDirEnv, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(SchemaCacheDirEnv)
+	pluginPath := filepath.Join(t.TempDir(), "terraform-provider-test")
+	if err := ioutil.WriteFile(pluginPath, []byte("plugin"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var requests int32
+	if schema := newTestProviderV5(t, &requests).GetSchema(); !schema.Diagnostics.HasErrors() || requests != 1 {
+		t.Fatalf("schema of unseeded client wasn't requested from the plugin, %d requests", requests)
+	}
+
+	schema := &ProviderSchema{GetSchemaResponse: providers.GetSchemaResponse{
+		Provider: providers.Schema{Block: &configschema.Block{}},
+		ResourceTypes: map[string]providers.Schema{
+			"test_server": {Block: &configschema.Block{Attributes: map[string]*configschema.Attribute{
+				"id":   {Type: cty.String, Computed: true},
+				"name": {Type: cty.String, Required: true},
+			}}},
+		},
+	}}
+	if err := writeCachedSchema(pluginPath, schema); err != nil {
+		t.Fatal(err)
+	}
+	requests = 0
+	client := newTestProviderV5(t, &requests)
+	provider := NewProviderWrapperWithProvider("test", client)
+	provider.pluginPath = pluginPath
+	readOnlyAttributes, err := provider.GetReadOnlyAttributes([]string{"test_server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isAttributeIgnored("id", readOnlyAttributes["test_server"]) {
+		t.Errorf("read-only attribute of cached schema was not ignored. Pattern list: %s", readOnlyAttributes["test_server"])
+	}
+	if seeded := client.GetSchema(); seeded.Diagnostics.HasErrors() || seeded.ResourceTypes["test_server"].Block == nil {
+		t.Errorf("client wasn't seeded with cached schema %v", seeded)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("schema was requested %d times although it was cached", n)
+	}
+}
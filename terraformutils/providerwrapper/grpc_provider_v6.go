@@ -0,0 +1,570 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providerwrapper //nolint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	proto "github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper/tfplugin6"
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/msgpack"
+	"google.golang.org/grpc"
+)
+
+// GRPCProviderPluginV6 implements plugin.GRPCPlugin for providers speaking plugin protocol 6
+type GRPCProviderPluginV6 struct {
+	plugin.Plugin
+}
+
+func (p *GRPCProviderPluginV6) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return NewGRPCProviderV6(ctx, proto.NewProviderClient(c)), nil
+}
+
+func (p *GRPCProviderPluginV6) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	return errors.New("terraformer doesn't serve providers")
+}
+
+// NestedType is a nested attribute type of protocol 6 providers.
+// configschema of terraform 0.12 knows only cty types, so nested attributes are kept aside.
+type NestedType struct {
+	Attributes map[string]*NestedAttribute
+	Nesting    configschema.NestingMode
+}
+
+type NestedAttribute struct {
+	configschema.Attribute
+	NestedType *NestedType
+}
+
+// GRPCProviderV6 translates protocol 6 calls to providers.Interface of terraform 0.12
+type GRPCProviderV6 struct {
+	client proto.ProviderClient
+	ctx    context.Context
+
+	mu          sync.Mutex
+	schemas     providers.GetSchemaResponse
+	nestedTypes map[string]map[string]*NestedType
+}
+
+func NewGRPCProviderV6(ctx context.Context, client proto.ProviderClient) *GRPCProviderV6 {
+	return &GRPCProviderV6{
+		client: client,
+		ctx:    ctx,
+	}
+}
+
+func (p *GRPCProviderV6) GetSchema() (resp providers.GetSchemaResponse) {
+	log.Printf("[TRACE] GRPCProviderV6: GetSchema")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.schemas.Provider.Block != nil {
+		return p.schemas
+	}
+
+	resp.ResourceTypes = make(map[string]providers.Schema)
+	resp.DataSources = make(map[string]providers.Schema)
+	nestedTypes := map[string]map[string]*NestedType{}
+
+	const maxRecvSize = 64 << 20
+	protoResp, err := p.client.GetProviderSchema(p.ctx, new(proto.GetProviderSchema_Request), grpc.MaxRecvMsgSizeCallOption{MaxRecvMsgSize: maxRecvSize})
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+
+	resp.Diagnostics = resp.Diagnostics.Append(protoToDiagnosticsV6(protoResp.Diagnostics))
+
+	if protoResp.Provider == nil {
+		resp.Diagnostics = resp.Diagnostics.Append(errors.New("missing provider schema"))
+		return resp
+	}
+
+	resp.Provider, _, err = protoToProviderSchemaV6(protoResp.Provider)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	for name, res := range protoResp.ResourceSchemas {
+		resp.ResourceTypes[name], nestedTypes[name], err = protoToProviderSchemaV6(res)
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("resource %s: %v", name, err))
+			return resp
+		}
+	}
+	for name, data := range protoResp.DataSourceSchemas {
+		resp.DataSources[name], _, err = protoToProviderSchemaV6(data)
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("data source %s: %v", name, err))
+			return resp
+		}
+	}
+
+	p.schemas = resp
+	p.nestedTypes = nestedTypes
+
+	return resp
+}
+
+// SetSchema seeds the schema, e.g. from the schema cache, so refresh doesn't request it from the plugin
+func (p *GRPCProviderV6) SetSchema(schema providers.GetSchemaResponse, nestedTypes map[string]map[string]*NestedType) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.schemas = schema
+	p.nestedTypes = nestedTypes
+}
+
+// NestedAttributeTypes returns nested attributes of resource types by path of attribute
+func (p *GRPCProviderV6) NestedAttributeTypes() map[string]map[string]*NestedType {
+	p.GetSchema()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nestedTypes
+}
+
+func (p *GRPCProviderV6) getSchema() providers.GetSchemaResponse {
+	p.mu.Lock()
+	if p.schemas.Provider.Block != nil {
+		p.mu.Unlock()
+		return p.schemas
+	}
+	p.mu.Unlock()
+	return p.GetSchema()
+}
+
+func (p *GRPCProviderV6) getResourceType(name string) (cty.Type, error) {
+	schema, ok := p.getSchema().ResourceTypes[name]
+	if !ok {
+		return cty.NilType, fmt.Errorf("unknown resource type %s", name)
+	}
+	return schema.Block.ImpliedType(), nil
+}
+
+func (p *GRPCProviderV6) getDataSourceType(name string) (cty.Type, error) {
+	schema, ok := p.getSchema().DataSources[name]
+	if !ok {
+		return cty.NilType, fmt.Errorf("unknown data source %s", name)
+	}
+	return schema.Block.ImpliedType(), nil
+}
+
+func (p *GRPCProviderV6) PrepareProviderConfig(r providers.PrepareProviderConfigRequest) (resp providers.PrepareProviderConfigResponse) {
+	log.Printf("[TRACE] GRPCProviderV6: ValidateProviderConfig")
+
+	// protocol 6 doesn't prepare config, it's only validated
+	resp.PreparedConfig = r.Config
+	mp, err := msgpack.Marshal(r.Config, p.getSchema().Provider.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	protoResp, err := p.client.ValidateProviderConfig(p.ctx, &proto.ValidateProviderConfig_Request{
+		Config: &proto.DynamicValue{Msgpack: mp},
+	})
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = resp.Diagnostics.Append(protoToDiagnosticsV6(protoResp.Diagnostics))
+	return resp
+}
+
+func (p *GRPCProviderV6) ValidateResourceTypeConfig(r providers.ValidateResourceTypeConfigRequest) (resp providers.ValidateResourceTypeConfigResponse) {
+	log.Printf("[TRACE] GRPCProviderV6: ValidateResourceConfig")
+
+	ty, err := p.getResourceType(r.TypeName)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	mp, err := msgpack.Marshal(r.Config, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	protoResp, err := p.client.ValidateResourceConfig(p.ctx, &proto.ValidateResourceConfig_Request{
+		TypeName: r.TypeName,
+		Config:   &proto.DynamicValue{Msgpack: mp},
+	})
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = resp.Diagnostics.Append(protoToDiagnosticsV6(protoResp.Diagnostics))
+	return resp
+}
+
+func (p *GRPCProviderV6) ValidateDataSourceConfig(r providers.ValidateDataSourceConfigRequest) (resp providers.ValidateDataSourceConfigResponse) {
+	log.Printf("[TRACE] GRPCProviderV6: ValidateDataResourceConfig")
+
+	ty, err := p.getDataSourceType(r.TypeName)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	mp, err := msgpack.Marshal(r.Config, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	protoResp, err := p.client.ValidateDataResourceConfig(p.ctx, &proto.ValidateDataResourceConfig_Request{
+		TypeName: r.TypeName,
+		Config:   &proto.DynamicValue{Msgpack: mp},
+	})
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = resp.Diagnostics.Append(protoToDiagnosticsV6(protoResp.Diagnostics))
+	return resp
+}
+
+func (p *GRPCProviderV6) UpgradeResourceState(r providers.UpgradeResourceStateRequest) (resp providers.UpgradeResourceStateResponse) {
+	log.Printf("[TRACE] GRPCProviderV6: UpgradeResourceState")
+
+	ty, err := p.getResourceType(r.TypeName)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	protoResp, err := p.client.UpgradeResourceState(p.ctx, &proto.UpgradeResourceState_Request{
+		TypeName: r.TypeName,
+		Version:  r.Version,
+		RawState: &proto.RawState{
+			Json:    r.RawStateJSON,
+			Flatmap: r.RawStateFlatmap,
+		},
+	})
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = resp.Diagnostics.Append(protoToDiagnosticsV6(protoResp.Diagnostics))
+	resp.UpgradedState, err = unmarshalDynamicValueV6(protoResp.UpgradedState, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+	}
+	return resp
+}
+
+func (p *GRPCProviderV6) Configure(r providers.ConfigureRequest) (resp providers.ConfigureResponse) {
+	log.Printf("[TRACE] GRPCProviderV6: ConfigureProvider")
+
+	mp, err := msgpack.Marshal(r.Config, p.getSchema().Provider.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	protoResp, err := p.client.ConfigureProvider(p.ctx, &proto.ConfigureProvider_Request{
+		TerraformVersion: r.TerraformVersion,
+		Config:           &proto.DynamicValue{Msgpack: mp},
+	})
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = resp.Diagnostics.Append(protoToDiagnosticsV6(protoResp.Diagnostics))
+	return resp
+}
+
+func (p *GRPCProviderV6) Stop() error {
+	log.Printf("[TRACE] GRPCProviderV6: StopProvider")
+
+	resp, err := p.client.StopProvider(p.ctx, new(proto.StopProvider_Request))
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+func (p *GRPCProviderV6) ReadResource(r providers.ReadResourceRequest) (resp providers.ReadResourceResponse) {
+	log.Printf("[TRACE] GRPCProviderV6: ReadResource")
+
+	ty, err := p.getResourceType(r.TypeName)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	mp, err := msgpack.Marshal(r.PriorState, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	protoResp, err := p.client.ReadResource(p.ctx, &proto.ReadResource_Request{
+		TypeName:     r.TypeName,
+		CurrentState: &proto.DynamicValue{Msgpack: mp},
+		Private:      r.Private,
+	})
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = resp.Diagnostics.Append(protoToDiagnosticsV6(protoResp.Diagnostics))
+	resp.NewState, err = unmarshalDynamicValueV6(protoResp.NewState, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Private = protoResp.Private
+	return resp
+}
+
+// PlanResourceChange isn't used by terraformer
+func (p *GRPCProviderV6) PlanResourceChange(r providers.PlanResourceChangeRequest) (resp providers.PlanResourceChangeResponse) {
+	resp.Diagnostics = resp.Diagnostics.Append(errors.New("PlanResourceChange is not supported for protocol 6 providers"))
+	return resp
+}
+
+// ApplyResourceChange isn't used by terraformer
+func (p *GRPCProviderV6) ApplyResourceChange(r providers.ApplyResourceChangeRequest) (resp providers.ApplyResourceChangeResponse) {
+	resp.Diagnostics = resp.Diagnostics.Append(errors.New("ApplyResourceChange is not supported for protocol 6 providers"))
+	return resp
+}
+
+func (p *GRPCProviderV6) ImportResourceState(r providers.ImportResourceStateRequest) (resp providers.ImportResourceStateResponse) {
+	log.Printf("[TRACE] GRPCProviderV6: ImportResourceState")
+
+	protoResp, err := p.client.ImportResourceState(p.ctx, &proto.ImportResourceState_Request{
+		TypeName: r.TypeName,
+		Id:       r.ID,
+	})
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = resp.Diagnostics.Append(protoToDiagnosticsV6(protoResp.Diagnostics))
+
+	for _, imported := range protoResp.ImportedResources {
+		ty, err := p.getResourceType(imported.TypeName)
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			return resp
+		}
+		state, err := unmarshalDynamicValueV6(imported.State, ty)
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			return resp
+		}
+		resp.ImportedResources = append(resp.ImportedResources, providers.ImportedResource{
+			TypeName: imported.TypeName,
+			State:    state,
+			Private:  imported.Private,
+		})
+	}
+	return resp
+}
+
+func (p *GRPCProviderV6) ReadDataSource(r providers.ReadDataSourceRequest) (resp providers.ReadDataSourceResponse) {
+	log.Printf("[TRACE] GRPCProviderV6: ReadDataSource")
+
+	ty, err := p.getDataSourceType(r.TypeName)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	mp, err := msgpack.Marshal(r.Config, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	protoResp, err := p.client.ReadDataSource(p.ctx, &proto.ReadDataSource_Request{
+		TypeName: r.TypeName,
+		Config:   &proto.DynamicValue{Msgpack: mp},
+	})
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp
+	}
+	resp.Diagnostics = resp.Diagnostics.Append(protoToDiagnosticsV6(protoResp.Diagnostics))
+	resp.State, err = unmarshalDynamicValueV6(protoResp.State, ty)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+	}
+	return resp
+}
+
+// Close does nothing, plugin process is killed by ProviderWrapper
+func (p *GRPCProviderV6) Close() error {
+	return nil
+}
+
+func unmarshalDynamicValueV6(v *proto.DynamicValue, ty cty.Type) (cty.Value, error) {
+	if v == nil {
+		return cty.NullVal(ty), nil
+	}
+	if len(v.Msgpack) > 0 {
+		return msgpack.Unmarshal(v.Msgpack, ty)
+	}
+	return cty.NullVal(ty), nil
+}
+
+func protoToDiagnosticsV6(ds []*proto.Diagnostic) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	for _, d := range ds {
+		severity := tfdiags.Error
+		if d.Severity == proto.Diagnostic_WARNING {
+			severity = tfdiags.Warning
+		}
+		if d.Attribute != nil {
+			diags = diags.Append(tfdiags.AttributeValue(severity, d.Summary, d.Detail, protoToAttributePathV6(d.Attribute)))
+		} else {
+			diags = diags.Append(tfdiags.WholeContainingBody(severity, d.Summary, d.Detail))
+		}
+	}
+	return diags
+}
+
+func protoToAttributePathV6(ap *proto.AttributePath) cty.Path {
+	var path cty.Path
+	for _, step := range ap.Steps {
+		switch selector := step.Selector.(type) {
+		case *proto.AttributePath_Step_AttributeName:
+			path = path.GetAttr(selector.AttributeName)
+		case *proto.AttributePath_Step_ElementKeyString:
+			path = path.Index(cty.StringVal(selector.ElementKeyString))
+		case *proto.AttributePath_Step_ElementKeyInt:
+			path = path.Index(cty.NumberIntVal(selector.ElementKeyInt))
+		}
+	}
+	return path
+}
+
+// protoToProviderSchemaV6 converts schema to configschema, nested attributes get their implied cty type
+// and are returned also separately by path of attribute
+func protoToProviderSchemaV6(s *proto.Schema) (providers.Schema, map[string]*NestedType, error) {
+	nestedTypes := map[string]*NestedType{}
+	block, err := protoToConfigSchemaV6(s.Block, nestedTypes, "")
+	if err != nil {
+		return providers.Schema{}, nil, err
+	}
+	return providers.Schema{
+		Version: s.Version,
+		Block:   block,
+	}, nestedTypes, nil
+}
+
+// protoToConfigSchemaV6 converts block to configschema, nested types of its attributes are added
+// to nestedTypes by path, names of blocks and of the attribute joined by dots
+func protoToConfigSchemaV6(b *proto.Schema_Block, nestedTypes map[string]*NestedType, path string) (*configschema.Block, error) {
+	block := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{},
+		BlockTypes: map[string]*configschema.NestedBlock{},
+	}
+	if b == nil {
+		return block, nil
+	}
+	for _, a := range b.Attributes {
+		attr, err := protoToAttributeV6(a)
+		if err != nil {
+			return nil, err
+		}
+		block.Attributes[a.Name] = &attr.Attribute
+		if attr.NestedType != nil {
+			nestedTypes[path+a.Name] = attr.NestedType
+		}
+	}
+	for _, nb := range b.BlockTypes {
+		nested, err := protoToConfigSchemaV6(nb.Block, nestedTypes, path+nb.TypeName+".")
+		if err != nil {
+			return nil, err
+		}
+		block.BlockTypes[nb.TypeName] = &configschema.NestedBlock{
+			Block:    *nested,
+			Nesting:  protoToNestingModeV6(nb.Nesting.String()),
+			MinItems: int(nb.MinItems),
+			MaxItems: int(nb.MaxItems),
+		}
+	}
+	return block, nil
+}
+
+func protoToAttributeV6(a *proto.Schema_Attribute) (*NestedAttribute, error) {
+	attr := &NestedAttribute{
+		Attribute: configschema.Attribute{
+			Description: a.Description,
+			Required:    a.Required,
+			Optional:    a.Optional,
+			Computed:    a.Computed,
+			Sensitive:   a.Sensitive,
+		},
+	}
+	if a.NestedType == nil {
+		if err := json.Unmarshal(a.Type, &attr.Type); err != nil {
+			return nil, fmt.Errorf("attribute %s: %v", a.Name, err)
+		}
+		return attr, nil
+	}
+	attr.NestedType = &NestedType{
+		Attributes: map[string]*NestedAttribute{},
+		Nesting:    protoToNestingModeV6(a.NestedType.Nesting.String()),
+	}
+	for _, nestedAttr := range a.NestedType.Attributes {
+		nested, err := protoToAttributeV6(nestedAttr)
+		if err != nil {
+			return nil, err
+		}
+		attr.NestedType.Attributes[nestedAttr.Name] = nested
+	}
+	attr.Type = nestedAttributeType(attr.NestedType)
+	return attr, nil
+}
+
+// nestedAttributeType returns cty type of attribute with nested type
+func nestedAttributeType(nestedType *NestedType) cty.Type {
+	attributeTypes := map[string]cty.Type{}
+	for name, attribute := range nestedType.Attributes {
+		attributeTypes[name] = attribute.Type
+	}
+	objectType := cty.Object(attributeTypes)
+	switch nestedType.Nesting {
+	case configschema.NestingList:
+		return cty.List(objectType)
+	case configschema.NestingSet:
+		return cty.Set(objectType)
+	case configschema.NestingMap:
+		return cty.Map(objectType)
+	default:
+		return objectType
+	}
+}
+
+// protocol 6 nesting modes of blocks and nested attributes are the same, except GROUP only for blocks
+func protoToNestingModeV6(mode string) configschema.NestingMode {
+	switch mode {
+	case "SINGLE":
+		return configschema.NestingSingle
+	case "GROUP":
+		return configschema.NestingGroup
+	case "LIST":
+		return configschema.NestingList
+	case "SET":
+		return configschema.NestingSet
+	case "MAP":
+		return configschema.NestingMap
+	}
+	return configschema.NestingSingle
+}
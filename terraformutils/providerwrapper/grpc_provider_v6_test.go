@@ -0,0 +1,198 @@
+package providerwrapper //nolint
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	proto "github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper/tfplugin6"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/msgpack"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testProviderServerV6 struct {
+	proto.UnimplementedProviderServer
+	state          cty.Value
+	schemaRequests int32
+}
+
+func (s *testProviderServerV6) GetProviderSchema(context.Context, *proto.GetProviderSchema_Request) (*proto.GetProviderSchema_Response, error) {
+	atomic.AddInt32(&s.schemaRequests, 1)
+	return &proto.GetProviderSchema_Response{
+		Provider: &proto.Schema{Block: &proto.Schema_Block{}},
+		ResourceSchemas: map[string]*proto.Schema{
+			"test_server": {Block: &proto.Schema_Block{
+				Attributes: []*proto.Schema_Attribute{
+					{Name: "id", Type: []byte(`"string"`), Computed: true},
+					{Name: "name", Type: []byte(`"string"`), Required: true},
+					{Name: "disks", Optional: true, NestedType: &proto.Schema_Object{
+						Nesting: proto.Schema_Object_LIST,
+						Attributes: []*proto.Schema_Attribute{
+							{Name: "size", Type: []byte(`"number"`), Required: true},
+							{Name: "device", Type: []byte(`"string"`), Computed: true},
+						},
+					}},
+					{Name: "status", Computed: true, NestedType: &proto.Schema_Object{
+						Nesting: proto.Schema_Object_SINGLE,
+						Attributes: []*proto.Schema_Attribute{
+							{Name: "state", Type: []byte(`"string"`), Computed: true},
+						},
+					}},
+				},
+				BlockTypes: []*proto.Schema_NestedBlock{
+					{TypeName: "network", Nesting: proto.Schema_NestedBlock_LIST, Block: &proto.Schema_Block{
+						Attributes: []*proto.Schema_Attribute{
+							{Name: "addresses", Optional: true, NestedType: &proto.Schema_Object{
+								Nesting: proto.Schema_Object_LIST,
+								Attributes: []*proto.Schema_Attribute{
+									{Name: "ip", Type: []byte(`"string"`), Computed: true},
+									{Name: "label", Type: []byte(`"string"`), Optional: true},
+								},
+							}},
+						},
+					}},
+				},
+			}},
+		},
+	}, nil
+}
+
+func (s *testProviderServerV6) ReadResource(ctx context.Context, req *proto.ReadResource_Request) (*proto.ReadResource_Response, error) {
+	mp, err := msgpack.Marshal(s.state, s.state.Type())
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ReadResource_Response{NewState: &proto.DynamicValue{Msgpack: mp}}, nil
+}
+
+func newTestProviderV6(t *testing.T, server *testProviderServerV6) *GRPCProviderV6 {
+	listener := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	proto.RegisterProviderServer(s, server)
+	go func() {
+		_ = s.Serve(listener)
+	}()
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return NewGRPCProviderV6(context.Background(), proto.NewProviderClient(conn))
+}
+
+func TestGRPCProviderV6(t *testing.T) {
+	diskType := cty.Object(map[string]cty.Type{"size": cty.Number, "device": cty.String})
+	server := &testProviderServerV6{state: cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.StringVal("server-1"),
+		"name": cty.StringVal("web"),
+		"disks": cty.ListVal([]cty.Value{cty.ObjectVal(map[string]cty.Value{
+			"size":   cty.NumberIntVal(10),
+			"device": cty.StringVal("/dev/sda"),
+		})}),
+		"status": cty.ObjectVal(map[string]cty.Value{"state": cty.StringVal("running")}),
+		"network": cty.ListVal([]cty.Value{cty.ObjectVal(map[string]cty.Value{
+			"addresses": cty.ListVal([]cty.Value{cty.ObjectVal(map[string]cty.Value{
+				"ip":    cty.StringVal("10.0.0.1"),
+				"label": cty.StringVal("private"),
+			})}),
+		})}),
+	})}
+	provider := NewProviderWrapperWithProvider("test", newTestProviderV6(t, server))
+
+	schema := provider.GetSchema()
+	if schema.Diagnostics.HasErrors() {
+		t.Fatal(schema.Diagnostics.Err())
+	}
+	disks := schema.ResourceTypes["test_server"].Block.Attributes["disks"]
+	if !disks.Type.Equals(cty.List(diskType)) {
+		t.Errorf("wrong nested attribute type %s", disks.Type.FriendlyName())
+	}
+
+	readOnlyAttributes, err := provider.GetReadOnlyAttributes([]string{"test_server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, attr := range []string{"id", "disks.0.device", "status.state", "network.0.addresses.0.ip"} {
+		if !isAttributeIgnored(attr, readOnlyAttributes["test_server"]) {
+			t.Errorf("attribute %q was not ignored. Pattern list: %s", attr, readOnlyAttributes["test_server"])
+		}
+	}
+	for _, attr := range []string{"name", "disks.#", "disks.0.size", "network.0.addresses.0.label"} {
+		if isAttributeIgnored(attr, readOnlyAttributes["test_server"]) {
+			t.Errorf("attribute %q was ignored. Pattern list: %s", attr, readOnlyAttributes["test_server"])
+		}
+	}
+
+	state, err := provider.Refresh(&terraform.InstanceInfo{Type: "test_server", Id: "test_server.web"},
+		&terraform.InstanceState{ID: "server-1", Attributes: map[string]string{"id": "server-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Attributes["disks.0.device"] != "/dev/sda" || state.Attributes["status.state"] != "running" ||
+		state.Attributes["network.0.addresses.0.ip"] != "10.0.0.1" {
+		t.Errorf("wrong refreshed state %v", state.Attributes)
+	}
+}
+
+func TestGRPCProviderV6CachedSchema(t *testing.T) {
+	if err := os.Setenv(SchemaCacheDirEnv, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(SchemaCacheDirEnv)
+	pluginPath := filepath.Join(t.TempDir(), "terraform-provider-test")
+	if err := ioutil.WriteFile(pluginPath, []byte("plugin"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	state := cty.ObjectVal(map[string]cty.Value{
+		"id":     cty.StringVal("server-1"),
+		"name":   cty.StringVal("web"),
+		"disks":  cty.ListValEmpty(cty.Object(map[string]cty.Type{"size": cty.Number, "device": cty.String})),
+		"status": cty.ObjectVal(map[string]cty.Value{"state": cty.StringVal("running")}),
+		"network": cty.ListValEmpty(cty.Object(map[string]cty.Type{
+			"addresses": cty.List(cty.Object(map[string]cty.Type{"ip": cty.String, "label": cty.String})),
+		})),
+	})
+
+	// first run caches the schema
+	provider := NewProviderWrapperWithProvider("test", newTestProviderV6(t, &testProviderServerV6{state: state}))
+	provider.pluginPath = pluginPath
+	if schema := provider.GetSchema(); schema.Diagnostics.HasErrors() {
+		t.Fatal(schema.Diagnostics.Err())
+	}
+
+	server := &testProviderServerV6{state: state}
+	provider = NewProviderWrapperWithProvider("test", newTestProviderV6(t, server))
+	provider.pluginPath = pluginPath
+	readOnlyAttributes, err := provider.GetReadOnlyAttributes([]string{"test_server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, attr := range []string{"status.state", "network.0.addresses.0.ip"} {
+		if !isAttributeIgnored(attr, readOnlyAttributes["test_server"]) {
+			t.Errorf("nested attribute %q of cached schema was not ignored. Pattern list: %s", attr, readOnlyAttributes["test_server"])
+		}
+	}
+	refreshed, err := provider.Refresh(&terraform.InstanceInfo{Type: "test_server", Id: "test_server.web"},
+		&terraform.InstanceState{ID: "server-1", Attributes: map[string]string{"id": "server-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.Attributes["status.state"] != "running" {
+		t.Errorf("wrong refreshed state %v", refreshed.Attributes)
+	}
+	if requests := atomic.LoadInt32(&server.schemaRequests); requests != 0 {
+		t.Errorf("schema was requested %d times although it was cached", requests)
+	}
+}
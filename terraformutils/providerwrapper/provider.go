@@ -49,21 +49,74 @@ const DefaultPluginVendorDirV12 = "terraform.d/plugins/" + pluginMachineName
 // pluginMachineName is the directory name used in new plugin paths.
 const pluginMachineName = runtime.GOOS + "_" + runtime.GOARCH
 
+// versionedPlugins allows to negotiate plugin protocol 5 (SDK providers) or 6 (plugin framework providers)
+var versionedPlugins = map[int]plugin.PluginSet{
+	5: {tfplugin.ProviderPluginName: &GRPCProviderPluginV5{}},
+	6: {tfplugin.ProviderPluginName: &GRPCProviderPluginV6{}},
+}
+
+// nestedAttributesProvider is implemented by providers with nested attribute types in schema (protocol 6)
+type nestedAttributesProvider interface {
+	NestedAttributeTypes() map[string]map[string]*NestedType
+}
+
+// schemaSeededProvider is implemented by providers which can use a cached schema instead of requesting it
+// from the plugin
+type schemaSeededProvider interface {
+	SetSchema(schema providers.GetSchemaResponse, nestedTypes map[string]map[string]*NestedType)
+}
+
 type ProviderWrapper struct {
-	Provider     *tfplugin.GRPCProvider
+	Provider     providers.Interface
 	client       *plugin.Client
 	rpcClient    plugin.ClientProtocol
 	providerName string
+	pluginPath   string
 	config       cty.Value
-	schema       *providers.GetSchemaResponse
+	schema       *ProviderSchema
 	retryCount   int
 	retrySleepMs int
 }
 
 func NewProviderWrapper(providerName string, providerConfig cty.Value, verbose bool, options ...map[string]int) (*ProviderWrapper, error) {
+	p := newProviderWrapper(providerName, options)
+	p.config = providerConfig
+
+	err := p.initProvider(verbose)
+
+	return p, err
+}
+
+// NewProviderWrapperWithSchema starts provider like NewProviderWrapper, but uses schema, e.g. read
+// with ReadSchemaFile, instead of asking the plugin or the schema cache for it
+func NewProviderWrapperWithSchema(providerName string, providerConfig cty.Value, schema *ProviderSchema, verbose bool, options ...map[string]int) (*ProviderWrapper, error) {
+	p := newProviderWrapper(providerName, options)
+	p.config = providerConfig
+	p.schema = schema
+
+	err := p.initProvider(verbose)
+
+	return p, err
+}
+
+// NewProviderWrapperWithProvider wraps already running provider, e.g. a fake provider in tests
+func NewProviderWrapperWithProvider(providerName string, provider providers.Interface, options ...map[string]int) *ProviderWrapper {
+	p := newProviderWrapper(providerName, options)
+	p.Provider = provider
+	return p
+}
+
+// NewSchemaProviderWrapper wraps only schema of provider, the plugin isn't started, so it can convert
+// states read earlier, e.g. from a snapshot, but it can't refresh them
+func NewSchemaProviderWrapper(providerName string, schema *ProviderSchema) *ProviderWrapper {
+	p := newProviderWrapper(providerName, nil)
+	p.schema = schema
+	return p
+}
+
+func newProviderWrapper(providerName string, options []map[string]int) *ProviderWrapper {
 	p := &ProviderWrapper{retryCount: 5, retrySleepMs: 300}
 	p.providerName = providerName
-	p.config = providerConfig
 
 	if len(options) > 0 {
 		retryCount, hasOption := options[0]["retryCount"]
@@ -76,23 +129,49 @@ func NewProviderWrapper(providerName string, providerConfig cty.Value, verbose b
 		}
 	}
 
-	err := p.initProvider(verbose)
-
-	return p, err
+	return p
 }
 
 func (p *ProviderWrapper) Kill() {
+	if p.client == nil {
+		if p.Provider != nil {
+			_ = p.Provider.Close()
+		}
+		return
+	}
 	p.client.Kill()
 }
 
-func (p *ProviderWrapper) GetSchema() *providers.GetSchemaResponse {
+// GetSchema returns schema of provider, the schema is cached on disk by hash of the plugin binary
+func (p *ProviderWrapper) GetSchema() *ProviderSchema {
+	if p.schema == nil && p.pluginPath != "" {
+		if schema, ok := readCachedSchema(p.pluginPath); ok {
+			p.schema = schema
+			p.seedSchema()
+		}
+	}
 	if p.schema == nil {
 		r := p.Provider.GetSchema()
-		p.schema = &r
+		p.schema = &ProviderSchema{GetSchemaResponse: r}
+		if provider, ok := p.Provider.(nestedAttributesProvider); ok {
+			p.schema.NestedTypes = provider.NestedAttributeTypes()
+		}
+		if p.pluginPath != "" && !r.Diagnostics.HasErrors() {
+			if err := writeCachedSchema(p.pluginPath, p.schema); err != nil {
+				log.Printf("WARN: failed to cache schema of provider %s: %v", p.providerName, err)
+			}
+		}
 	}
 	return p.schema
 }
 
+// seedSchema passes schema read from the cache or a schema file to the provider
+func (p *ProviderWrapper) seedSchema() {
+	if provider, ok := p.Provider.(schemaSeededProvider); ok {
+		provider.SetSchema(p.schema.GetSchemaResponse, p.schema.NestedTypes)
+	}
+}
+
 func (p *ProviderWrapper) GetReadOnlyAttributes(resourceTypes []string) (map[string][]string, error) {
 	r := p.GetSchema()
 
@@ -104,6 +183,10 @@ func (p *ProviderWrapper) GetReadOnlyAttributes(resourceTypes []string) (map[str
 		if terraformerstring.ContainsString(resourceTypes, resourceName) {
 			readOnlyAttributes[resourceName] = append(readOnlyAttributes[resourceName], "^id$")
 			for k, v := range obj.Block.Attributes {
+				if nestedType, ok := r.NestedTypes[resourceName][k]; ok {
+					readOnlyAttributes[resourceName] = readNestedAttribute(k, &NestedAttribute{Attribute: *v, NestedType: nestedType}, readOnlyAttributes[resourceName])
+					continue
+				}
 				if !v.Optional && !v.Required {
 					if v.Type.IsListType() || v.Type.IsSetType() {
 						readOnlyAttributes[resourceName] = append(readOnlyAttributes[resourceName], "^"+k+"\\.(.*)")
@@ -112,23 +195,36 @@ func (p *ProviderWrapper) GetReadOnlyAttributes(resourceTypes []string) (map[str
 					}
 				}
 			}
-			readOnlyAttributes[resourceName] = p.readObjBlocks(obj.Block.BlockTypes, readOnlyAttributes[resourceName], "-1")
+			readOnlyAttributes[resourceName] = p.readObjBlocks(obj.Block.BlockTypes, readOnlyAttributes[resourceName], "-1", r.NestedTypes[resourceName], "")
 		}
 	}
 	return readOnlyAttributes, nil
 }
 
-func (p *ProviderWrapper) readObjBlocks(block map[string]*configschema.NestedBlock, readOnlyAttributes []string, parent string) []string {
+// readObjBlocks adds read-only patterns of attributes of blocks, nestedTypes are nested attribute types
+// of the resource type by path, path is the path of parent block in schema
+func (p *ProviderWrapper) readObjBlocks(block map[string]*configschema.NestedBlock, readOnlyAttributes []string, parent string, nestedTypes map[string]*NestedType, path string) []string {
 	for k, v := range block {
+		blockPrefix := k
+		if parent != "-1" {
+			blockPrefix = parent + "\\.[0-9]+\\." + k
+		}
 		if len(v.BlockTypes) > 0 {
-			if parent == "-1" {
-				readOnlyAttributes = p.readObjBlocks(v.BlockTypes, readOnlyAttributes, k)
-			} else {
-				readOnlyAttributes = p.readObjBlocks(v.BlockTypes, readOnlyAttributes, parent+"\\.[0-9]+\\."+k)
-			}
+			readOnlyAttributes = p.readObjBlocks(v.BlockTypes, readOnlyAttributes, blockPrefix, nestedTypes, path+k+".")
+		}
+		elementPrefix := blockPrefix + "\\.[0-9]+"
+		if v.Nesting == configschema.NestingMap {
+			elementPrefix = blockPrefix + "\\.[^.]+"
 		}
 		fieldCount := 0
 		for key, l := range v.Attributes {
+			if nestedType, ok := nestedTypes[path+k+"."+key]; ok {
+				if !l.Optional && !l.Required {
+					fieldCount++
+				}
+				readOnlyAttributes = readNestedAttribute(elementPrefix+"\\."+key, &NestedAttribute{Attribute: *l, NestedType: nestedType}, readOnlyAttributes)
+				continue
+			}
 			if !l.Optional && !l.Required {
 				fieldCount++
 				switch v.Nesting {
@@ -158,24 +254,88 @@ func (p *ProviderWrapper) readObjBlocks(block map[string]*configschema.NestedBlo
 	return readOnlyAttributes
 }
 
+// readNestedAttribute adds read-only patterns of nested attribute with flatmap path prefix
+func readNestedAttribute(prefix string, attribute *NestedAttribute, readOnlyAttributes []string) []string {
+	if !attribute.Optional && !attribute.Required {
+		return append(readOnlyAttributes, "^"+prefix+"($|\\.(.*))")
+	}
+	elementPrefix := prefix
+	if attribute.NestedType.Nesting != configschema.NestingSingle {
+		elementPrefix += "\\.[^.]+"
+	}
+	for key, l := range attribute.NestedType.Attributes {
+		if l.NestedType != nil {
+			readOnlyAttributes = readNestedAttribute(elementPrefix+"\\."+key, l, readOnlyAttributes)
+		} else if !l.Optional && !l.Required {
+			readOnlyAttributes = append(readOnlyAttributes, "^"+elementPrefix+"\\."+key+"($|\\.(.*))")
+		}
+	}
+	return readOnlyAttributes
+}
+
 func (p *ProviderWrapper) Refresh(info *terraform.InstanceInfo, state *terraform.InstanceState) (*terraform.InstanceState, error) {
+	newState, _, _, err := p.RefreshWithRetries(info, state)
+	return newState, err
+}
+
+// RefreshWithRetries reads resource like Refresh and returns its typed state value and number of failed reads
+func (p *ProviderWrapper) RefreshWithRetries(info *terraform.InstanceInfo, state *terraform.InstanceState) (*terraform.InstanceState, cty.Value, int, error) {
 	schema := p.GetSchema()
 	impliedType := schema.ResourceTypes[info.Type].Block.ImpliedType()
 	priorState, err := state.AttrsAsObjectValue(impliedType)
 	if err != nil {
-		return nil, err
+		return nil, cty.NilVal, 0, err
+	}
+	return p.readWithRetries(info, state.ID, priorState, []byte{}, 0)
+}
+
+// ImportWithRetries imports resource by its ID like `terraform import` and reads the imported state,
+// for resources known only by type and ID
+func (p *ProviderWrapper) ImportWithRetries(info *terraform.InstanceInfo, state *terraform.InstanceState) (*terraform.InstanceState, cty.Value, int, error) {
+	if _, ok := p.GetSchema().ResourceTypes[info.Type]; !ok {
+		return nil, cty.NilVal, 0, fmt.Errorf("resource type %s is not supported by provider %s", info.Type, p.providerName)
+	}
+	retries := 0
+	var importResponse providers.ImportResourceStateResponse
+	for i := 0; i < p.retryCount; i++ {
+		importResponse = p.Provider.ImportResourceState(providers.ImportResourceStateRequest{
+			TypeName: info.Type,
+			ID:       state.ID,
+		})
+		if !importResponse.Diagnostics.HasErrors() {
+			break
+		}
+		log.Println(importResponse.Diagnostics.Err())
+		log.Printf("WARN: Fail import resource from provider, wait %dms before retry\n", p.retrySleepMs)
+		retries++
+		time.Sleep(time.Duration(p.retrySleepMs) * time.Millisecond)
+	}
+	if importResponse.Diagnostics.HasErrors() {
+		return nil, cty.NilVal, retries, importResponse.Diagnostics.Err()
+	}
+	// importers may return related resources too, e.g. rules of a security group
+	for _, imported := range importResponse.ImportedResources {
+		if imported.TypeName == info.Type {
+			return p.readWithRetries(info, state.ID, imported.State, imported.Private, retries)
+		}
 	}
+	return nil, cty.NilVal, retries, errors.New("not able to import resource for a given ID")
+}
+
+func (p *ProviderWrapper) readWithRetries(info *terraform.InstanceInfo, id string, priorState cty.Value, private []byte, retries int) (*terraform.InstanceState, cty.Value, int, error) {
+	schema := p.GetSchema()
 	successReadResource := false
 	resp := providers.ReadResourceResponse{}
 	for i := 0; i < p.retryCount; i++ {
 		resp = p.Provider.ReadResource(providers.ReadResourceRequest{
 			TypeName:   info.Type,
 			PriorState: priorState,
-			Private:    []byte{},
+			Private:    private,
 		})
 		if resp.Diagnostics.HasErrors() {
 			log.Println(resp.Diagnostics.Err())
 			log.Printf("WARN: Fail read resource from provider, wait %dms before retry\n", p.retrySleepMs)
+			retries++
 			time.Sleep(time.Duration(p.retrySleepMs) * time.Millisecond)
 			continue
 		} else {
@@ -189,30 +349,53 @@ func (p *ProviderWrapper) Refresh(info *terraform.InstanceInfo, state *terraform
 		// retry with regular import command - without resource attributes
 		importResponse := p.Provider.ImportResourceState(providers.ImportResourceStateRequest{
 			TypeName: info.Type,
-			ID:       state.ID,
+			ID:       id,
 		})
 		if importResponse.Diagnostics.HasErrors() {
-			return nil, resp.Diagnostics.Err()
+			return nil, cty.NilVal, retries, resp.Diagnostics.Err()
 		}
 		if len(importResponse.ImportedResources) == 0 {
-			return nil, errors.New("not able to import resource for a given ID")
+			return nil, cty.NilVal, retries, errors.New("not able to import resource for a given ID")
 		}
-		return terraform.NewInstanceStateShimmedFromValue(importResponse.ImportedResources[0].State, int(schema.ResourceTypes[info.Type].Version)), nil
+		importedState := importResponse.ImportedResources[0].State
+		return terraform.NewInstanceStateShimmedFromValue(importedState, int(schema.ResourceTypes[info.Type].Version)), importedState, retries, nil
 	}
 
 	if resp.NewState.IsNull() {
 		msg := fmt.Sprintf("ERROR: Read resource response is null for resource %s", info.Id)
-		return nil, errors.New(msg)
+		return nil, cty.NilVal, retries, errors.New(msg)
 	}
 
-	return terraform.NewInstanceStateShimmedFromValue(resp.NewState, int(schema.ResourceTypes[info.Type].Version)), nil
+	return terraform.NewInstanceStateShimmedFromValue(resp.NewState, int(schema.ResourceTypes[info.Type].Version)), resp.NewState, retries, nil
 }
 
 func (p *ProviderWrapper) initProvider(verbose bool) error {
+	if err := p.startProvider(verbose); err != nil {
+		return err
+	}
+	if p.schema != nil {
+		p.seedSchema()
+	}
+
+	config, err := p.GetSchema().Provider.Block.CoerceValue(p.config)
+	if err != nil {
+		return err
+	}
+	p.Provider.Configure(providers.ConfigureRequest{
+		TerraformVersion: version.Version,
+		Config:           config,
+	})
+
+	return nil
+}
+
+// startProvider starts plugin of provider without configuring it
+func (p *ProviderWrapper) startProvider(verbose bool) error {
 	providerFilePath, err := getProviderFileName(p.providerName)
 	if err != nil {
 		return err
 	}
+	p.pluginPath = providerFilePath
 	options := hclog.LoggerOptions{
 		Name:   "plugin",
 		Level:  hclog.Error,
@@ -226,7 +409,7 @@ func (p *ProviderWrapper) initProvider(verbose bool) error {
 		&plugin.ClientConfig{
 			Cmd:              exec.Command(providerFilePath),
 			HandshakeConfig:  tfplugin.Handshake,
-			VersionedPlugins: tfplugin.VersionedPlugins,
+			VersionedPlugins: versionedPlugins,
 			Managed:          true,
 			Logger:           logger,
 			AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
@@ -241,16 +424,8 @@ func (p *ProviderWrapper) initProvider(verbose bool) error {
 		return err
 	}
 
-	p.Provider = raw.(*tfplugin.GRPCProvider)
-
-	config, err := p.GetSchema().Provider.Block.CoerceValue(p.config)
-	if err != nil {
-		return err
-	}
-	p.Provider.Configure(providers.ConfigureRequest{
-		TerraformVersion: version.Version,
-		Config:           config,
-	})
+	p.Provider = raw.(providers.Interface)
+	log.Printf("Provider %s uses plugin protocol %d", p.providerName, p.client.NegotiatedVersion())
 
 	return nil
 }
@@ -56,7 +56,7 @@ func TestIgnoredAttributes(t *testing.T) {
 	for key, tc := range testCases {
 		t.Run(key, func(t *testing.T) {
 			provider := ProviderWrapper{}
-			readOnlyAttributes := provider.readObjBlocks(tc.block, []string{}, key)
+			readOnlyAttributes := provider.readObjBlocks(tc.block, []string{}, key, nil, "")
 			for _, attr := range tc.ignoredAttributes {
 				if ignored := isAttributeIgnored(attr, readOnlyAttributes); !ignored {
 					t.Errorf("attribute \"%s\" was not ignored. Pattern list: %s", attr, readOnlyAttributes)
@@ -0,0 +1,283 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providerwrapper //nolint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ProviderSchemaJSON is provider schema in the format of `terraform providers schema -json`
+type ProviderSchemaJSON struct {
+	Provider          *SchemaJSON            `json:"provider,omitempty"`
+	ResourceSchemas   map[string]*SchemaJSON `json:"resource_schemas,omitempty"`
+	DataSourceSchemas map[string]*SchemaJSON `json:"data_source_schemas,omitempty"`
+}
+
+type SchemaJSON struct {
+	Version uint64     `json:"version"`
+	Block   *BlockJSON `json:"block,omitempty"`
+}
+
+type BlockJSON struct {
+	Attributes map[string]*AttributeJSON `json:"attributes,omitempty"`
+	BlockTypes map[string]*BlockTypeJSON `json:"block_types,omitempty"`
+}
+
+type AttributeJSON struct {
+	Type       cty.Type        `json:"type"`
+	NestedType *NestedTypeJSON `json:"nested_type,omitempty"`
+	Required   bool            `json:"required,omitempty"`
+	Optional   bool            `json:"optional,omitempty"`
+	Computed   bool            `json:"computed,omitempty"`
+	Sensitive  bool            `json:"sensitive,omitempty"`
+}
+
+// NestedTypeJSON is nested attribute type of protocol 6 providers, type of such attribute is derived from it
+type NestedTypeJSON struct {
+	Attributes  map[string]*AttributeJSON `json:"attributes,omitempty"`
+	NestingMode string                    `json:"nesting_mode,omitempty"`
+}
+
+type BlockTypeJSON struct {
+	NestingMode string     `json:"nesting_mode,omitempty"`
+	Block       *BlockJSON `json:"block,omitempty"`
+	MinItems    uint64     `json:"min_items,omitempty"`
+	MaxItems    uint64     `json:"max_items,omitempty"`
+}
+
+// ProviderSchema is schema of provider with nested attribute types of its resource types. configschema
+// of terraform 0.12 can't hold them, so they are kept aside for schemas of protocol 6 providers or from JSON.
+type ProviderSchema struct {
+	providers.GetSchemaResponse
+	// NestedTypes of resource types by path of attribute, names of blocks and of the attribute joined by dots
+	NestedTypes map[string]map[string]*NestedType
+}
+
+var nestingModes = map[configschema.NestingMode]string{
+	configschema.NestingSingle: "single",
+	configschema.NestingGroup:  "group",
+	configschema.NestingList:   "list",
+	configschema.NestingSet:    "set",
+	configschema.NestingMap:    "map",
+}
+
+// ReadSchemaJSON reads provider schema from output of `terraform providers schema -json`
+// or from a single provider schema. With the full output providerName selects the provider.
+func ReadSchemaJSON(r io.Reader, providerName string) (*ProviderSchema, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error parsing provider schema: %v", err)
+	}
+	schemaData, isFullOutput := raw["provider_schemas"]
+	if !isFullOutput {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		return unmarshalProviderSchema(data)
+	}
+	var providerSchemas map[string]json.RawMessage
+	if err := json.Unmarshal(schemaData, &providerSchemas); err != nil {
+		return nil, fmt.Errorf("error parsing provider schema: %v", err)
+	}
+	for name, data := range providerSchemas {
+		if name == providerName || strings.HasSuffix(name, "/"+providerName) {
+			return unmarshalProviderSchema(data)
+		}
+	}
+	return nil, fmt.Errorf("schema for provider %s not found", providerName)
+}
+
+// WriteSchemaJSON writes single provider schema in the format of `terraform providers schema -json`
+func WriteSchemaJSON(w io.Writer, schema *ProviderSchema) error {
+	s := ProviderSchemaJSON{
+		Provider:          marshalSchema(schema.Provider, nil),
+		ResourceSchemas:   map[string]*SchemaJSON{},
+		DataSourceSchemas: map[string]*SchemaJSON{},
+	}
+	for k, v := range schema.ResourceTypes {
+		s.ResourceSchemas[k] = marshalSchema(v, schema.NestedTypes[k])
+	}
+	for k, v := range schema.DataSources {
+		s.DataSourceSchemas[k] = marshalSchema(v, nil)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func unmarshalProviderSchema(data []byte) (*ProviderSchema, error) {
+	s := ProviderSchemaJSON{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error parsing provider schema: %v", err)
+	}
+	schema := &ProviderSchema{
+		GetSchemaResponse: providers.GetSchemaResponse{
+			Provider:      unmarshalSchema(s.Provider, nil),
+			ResourceTypes: map[string]providers.Schema{},
+			DataSources:   map[string]providers.Schema{},
+		},
+		NestedTypes: map[string]map[string]*NestedType{},
+	}
+	for k, v := range s.ResourceSchemas {
+		nestedTypes := map[string]*NestedType{}
+		schema.ResourceTypes[k] = unmarshalSchema(v, nestedTypes)
+		if len(nestedTypes) > 0 {
+			schema.NestedTypes[k] = nestedTypes
+		}
+	}
+	for k, v := range s.DataSourceSchemas {
+		schema.DataSources[k] = unmarshalSchema(v, nil)
+	}
+	return schema, nil
+}
+
+func marshalNestedType(nestedType *NestedType) *NestedTypeJSON {
+	n := &NestedTypeJSON{
+		Attributes:  map[string]*AttributeJSON{},
+		NestingMode: nestingModes[nestedType.Nesting],
+	}
+	for k, v := range nestedType.Attributes {
+		n.Attributes[k] = &AttributeJSON{
+			Type:      v.Type,
+			Required:  v.Required,
+			Optional:  v.Optional,
+			Computed:  v.Computed,
+			Sensitive: v.Sensitive,
+		}
+		if v.NestedType != nil {
+			n.Attributes[k].NestedType = marshalNestedType(v.NestedType)
+		}
+	}
+	return n
+}
+
+// unmarshalNestedAttribute returns attribute with its nested type, if any. Output of terraform has no type
+// of attributes with nested type, so it's derived from the nested type.
+func unmarshalNestedAttribute(attribute *AttributeJSON) *NestedAttribute {
+	a := &NestedAttribute{
+		Attribute: configschema.Attribute{
+			Type:      attribute.Type,
+			Required:  attribute.Required,
+			Optional:  attribute.Optional,
+			Computed:  attribute.Computed,
+			Sensitive: attribute.Sensitive,
+		},
+	}
+	if attribute.NestedType == nil {
+		return a
+	}
+	a.NestedType = &NestedType{
+		Attributes: map[string]*NestedAttribute{},
+		Nesting:    unmarshalNestingMode(attribute.NestedType.NestingMode),
+	}
+	for k, v := range attribute.NestedType.Attributes {
+		a.NestedType.Attributes[k] = unmarshalNestedAttribute(v)
+	}
+	a.Type = nestedAttributeType(a.NestedType)
+	return a
+}
+
+func unmarshalNestingMode(mode string) configschema.NestingMode {
+	for nesting, name := range nestingModes {
+		if name == mode {
+			return nesting
+		}
+	}
+	return configschema.NestingSingle
+}
+
+// marshalSchema converts schema to JSON, nestedTypes are nested types of its attributes by path
+func marshalSchema(schema providers.Schema, nestedTypes map[string]*NestedType) *SchemaJSON {
+	return &SchemaJSON{
+		Version: uint64(schema.Version),
+		Block:   marshalBlock(schema.Block, nestedTypes, ""),
+	}
+}
+
+func marshalBlock(block *configschema.Block, nestedTypes map[string]*NestedType, path string) *BlockJSON {
+	if block == nil {
+		return &BlockJSON{}
+	}
+	b := &BlockJSON{
+		Attributes: map[string]*AttributeJSON{},
+		BlockTypes: map[string]*BlockTypeJSON{},
+	}
+	for k, v := range block.Attributes {
+		b.Attributes[k] = &AttributeJSON{
+			Type:      v.Type,
+			Required:  v.Required,
+			Optional:  v.Optional,
+			Computed:  v.Computed,
+			Sensitive: v.Sensitive,
+		}
+		if nestedType, exist := nestedTypes[path+k]; exist {
+			b.Attributes[k].NestedType = marshalNestedType(nestedType)
+		}
+	}
+	for k, v := range block.BlockTypes {
+		b.BlockTypes[k] = &BlockTypeJSON{
+			NestingMode: nestingModes[v.Nesting],
+			Block:       marshalBlock(&v.Block, nestedTypes, path+k+"."),
+			MinItems:    uint64(v.MinItems),
+			MaxItems:    uint64(v.MaxItems),
+		}
+	}
+	return b
+}
+
+// unmarshalSchema converts schema from JSON, nested types of its attributes are added to nestedTypes by path
+func unmarshalSchema(schema *SchemaJSON, nestedTypes map[string]*NestedType) providers.Schema {
+	if schema == nil {
+		return providers.Schema{Block: &configschema.Block{}}
+	}
+	return providers.Schema{
+		Version: int64(schema.Version),
+		Block:   unmarshalBlock(schema.Block, nestedTypes, ""),
+	}
+}
+
+func unmarshalBlock(block *BlockJSON, nestedTypes map[string]*NestedType, path string) *configschema.Block {
+	b := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{},
+		BlockTypes: map[string]*configschema.NestedBlock{},
+	}
+	if block == nil {
+		return b
+	}
+	for k, v := range block.Attributes {
+		attribute := unmarshalNestedAttribute(v)
+		b.Attributes[k] = &attribute.Attribute
+		if attribute.NestedType != nil && nestedTypes != nil {
+			nestedTypes[path+k] = attribute.NestedType
+		}
+	}
+	for k, v := range block.BlockTypes {
+		b.BlockTypes[k] = &configschema.NestedBlock{
+			Block:    *unmarshalBlock(v.Block, nestedTypes, path+k+"."),
+			Nesting:  unmarshalNestingMode(v.NestingMode),
+			MinItems: int(v.MinItems),
+			MaxItems: int(v.MaxItems),
+		}
+	}
+	return b
+}
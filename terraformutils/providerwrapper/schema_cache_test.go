@@ -0,0 +1,91 @@
+package providerwrapper //nolint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+	previous, isSet := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if isSet {
+			_ = os.Setenv(key, previous)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+func TestSchemaCache(t *testing.T) {
+	dataDir := t.TempDir()
+	setenv(t, "HOME", t.TempDir())
+	setenv(t, "TF_DATA_DIR", dataDir)
+	setenv(t, SchemaCacheDirEnv, t.TempDir())
+
+	pluginDir := filepath.Join(dataDir, "plugins", runtime.GOOS+"_"+runtime.GOARCH)
+	if err := os.MkdirAll(pluginDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	// not an executable, LoadSchema fails if it starts the plugin
+	pluginPath := filepath.Join(pluginDir, "terraform-provider-test_v1.0.0")
+	if err := ioutil.WriteFile(pluginPath, []byte("plugin v1.0.0"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := readCachedSchema(pluginPath); ok {
+		t.Fatal("unexpected cached schema")
+	}
+	if _, err := LoadSchema("test", "", false); err == nil {
+		t.Fatal("expected error starting plugin without cached schema")
+	}
+
+	schema := &ProviderSchema{
+		GetSchemaResponse: providers.GetSchemaResponse{
+			Provider: providers.Schema{Block: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{},
+				BlockTypes: map[string]*configschema.NestedBlock{},
+			}},
+			ResourceTypes: map[string]providers.Schema{
+				"test_server": {Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"name": {Type: cty.String, Required: true},
+						"port": {Type: cty.Number, Optional: true},
+					},
+					BlockTypes: map[string]*configschema.NestedBlock{},
+				}},
+			},
+			DataSources: map[string]providers.Schema{},
+		},
+		NestedTypes: map[string]map[string]*NestedType{},
+	}
+	if err := writeCachedSchema(pluginPath, schema); err != nil {
+		t.Fatal(err)
+	}
+	result, err := LoadSchema("test", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, schema) {
+		t.Errorf("failed to read cached schema, got %#v", result)
+	}
+
+	// upgraded plugin binary has a different hash
+	if err := ioutil.WriteFile(pluginPath, []byte("plugin v1.10.0"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readCachedSchema(pluginPath); ok {
+		t.Error("unexpected cached schema of other plugin binary")
+	}
+}
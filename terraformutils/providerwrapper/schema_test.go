@@ -0,0 +1,153 @@
+package providerwrapper //nolint
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSchemaJSONRoundTrip(t *testing.T) {
+	schema := &ProviderSchema{
+		GetSchemaResponse: providers.GetSchemaResponse{
+			Provider: providers.Schema{Block: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"region": {Type: cty.String, Required: true},
+				},
+				BlockTypes: map[string]*configschema.NestedBlock{},
+			}},
+			ResourceTypes: map[string]providers.Schema{
+				"aws_vpc": {Version: 1, Block: &configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id":   {Type: cty.String, Optional: true, Computed: true},
+						"tags": {Type: cty.Map(cty.String), Optional: true},
+					},
+					BlockTypes: map[string]*configschema.NestedBlock{
+						"timeouts": {Nesting: configschema.NestingSingle, Block: configschema.Block{
+							Attributes: map[string]*configschema.Attribute{
+								"create": {Type: cty.String, Optional: true},
+							},
+							BlockTypes: map[string]*configschema.NestedBlock{},
+						}},
+					},
+				}},
+			},
+			DataSources: map[string]providers.Schema{},
+		},
+		NestedTypes: map[string]map[string]*NestedType{},
+	}
+	var buf bytes.Buffer
+	if err := WriteSchemaJSON(&buf, schema); err != nil {
+		t.Fatal(err)
+	}
+	result, err := ReadSchemaJSON(&buf, "aws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, schema) {
+		t.Errorf("schema changed after round trip, got %#v", result)
+	}
+}
+
+func TestReadTerraformProvidersSchemaJSON(t *testing.T) {
+	data := `{"format_version": "0.1", "provider_schemas": {"registry.terraform.io/hashicorp/aws": {
+		"resource_schemas": {"aws_vpc": {"version": 1, "block": {"attributes": {"cidr_block": {"type": "string", "optional": true}}}}}
+	}}}`
+	schema, err := ReadSchemaJSON(strings.NewReader(data), "aws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.ResourceTypes["aws_vpc"].Block.Attributes["cidr_block"].Type != cty.String {
+		t.Errorf("failed to read schema, got %#v", schema.ResourceTypes)
+	}
+}
+
+func TestReadNestedAttributesFromSchemaJSON(t *testing.T) {
+	data := `{"format_version": "1.0", "provider_schemas": {"registry.terraform.io/example/example": {
+		"resource_schemas": {"example_policy": {"version": 0, "block": {"attributes": {
+			"name": {"type": "string", "required": true},
+			"rules": {"nested_type": {"nesting_mode": "list", "attributes": {
+				"id": {"type": "string", "computed": true},
+				"action": {"type": "string", "optional": true}
+			}}, "optional": true}
+		}}}}
+	}}}`
+	schema, err := ReadSchemaJSON(strings.NewReader(data), "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedType := cty.List(cty.Object(map[string]cty.Type{"id": cty.String, "action": cty.String}))
+	if !schema.ResourceTypes["example_policy"].Block.Attributes["rules"].Type.Equals(expectedType) {
+		t.Errorf("unexpected type %#v", schema.ResourceTypes["example_policy"].Block.Attributes["rules"].Type)
+	}
+
+	// nested types are written with the schema, e.g. to the schema cache
+	var buf bytes.Buffer
+	if err := WriteSchemaJSON(&buf, schema); err != nil {
+		t.Fatal(err)
+	}
+	schema, err = ReadSchemaJSON(&buf, "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// read-only nested attributes are known without the plugin
+	readOnlyAttributes, err := NewSchemaProviderWrapper("example", schema).GetReadOnlyAttributes([]string{"example_policy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isAttributeIgnored("rules.0.id", readOnlyAttributes["example_policy"]) {
+		t.Errorf("read-only nested attribute isn't ignored, patterns: %s", readOnlyAttributes["example_policy"])
+	}
+	if isAttributeIgnored("rules.0.action", readOnlyAttributes["example_policy"]) {
+		t.Errorf("optional nested attribute is ignored, patterns: %s", readOnlyAttributes["example_policy"])
+	}
+}
+
+func TestReadNestedAttributesOfBlocksFromSchemaJSON(t *testing.T) {
+	data := `{"format_version": "1.0", "provider_schemas": {"registry.terraform.io/example/example": {
+		"resource_schemas": {"example_server": {"version": 0, "block": {
+			"attributes": {"name": {"type": "string", "required": true}},
+			"block_types": {"network": {"nesting_mode": "list", "block": {"attributes": {
+				"addresses": {"nested_type": {"nesting_mode": "list", "attributes": {
+					"ip": {"type": "string", "computed": true},
+					"label": {"type": "string", "optional": true}
+				}}, "optional": true}
+			}}}}
+		}}}
+	}}}`
+	schema, err := ReadSchemaJSON(strings.NewReader(data), "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schema.NestedTypes["example_server"]["network.addresses"]; !ok {
+		t.Fatalf("nested attribute of block isn't read, got %#v", schema.NestedTypes)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSchemaJSON(&buf, schema); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"nested_type"`) {
+		t.Errorf("nested attribute of block isn't written:\n%s", buf.String())
+	}
+	schema, err = ReadSchemaJSON(&buf, "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readOnlyAttributes, err := NewSchemaProviderWrapper("example", schema).GetReadOnlyAttributes([]string{"example_server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isAttributeIgnored("network.0.addresses.0.ip", readOnlyAttributes["example_server"]) {
+		t.Errorf("read-only nested attribute of block isn't ignored, patterns: %s", readOnlyAttributes["example_server"])
+	}
+	if isAttributeIgnored("network.0.addresses.0.label", readOnlyAttributes["example_server"]) {
+		t.Errorf("optional nested attribute of block is ignored, patterns: %s", readOnlyAttributes["example_server"])
+	}
+}
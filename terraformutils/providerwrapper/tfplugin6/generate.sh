@@ -0,0 +1,14 @@
+#!/bin/bash
+
+# tfplugin6.proto is a copy of Terraform plugin protocol 6.0 definition.
+# Run this script to regenerate Go stubs after updating the copy.
+
+set -eu
+
+SOURCE="${BASH_SOURCE[0]}"
+while [ -h "$SOURCE" ] ; do SOURCE="$(readlink "$SOURCE")"; done
+DIR="$( cd -P "$( dirname "$SOURCE" )" && pwd )"
+
+cd "$DIR"
+
+protoc --proto_path=. --go_out=. --go_opt=paths=source_relative  --go-grpc_out=. --go-grpc_opt=paths=source_relative tfplugin6.proto
@@ -38,6 +38,19 @@ type Resource struct {
 	AdditionalFields  map[string]interface{} `json:",omitempty"`
 	SlowQueryRequired bool
 	DataFiles         map[string][]byte
+	CollapsedName     string   `json:",omitempty"`
+	CollapsedKeys     []string `json:",omitempty"`
+	// StateValue is the typed state read by refresh, Item is converted from it rather than flatmap attributes
+	StateValue cty.Value `json:"-"`
+	// ImportRequired resources are imported by ID through the provider before they are read,
+	// for resources known only by type and ID
+	ImportRequired bool `json:",omitempty"`
+	// Metadata from the provider API, like creation time, creator or console URL, is written by --annotate
+	Metadata map[string]string `json:",omitempty"`
+	// Comments are printed above the resource block
+	Comments []string `json:"-"`
+	// ProviderAlias selects aliased configuration of provider, like aws.eu_west_1, for the resource
+	ProviderAlias string `json:",omitempty"`
 }
 
 type ApplicableFilter interface {
@@ -123,14 +136,39 @@ func NewSimpleResource(id, resourceName, resourceType, provider string, allowEmp
 }
 
 func (r *Resource) Refresh(provider *providerwrapper.ProviderWrapper) {
+	if _, err := r.refresh(provider); err != nil {
+		log.Println(err)
+	}
+}
+
+func (r *Resource) refresh(provider *providerwrapper.ProviderWrapper) (int, error) {
 	var err error
+	var retries int
 	if r.SlowQueryRequired {
 		time.Sleep(200 * time.Millisecond)
 	}
-	r.InstanceState, err = provider.Refresh(r.InstanceInfo, r.InstanceState)
-	if err != nil {
-		log.Println(err)
+	if r.ImportRequired {
+		r.InstanceState, r.StateValue, retries, err = provider.ImportWithRetries(r.InstanceInfo, r.InstanceState)
+	} else {
+		r.InstanceState, r.StateValue, retries, err = provider.RefreshWithRetries(r.InstanceInfo, r.InstanceState)
 	}
+	return retries, err
+}
+
+// Address returns resource address, for collapsed resources it points to the for_each instance
+func (r Resource) Address() string {
+	if r.CollapsedName != "" {
+		return fmt.Sprintf("%s.%s[\"%s\"]", r.InstanceInfo.Type, r.CollapsedName, r.ResourceName)
+	}
+	return r.InstanceInfo.Type + "." + r.ResourceName
+}
+
+// ProviderAddress returns reference of provider configuration of the resource, like aws or aws.eu_west_1
+func (r Resource) ProviderAddress() string {
+	if r.ProviderAlias == "" {
+		return r.Provider
+	}
+	return r.Provider + "." + r.ProviderAlias
 }
 
 func (r Resource) GetIDKey() string {
@@ -146,15 +184,15 @@ func (r *Resource) ParseTFstate(parser Flatmapper, impliedType cty.Type) error {
 		return err
 	}
 
+	if attributes == nil {
+		attributes = map[string]interface{}{} // ensure HCL can represent empty resource correctly
+	}
+
 	// add Additional Fields to resource
 	for key, value := range r.AdditionalFields {
 		attributes[key] = value
 	}
 
-	if attributes == nil {
-		attributes = map[string]interface{}{} // ensure HCL can represent empty resource correctly
-	}
-
 	r.Item = attributes
 	return nil
 }
@@ -170,10 +208,13 @@ func (r *Resource) ConvertTFstate(provider *providerwrapper.ProviderWrapper) err
 			allowEmptyValues = append(allowEmptyValues, regexp.MustCompile(pattern))
 		}
 	}
-	parser := NewFlatmapParser(r.InstanceState.Attributes, ignoreKeys, allowEmptyValues)
 	schema := provider.GetSchema()
 	impliedType := schema.ResourceTypes[r.InstanceInfo.Type].Block.ImpliedType()
-	return r.ParseTFstate(parser, impliedType)
+	if r.StateValue != cty.NilVal && !r.StateValue.IsNull() {
+		return r.ParseTFstate(NewValueParser(r.StateValue, ignoreKeys, allowEmptyValues), impliedType)
+	}
+	// resources which weren't refreshed have only flatmap attributes
+	return r.ParseTFstate(NewFlatmapParser(r.InstanceState.Attributes, ignoreKeys, allowEmptyValues), impliedType)
 }
 
 func (r *Resource) ServiceName() string {
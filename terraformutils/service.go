@@ -27,6 +27,7 @@ type ServiceGenerator interface {
 	SetResources(resources []Resource)
 	ParseFilter(rawFilter string) []ResourceFilter
 	ParseFilters(rawFilters []string)
+	AddFilters(filters []ResourceFilter)
 	PostConvertHook() error
 	GetArgs() map[string]interface{}
 	SetArgs(args map[string]interface{})
@@ -69,6 +70,11 @@ func (s *Service) ParseFilters(rawFilters []string) {
 	}
 }
 
+// AddFilters adds filters built by terraformer, like filters of IDs of --ids-from, to parsed filters
+func (s *Service) AddFilters(filters []ResourceFilter) {
+	s.Filter = append(s.Filter, filters...)
+}
+
 func (s *Service) ParseFilter(rawFilter string) []ResourceFilter {
 	var filters []ResourceFilter
 	if !strings.HasPrefix(rawFilter, "Name=") && len(strings.Split(rawFilter, "=")) == 2 {
@@ -0,0 +1,63 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"encoding/json"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// SnapshotResource is a refreshed resource with its typed state, which isn't part of JSON of Resource,
+// so conversion of the snapshot gives the same result as conversion right after refresh
+type SnapshotResource struct {
+	Resource
+	ValueType json.RawMessage `json:",omitempty"`
+	Value     json.RawMessage `json:",omitempty"`
+}
+
+func NewSnapshotResource(r Resource) (SnapshotResource, error) {
+	snapshot := SnapshotResource{Resource: r}
+	if r.StateValue == cty.NilVal || r.StateValue.IsNull() {
+		// resources which weren't refreshed have only flatmap attributes
+		return snapshot, nil
+	}
+	value, _ := r.StateValue.UnmarkDeep()
+	var err error
+	if snapshot.ValueType, err = ctyjson.MarshalType(value.Type()); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Value, err = ctyjson.Marshal(value, value.Type()); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+// GetResource returns the resource with its state value
+func (r SnapshotResource) GetResource() (Resource, error) {
+	resource := r.Resource
+	if len(r.Value) == 0 {
+		return resource, nil
+	}
+	ty, err := ctyjson.UnmarshalType(r.ValueType)
+	if err != nil {
+		return resource, err
+	}
+	if resource.StateValue, err = ctyjson.Unmarshal(r.Value, ty); err != nil {
+		return resource, err
+	}
+	return resource, nil
+}
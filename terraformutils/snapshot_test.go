@@ -0,0 +1,64 @@
+package terraformutils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSnapshotResourceKeepsStateValue(t *testing.T) {
+	r := NewSimpleResource("events@prod", "queue_prod_events", "rabbitmq_queue", "rabbitmq", nil)
+	r.StateValue = cty.ObjectVal(map[string]cty.Value{
+		"name":  cty.StringVal("events"),
+		"vhost": cty.StringVal("prod"),
+		"tags":  cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+		"settings": cty.ObjectVal(map[string]cty.Value{
+			"durable":   cty.True,
+			"arguments": cty.MapVal(map[string]cty.Value{"x-max-length": cty.StringVal("10")}),
+		}),
+		"comment": cty.NullVal(cty.String),
+	})
+
+	snapshotResource, err := NewSnapshotResource(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(snapshotResource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var read SnapshotResource
+	if err := json.Unmarshal(data, &read); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := read.GetResource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.StateValue.RawEquals(r.StateValue) {
+		t.Errorf("unexpected state value %#v", restored.StateValue)
+	}
+	if restored.InstanceState.ID != "events@prod" || restored.ResourceName != "tfer--queue_prod_events" {
+		t.Errorf("unexpected resource %+v", restored)
+	}
+}
+
+func TestSnapshotResourceWithoutStateValue(t *testing.T) {
+	r := NewResource("prod", "vhost_prod", "rabbitmq_vhost", "rabbitmq", map[string]string{"name": "prod"}, nil, nil)
+
+	snapshotResource, err := NewSnapshotResource(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshotResource.Value) != 0 {
+		t.Errorf("unexpected value of resource which wasn't refreshed: %s", snapshotResource.Value)
+	}
+	restored, err := snapshotResource.GetResource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.StateValue != cty.NilVal || restored.InstanceState.Attributes["name"] != "prod" {
+		t.Errorf("unexpected resource %+v", restored)
+	}
+}
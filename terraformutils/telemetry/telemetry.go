@@ -0,0 +1,208 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry records OpenTelemetry spans and metrics of import runs.
+// Without Setup, spans and metrics are not recorded.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/unit"
+	exportmetric "go.opentelemetry.io/otel/sdk/export/metric"
+	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
+	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
+	"go.opentelemetry.io/otel/sdk/metric/selector/simple"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/GoogleCloudPlatform/terraformer"
+
+// Attribute keys of spans and metrics
+const (
+	ProviderKey = attribute.Key("terraformer.provider")
+	ServiceKey  = attribute.Key("terraformer.service")
+	TypeKey     = attribute.Key("terraformer.resource.type")
+	IDKey       = attribute.Key("terraformer.resource.id")
+)
+
+type instruments struct {
+	resourcesListed metric.Int64Counter
+	serviceFailures metric.Int64Counter
+	refreshDuration metric.Float64Histogram
+	refreshRetries  metric.Int64Counter
+	refreshFailures metric.Int64Counter
+}
+
+var (
+	mu      sync.RWMutex
+	current *instruments
+)
+
+// Setup starts exporting spans and metrics with otlp (configured by OTEL_EXPORTER_OTLP_* environment)
+// or json to a file, the returned function flushes and stops exporting.
+func Setup(ctx context.Context, exporter, path, version string) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var metricExporter exportmetric.Exporter
+	var file *os.File
+	switch exporter {
+	case "otlp":
+		otlpSpanExporter, err := otlptracegrpc.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		otlpMetricExporter, err := otlpmetricgrpc.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		spanExporter, metricExporter = otlpSpanExporter, otlpMetricExporter
+	case "json":
+		var err error
+		file, err = os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		// spans and metrics are written as json lines
+		jsonSpanExporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			return nil, err
+		}
+		jsonMetricExporter, err := stdoutmetric.New(stdoutmetric.WithWriter(file))
+		if err != nil {
+			return nil, err
+		}
+		spanExporter, metricExporter = jsonSpanExporter, jsonMetricExporter
+	default:
+		return nil, fmt.Errorf("unsupported telemetry exporter %s", exporter)
+	}
+
+	res := resource.NewWithAttributes("",
+		attribute.String("service.name", "terraformer"),
+		attribute.String("service.version", version),
+	)
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	metricController := controller.New(
+		processor.NewFactory(simple.NewWithHistogramDistribution(), metricExporter),
+		controller.WithExporter(metricExporter),
+		controller.WithResource(res),
+	)
+	if err := metricController.Start(ctx); err != nil {
+		return nil, err
+	}
+	meter := metric.Must(metricController.Meter(instrumentationName))
+	otel.SetTracerProvider(tracerProvider)
+	mu.Lock()
+	current = &instruments{
+		resourcesListed: meter.NewInt64Counter("terraformer.resources.listed",
+			metric.WithDescription("resources listed by InitResources")),
+		serviceFailures: meter.NewInt64Counter("terraformer.service.failures",
+			metric.WithDescription("services failed to list resources")),
+		refreshDuration: meter.NewFloat64Histogram("terraformer.refresh.duration",
+			metric.WithDescription("duration of resource refresh including retries"), metric.WithUnit(unit.Milliseconds)),
+		refreshRetries: meter.NewInt64Counter("terraformer.refresh.retries",
+			metric.WithDescription("retries of failed resource reads")),
+		refreshFailures: meter.NewInt64Counter("terraformer.refresh.failures",
+			metric.WithDescription("resources failed to refresh")),
+	}
+	mu.Unlock()
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		current = nil
+		mu.Unlock()
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		err := tracerProvider.Shutdown(ctx)
+		if stopErr := metricController.Stop(ctx); err == nil {
+			err = stopErr
+		}
+		if file != nil {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span of the global tracer provider
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End ends span, marking it failed if err is set
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func instrumentsOrNil() *instruments {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// RecordListed counts resources listed in a service or a failed service
+func RecordListed(ctx context.Context, provider, service string, resources int, err error) {
+	i := instrumentsOrNil()
+	if i == nil {
+		return
+	}
+	attributes := []attribute.KeyValue{ProviderKey.String(provider), ServiceKey.String(service)}
+	if err != nil {
+		i.serviceFailures.Add(ctx, 1, attributes...)
+		return
+	}
+	i.resourcesListed.Add(ctx, int64(resources), attributes...)
+}
+
+// RecordRefresh records duration of a resource refresh and whether it failed
+func RecordRefresh(ctx context.Context, resourceType string, duration time.Duration, failed bool) {
+	i := instrumentsOrNil()
+	if i == nil {
+		return
+	}
+	i.refreshDuration.Record(ctx, float64(duration)/float64(time.Millisecond), TypeKey.String(resourceType))
+	if failed {
+		i.refreshFailures.Add(ctx, 1, TypeKey.String(resourceType))
+	}
+}
+
+// RecordRetries counts retries of failed resource reads
+func RecordRetries(ctx context.Context, resourceType string, retries int) {
+	i := instrumentsOrNil()
+	if i == nil || retries == 0 {
+		return
+	}
+	i.refreshRetries.Add(ctx, int64(retries), TypeKey.String(resourceType))
+}
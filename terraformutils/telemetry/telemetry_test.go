@@ -0,0 +1,89 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetupJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.json")
+	shutdown, err := Setup(context.Background(), "json", path, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, span := Start(context.Background(), "import", ProviderKey.String("rabbitmq"))
+	_, listSpan := Start(ctx, "list vhosts", ServiceKey.String("vhosts"))
+	RecordListed(ctx, "rabbitmq", "vhosts", 2, nil)
+	RecordListed(ctx, "rabbitmq", "queues", 0, errors.New("failed"))
+	End(listSpan, errors.New("failed"))
+	RecordRefresh(ctx, "rabbitmq_vhost", 10*time.Millisecond, false)
+	RecordRefresh(ctx, "rabbitmq_vhost", 20*time.Millisecond, true)
+	RecordRetries(ctx, "rabbitmq_vhost", 2)
+	End(span, nil)
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	spans := map[string]string{}
+	metrics := map[string]bool{}
+	decoder := json.NewDecoder(f)
+	for {
+		var value interface{}
+		if err := decoder.Decode(&value); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			status := value["Status"].(map[string]interface{})
+			spans[value["Name"].(string)] = status["Code"].(string)
+		case []interface{}:
+			for _, line := range value {
+				name := line.(map[string]interface{})["Name"].(string)
+				metrics[name[:strings.Index(name, "{")]] = true
+			}
+		}
+	}
+	expectedSpans := map[string]string{"import": "Unset", "list vhosts": "Error"}
+	for name, code := range expectedSpans {
+		if spans[name] != code {
+			t.Errorf("expected span %s with status %s, got %v", name, code, spans)
+		}
+	}
+	for _, name := range []string{"terraformer.resources.listed", "terraformer.service.failures",
+		"terraformer.refresh.duration", "terraformer.refresh.failures", "terraformer.refresh.retries"} {
+		if !metrics[name] {
+			t.Errorf("missing metric %s, got %v", name, metrics)
+		}
+	}
+}
+
+func TestWithoutSetup(t *testing.T) {
+	// spans and metrics are dropped
+	ctx, span := Start(context.Background(), "import")
+	RecordListed(ctx, "rabbitmq", "vhosts", 2, nil)
+	RecordRetries(ctx, "rabbitmq_vhost", 2)
+	End(span, errors.New("failed"))
+	if span.SpanContext().IsValid() {
+		t.Errorf("expected span not to be recorded")
+	}
+}
+
+func TestSetupUnsupportedExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), "zipkin", "", "test"); err == nil {
+		t.Errorf("expected error for unsupported exporter")
+	}
+}
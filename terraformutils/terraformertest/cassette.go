@@ -0,0 +1,108 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformertest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cassette holds HTTP interactions recorded from a real API
+type Cassette struct {
+	Interactions []*Interaction
+
+	path string
+	mu   sync.Mutex
+	used map[*Interaction]bool
+}
+
+type Interaction struct {
+	Request  CassetteRequest
+	Response CassetteResponse
+}
+
+type CassetteRequest struct {
+	Method string
+	URL    string
+	Body   string `json:",omitempty"`
+}
+
+type CassetteResponse struct {
+	StatusCode int
+	Header     http.Header `json:",omitempty"`
+	Body       string
+}
+
+// LoadCassette reads cassette from path, missing file results in an empty cassette
+func LoadCassette(path string) (*Cassette, error) {
+	c := &Cassette{path: path, used: map[*Interaction]bool{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(c.path), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(data, '\n'), 0600)
+}
+
+func (c *Cassette) Add(request CassetteRequest, response CassetteResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, &Interaction{Request: request, Response: response})
+}
+
+// Match returns recorded response for a request. Interactions are replayed in recorded order,
+// so paginated APIs get the same sequence of pages. Once all matching interactions
+// were replayed the last one is returned again.
+func (c *Cassette) Match(request CassetteRequest) (*CassetteResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var last *Interaction
+	for _, interaction := range c.Interactions {
+		if interaction.Request != request {
+			continue
+		}
+		if !c.used[interaction] {
+			c.used[interaction] = true
+			return &interaction.Response, true
+		}
+		last = interaction
+	}
+	if last == nil {
+		return nil, false
+	}
+	return &last.Response, true
+}
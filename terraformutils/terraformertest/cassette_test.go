@@ -0,0 +1,46 @@
+package terraformertest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestCassetteReplaysInRecordedOrder(t *testing.T) {
+	cassette := &Cassette{used: map[*Interaction]bool{}}
+	request := CassetteRequest{Method: "GET", URL: "/items?page=next"}
+	cassette.Add(request, CassetteResponse{StatusCode: 200, Body: "first"})
+	cassette.Add(request, CassetteResponse{StatusCode: 200, Body: "second"})
+
+	for _, expected := range []string{"first", "second", "second"} {
+		response, ok := cassette.Match(request)
+		if !ok || response.Body != expected {
+			t.Errorf("expected %s, got %v", expected, response)
+		}
+	}
+	if _, ok := cassette.Match(CassetteRequest{Method: "GET", URL: "/other"}); ok {
+		t.Error("unexpected match for unknown request")
+	}
+}
+
+func TestServerReplaysServerURL(t *testing.T) {
+	server := NewServer(t, "testdata/missing.json", "")
+	server.Cassette.Add(CassetteRequest{Method: "GET", URL: "/items"}, CassetteResponse{
+		StatusCode: 200,
+		Header:     http.Header{"Link": []string{"<" + serverPlaceholder + "/items?page=2>; rel=\"next\""}},
+		Body:       "[]",
+	})
+
+	resp, err := http.Get(server.URL + "/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != "[]" {
+		t.Errorf("unexpected body %s", body)
+	}
+	if link := resp.Header.Get("Link"); link != "<"+server.URL+"/items?page=2>; rel=\"next\"" {
+		t.Errorf("unexpected link %s", link)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/hashicorp/terraform/configs/hcl2shim"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/hashicorp/terraform/tfdiags"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FakeProvider is a Terraform provider returning canned responses instead of calling a plugin
+type FakeProvider struct {
+	providers.Interface
+
+	Schema *providerwrapper.ProviderSchema
+	// refreshed resources in flatmap format by resource type and ID
+	Resources map[string]map[string]map[string]string
+
+	mu sync.Mutex
+}
+
+type fakeProviderData struct {
+	Resources map[string]map[string]map[string]string
+}
+
+// NewFakeProvider loads provider schema from schemaPath (`terraform providers schema -json` format)
+// and canned resources from resourcesPath recorded with RecordingProvider
+func NewFakeProvider(providerName, schemaPath, resourcesPath string) (*FakeProvider, error) {
+	f, err := os.Open(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	schema, err := providerwrapper.ReadSchemaJSON(f, providerName)
+	if err != nil {
+		return nil, err
+	}
+	p := &FakeProvider{
+		Schema:    schema,
+		Resources: map[string]map[string]map[string]string{},
+	}
+	if resourcesPath == "" {
+		return p, nil
+	}
+	data, err := ioutil.ReadFile(resourcesPath)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	resources := fakeProviderData{}
+	if err := json.Unmarshal(data, &resources); err != nil {
+		return nil, err
+	}
+	p.Resources = resources.Resources
+	return p, nil
+}
+
+// NewProviderWrapper returns ProviderWrapper replaying canned resources from resourcesPath.
+// If RecordEnv is set the real provider plugin is started with config instead,
+// and its schema and refreshed resources are saved when the test finishes.
+func NewProviderWrapper(t testing.TB, providerName string, config cty.Value, schemaPath, resourcesPath string) *providerwrapper.ProviderWrapper {
+	t.Helper()
+	if os.Getenv(RecordEnv) == "" {
+		fake, err := NewFakeProvider(providerName, schemaPath, resourcesPath)
+		if err != nil {
+			t.Fatalf("failed to load fake provider: %v", err)
+		}
+		return providerwrapper.NewProviderWrapperWithProvider(providerName, fake, map[string]int{"retryCount": 1, "retrySleepMs": 0})
+	}
+
+	realProviderWrapper, err := providerwrapper.NewProviderWrapper(providerName, config, false)
+	if err != nil {
+		t.Fatalf("failed to start provider %s: %v", providerName, err)
+	}
+	recorder := NewRecordingProvider(realProviderWrapper.Provider)
+	t.Cleanup(func() {
+		realProviderWrapper.Kill()
+		f, err := os.Create(schemaPath)
+		if err != nil {
+			t.Errorf("failed to save schema: %v", err)
+			return
+		}
+		defer f.Close()
+		if err := providerwrapper.WriteSchemaJSON(f, recorder.Fake.Schema); err != nil {
+			t.Errorf("failed to save schema: %v", err)
+		}
+		if err := recorder.Fake.Save(resourcesPath); err != nil {
+			t.Errorf("failed to save resources: %v", err)
+		}
+	})
+	return providerwrapper.NewProviderWrapperWithProvider(providerName, recorder)
+}
+
+func (p *FakeProvider) AddResource(resourceType, id string, attributes map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Resources[resourceType] == nil {
+		p.Resources[resourceType] = map[string]map[string]string{}
+	}
+	p.Resources[resourceType][id] = attributes
+}
+
+// Save writes canned resources, e.g. after recording them with RecordingProvider
+func (p *FakeProvider) Save(path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, err := json.MarshalIndent(fakeProviderData{Resources: p.Resources}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0600)
+}
+
+func (p *FakeProvider) GetSchema() providers.GetSchemaResponse {
+	return p.Schema.GetSchemaResponse
+}
+
+// NestedAttributeTypes returns nested attribute types of the schema, like protocol 6 providers do
+func (p *FakeProvider) NestedAttributeTypes() map[string]map[string]*providerwrapper.NestedType {
+	return p.Schema.NestedTypes
+}
+
+func (p *FakeProvider) Configure(providers.ConfigureRequest) providers.ConfigureResponse {
+	return providers.ConfigureResponse{}
+}
+
+func (p *FakeProvider) ReadResource(req providers.ReadResourceRequest) providers.ReadResourceResponse {
+	resp := providers.ReadResourceResponse{}
+	id := req.PriorState.GetAttr("id")
+	if id.IsNull() || !id.IsKnown() {
+		resp.Diagnostics = resp.Diagnostics.Append(fmt.Errorf("resource %s has no id", req.TypeName))
+		return resp
+	}
+	resp.NewState, resp.Diagnostics = p.state(req.TypeName, id.AsString())
+	return resp
+}
+
+func (p *FakeProvider) ImportResourceState(req providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	resp := providers.ImportResourceStateResponse{}
+	state, diags := p.state(req.TypeName, req.ID)
+	resp.Diagnostics = diags
+	if diags.HasErrors() || state.IsNull() {
+		return resp
+	}
+	resp.ImportedResources = []providers.ImportedResource{{
+		TypeName: req.TypeName,
+		State:    state,
+	}}
+	return resp
+}
+
+func (p *FakeProvider) Close() error {
+	return nil
+}
+
+func (p *FakeProvider) state(resourceType, id string) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	schema, ok := p.Schema.ResourceTypes[resourceType]
+	if !ok {
+		return cty.NilVal, diags.Append(fmt.Errorf("unknown resource type %s", resourceType))
+	}
+	impliedType := schema.Block.ImpliedType()
+	p.mu.Lock()
+	attributes, ok := p.Resources[resourceType][id]
+	p.mu.Unlock()
+	if !ok {
+		return cty.NullVal(impliedType), diags
+	}
+	state, err := hcl2shim.HCL2ValueFromFlatmap(attributes, impliedType)
+	if err != nil {
+		return cty.NilVal, diags.Append(err)
+	}
+	return state, diags
+}
+
+// RecordingProvider passes calls to a real provider and stores refreshed resources into FakeProvider
+type RecordingProvider struct {
+	providers.Interface
+	Fake *FakeProvider
+}
+
+func NewRecordingProvider(provider providers.Interface) *RecordingProvider {
+	schema := &providerwrapper.ProviderSchema{GetSchemaResponse: provider.GetSchema()}
+	if nestedProvider, ok := provider.(interface {
+		NestedAttributeTypes() map[string]map[string]*providerwrapper.NestedType
+	}); ok {
+		schema.NestedTypes = nestedProvider.NestedAttributeTypes()
+	}
+	return &RecordingProvider{
+		Interface: provider,
+		Fake: &FakeProvider{
+			Schema:    schema,
+			Resources: map[string]map[string]map[string]string{},
+		},
+	}
+}
+
+func (p *RecordingProvider) NestedAttributeTypes() map[string]map[string]*providerwrapper.NestedType {
+	return p.Fake.Schema.NestedTypes
+}
+
+func (p *RecordingProvider) ReadResource(req providers.ReadResourceRequest) providers.ReadResourceResponse {
+	resp := p.Interface.ReadResource(req)
+	if !resp.Diagnostics.HasErrors() {
+		p.record(req.TypeName, resp.NewState)
+	}
+	return resp
+}
+
+func (p *RecordingProvider) ImportResourceState(req providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	resp := p.Interface.ImportResourceState(req)
+	if !resp.Diagnostics.HasErrors() {
+		for _, imported := range resp.ImportedResources {
+			p.record(imported.TypeName, imported.State)
+		}
+	}
+	return resp
+}
+
+func (p *RecordingProvider) record(resourceType string, state cty.Value) {
+	if state.IsNull() {
+		return
+	}
+	schemaVersion := int(p.Fake.Schema.ResourceTypes[resourceType].Version)
+	instanceState := terraform.NewInstanceStateShimmedFromValue(state, schemaVersion)
+	p.Fake.AddResource(resourceType, instanceState.ID, instanceState.Attributes)
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformertest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+// RecordEnv enables recording of cassettes against real APIs instead of replaying them
+const RecordEnv = "TERRAFORMER_RECORD"
+
+// absolute upstream URLs in responses (e.g. pagination links) are stored relative to the replay server
+const serverPlaceholder = "{{server}}"
+
+// response headers worth keeping in cassettes, everything else may contain volatile or sensitive data
+var recordedHeaders = []string{"Content-Type", "Link", "X-Next-Page", "X-Total-Count"}
+
+// Server is a local HTTP server which replays cassette interactions.
+// In recording mode it proxies requests to upstream and records them into the cassette.
+type Server struct {
+	*httptest.Server
+	Cassette  *Cassette
+	upstream  *url.URL
+	recording bool
+	t         testing.TB
+}
+
+// NewServer starts a replay server for cassettePath. Point provider endpoint to server URL.
+// If RecordEnv is set requests are sent to upstream and the cassette is saved when the test finishes.
+func NewServer(t testing.TB, cassettePath, upstream string) *Server {
+	t.Helper()
+	cassette, err := LoadCassette(cassettePath)
+	if err != nil {
+		t.Fatalf("failed to load cassette %s: %v", cassettePath, err)
+	}
+	s := &Server{
+		Cassette:  cassette,
+		recording: os.Getenv(RecordEnv) != "",
+		t:         t,
+	}
+	if s.recording {
+		s.upstream, err = url.Parse(upstream)
+		if err != nil || upstream == "" {
+			t.Fatalf("recording requires upstream URL, got %q", upstream)
+		}
+		s.Cassette.Interactions = nil
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(func() {
+		s.Close()
+		if s.recording {
+			if err := s.Cassette.Save(); err != nil {
+				t.Errorf("failed to save cassette %s: %v", cassettePath, err)
+			}
+		}
+	})
+	return s
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	request := CassetteRequest{
+		Method: r.Method,
+		URL:    r.URL.RequestURI(),
+		Body:   string(body),
+	}
+	if s.recording {
+		response, err := s.forward(r, body)
+		if err != nil {
+			s.t.Errorf("failed to record %s %s: %v", request.Method, request.URL, err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		s.Cassette.Add(request, *response)
+		s.writeResponse(w, response)
+		return
+	}
+	response, ok := s.Cassette.Match(request)
+	if !ok {
+		s.t.Errorf("no recorded interaction for %s %s", request.Method, request.URL)
+		http.NotFound(w, r)
+		return
+	}
+	s.writeResponse(w, response)
+}
+
+func (s *Server) forward(r *http.Request, body []byte) (*CassetteResponse, error) {
+	target := *s.upstream
+	target.Path = s.upstream.Path + r.URL.Path
+	target.RawQuery = r.URL.RawQuery
+	req, err := http.NewRequest(r.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header.Clone()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	upstreamBase := strings.TrimSuffix(s.upstream.String(), "/")
+	header := http.Header{}
+	for _, k := range recordedHeaders {
+		for _, v := range resp.Header.Values(k) {
+			header.Add(k, strings.ReplaceAll(v, upstreamBase, serverPlaceholder))
+		}
+	}
+	return &CassetteResponse{
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       strings.ReplaceAll(string(respBody), upstreamBase, serverPlaceholder),
+	}, nil
+}
+
+func (s *Server) writeResponse(w http.ResponseWriter, response *CassetteResponse) {
+	for k, values := range response.Header {
+		for _, v := range values {
+			w.Header().Add(k, strings.ReplaceAll(v, serverPlaceholder, s.URL))
+		}
+	}
+	w.WriteHeader(response.StatusCode)
+	_, _ = w.Write([]byte(strings.ReplaceAll(response.Body, serverPlaceholder, s.URL)))
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformertest
+
+import (
+	"context"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+)
+
+// RunService lists resources of a single service and refreshes them through providerWrapper
+// the same way `terraformer import` does, without generating any files
+func RunService(provider terraformutils.ProviderGenerator, service string, args []string, filters []string,
+	providerWrapper *providerwrapper.ProviderWrapper) ([]terraformutils.Resource, error) {
+	providersMapping := terraformutils.NewProvidersMapping(provider)
+	serviceProvider := providersMapping.AddServiceToProvider(service)
+	if err := serviceProvider.Init(args); err != nil {
+		return nil, err
+	}
+	if err := serviceProvider.InitService(service, false); err != nil {
+		return nil, err
+	}
+	serviceProvider.GetService().ParseFilters(filters)
+	if err := serviceProvider.GetService().InitResources(); err != nil {
+		return nil, err
+	}
+	serviceProvider.GetService().PopulateIgnoreKeys(providerWrapper)
+	serviceProvider.GetService().InitialCleanup()
+	providersMapping.ProcessResources(false)
+
+	if err := terraformutils.RefreshResourcesByProvider(context.Background(), providersMapping, providerWrapper, nil); err != nil {
+		return nil, err
+	}
+	providersMapping.ConvertTFStates(providerWrapper)
+	providersMapping.CleanupProviders()
+	return providersMapping.GetResourcesByService()[service], nil
+}
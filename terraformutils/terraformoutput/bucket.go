@@ -15,10 +15,12 @@ package terraformoutput
 
 import (
 	"context"
+	"io/ioutil"
 	"log"
 	"strings"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
 )
 
 type BucketState struct {
@@ -62,3 +64,39 @@ func (b BucketState) BucketUpload(path string, file []byte) error {
 	}
 	return nil
 }
+
+// BucketDownloadStates returns content of all *.tfstate objects under given bucket prefix
+func (b BucketState) BucketDownloadStates(prefix string) ([][]byte, error) {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	name := strings.ReplaceAll(b.Name, "gs://", "")
+	var states [][]byte
+	it := client.Bucket(name).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasSuffix(attrs.Name, ".tfstate") {
+			continue
+		}
+		r, err := client.Bucket(name).Object(attrs.Name).NewReader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, data)
+	}
+	return states, nil
+}
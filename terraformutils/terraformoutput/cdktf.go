@@ -0,0 +1,463 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// cdktfModule is the module of classes which aren't generated from provider schema
+const cdktfModule = "cdktf"
+
+// CdktfStack is everything printed for one service (or for all services with compact path pattern)
+type CdktfStack struct {
+	ServiceName string
+	Provider    terraformutils.ProviderGenerator
+	Schema      *providerwrapper.ProviderSchema
+	Resources   []terraformutils.Resource
+	// DataSources are data source arguments by type and name, e.g. lookups of external references
+	DataSources map[string]map[string]interface{}
+	// RemoteStates are terraform_remote_state arguments (backend and config) by name
+	RemoteStates map[string]map[string]interface{}
+}
+
+// IsCdktfOutput reports whether output format generates CDK for Terraform code
+func IsCdktfOutput(output string) bool {
+	_, ok := cdktfLanguages[output]
+	return ok
+}
+
+var cdktfLanguages = map[string]cdktfLanguage{
+	"cdktf-typescript": typescriptLanguage{},
+	"cdktf-python":     pythonLanguage{},
+	"cdktf-go":         goLanguage{},
+}
+
+// cdktfLanguage prints constructs in one of the CDK for Terraform languages
+type cdktfLanguage interface {
+	// name as used in cdktf.json
+	name() string
+	// app command and directory with provider bindings in cdktf.json
+	app() string
+	codeMakerOutput() string
+	// files returns file name and content of each resource group and the main file wiring them together
+	groupFile(group cdktfGroup, providerName string) (string, []byte)
+	mainFile(stack cdktfMain, providerName string) map[string][]byte
+}
+
+// cdktfConstruct is instantiation of a resource, data source, provider or output
+type cdktfConstruct struct {
+	Module string
+	Class  string
+	ID     string
+	Config cdktfExpr
+}
+
+type cdktfGroup struct {
+	// Name is the same as of the file the resources would be printed to in HCL
+	Name       string
+	Constructs []cdktfConstruct
+}
+
+type cdktfMain struct {
+	Name       string
+	Groups     []string
+	Provider   *cdktfConstruct
+	Constructs []cdktfConstruct
+	Outputs    []cdktfConstruct
+}
+
+type cdktfExprKind int
+
+const (
+	cdktfString cdktfExprKind = iota
+	cdktfNumber
+	cdktfBool
+	// tokens are references to other resources where number or any other type is expected
+	cdktfNumberToken
+	cdktfAnyToken
+	cdktfList
+	cdktfMap
+	// objects of blocks and configs have properties named by the language convention,
+	// the other objects keep attribute names as keys
+	cdktfObject
+)
+
+type cdktfExpr struct {
+	Kind  cdktfExprKind
+	Value string
+	// Items are elements of lists, values of maps and properties of objects
+	Items []cdktfExpr
+	// Keys are map keys and attribute names of object properties
+	Keys []string
+	// ElementType is Go type of list and map elements
+	ElementType string
+	// Module and Class of struct for objects of blocks and configs
+	Module string
+	Class  string
+}
+
+// OutputCdktfFiles prints resources as construct instantiations grouped by type like OutputHclFiles,
+// and a main stack with provider, data sources and outputs
+func OutputCdktfFiles(stack CdktfStack, path string, isCompact bool, output string) error {
+	language, ok := cdktfLanguages[output]
+	if !ok {
+		return fmt.Errorf("unknown cdktf output format %s", output)
+	}
+	if stack.Schema == nil {
+		return errors.New("cdktf output requires provider schema")
+	}
+	for _, r := range stack.Resources {
+		if r.CollapsedName != "" {
+			return errors.New("collapsed resources can't be printed as cdktf code")
+		}
+	}
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return err
+	}
+	providerName := stack.Provider.GetName()
+	files := map[string][]byte{}
+
+	groups := map[string][]terraformutils.Resource{}
+	for _, r := range stack.Resources {
+		group := "resources"
+		if !isCompact {
+			group = strings.TrimPrefix(r.InstanceInfo.Type, strings.Split(r.InstanceInfo.Type, "_")[0]+"_")
+		}
+		groups[group] = append(groups[group], r)
+	}
+	main := cdktfMain{Name: providerName}
+	if stack.ServiceName != "" {
+		main.Name += "-" + stack.ServiceName
+	}
+	for _, group := range sortedGroupNames(groups) {
+		if err := writeDataFiles(groups[group], path); err != nil {
+			return err
+		}
+		g := cdktfGroup{Name: group}
+		for _, r := range groups[group] {
+			var block *configschema.Block
+			if schema, ok := stack.Schema.ResourceTypes[r.InstanceInfo.Type]; ok {
+				block = schema.Block
+			}
+			class := cdktfResourceClass(r.InstanceInfo.Type)
+			g.Constructs = append(g.Constructs, cdktfConstruct{
+				Module: providerName,
+				Class:  class,
+				ID:     r.ResourceName,
+				Config: cdktfConfig(providerName, class, block, r.Item),
+			})
+		}
+		fileName, content := language.groupFile(g, providerName)
+		files[fileName] = content
+		main.Groups = append(main.Groups, group)
+	}
+
+	// resources need provider construct even without arguments, they can be set with environment variables
+	providerConfig := map[string]interface{}{}
+	if providerData, ok := stack.Provider.GetProviderData()["provider"].(map[string]interface{}); ok {
+		if config, ok := providerData[providerName].(map[string]interface{}); ok {
+			providerConfig = config
+		}
+	}
+	providerClass := cdktfPascalCase(providerName) + "Provider"
+	main.Provider = &cdktfConstruct{
+		Module: providerName,
+		Class:  providerClass,
+		ID:     providerName,
+		Config: cdktfConfig(providerName, providerClass, stack.Schema.Provider.Block, providerConfig),
+	}
+	for _, name := range sortedBlockNames(stack.RemoteStates) {
+		remoteState := stack.RemoteStates[name]
+		class := "DataTerraformRemoteState" + cdktfPascalCase(fmt.Sprint(remoteState["backend"]))
+		config, _ := remoteState["config"].(map[string]interface{})
+		main.Constructs = append(main.Constructs, cdktfConstruct{
+			Module: cdktfModule,
+			Class:  class,
+			ID:     name,
+			Config: cdktfConfig(cdktfModule, class, nil, config),
+		})
+	}
+	for _, dataSource := range sortedBlockNames(stack.DataSources) {
+		var block *configschema.Block
+		if schema, ok := stack.Schema.DataSources[dataSource]; ok {
+			block = schema.Block
+		}
+		class := "Data" + cdktfPascalCase(dataSource)
+		for _, name := range sortedKeys(stack.DataSources[dataSource]) {
+			arguments, _ := stack.DataSources[dataSource][name].(map[string]interface{})
+			main.Constructs = append(main.Constructs, cdktfConstruct{
+				Module: providerName,
+				Class:  class,
+				ID:     name,
+				Config: cdktfConfig(providerName, class, block, arguments),
+			})
+		}
+	}
+	outputs := resourceOutputs(stack.Resources, stack.Provider, stack.ServiceName)
+	for _, name := range sortedBlockNames(outputs) {
+		main.Outputs = append(main.Outputs, cdktfConstruct{
+			Module: cdktfModule,
+			Class:  "TerraformOutput",
+			ID:     name,
+			Config: cdktfConfig(cdktfModule, "TerraformOutput", nil, outputs[name]),
+		})
+	}
+	for fileName, content := range language.mainFile(main, providerName) {
+		files[fileName] = content
+	}
+
+	terraformProvider := providerName
+	if version := providerwrapper.GetProviderVersion(providerName); version != "" {
+		terraformProvider += "@" + version
+	}
+	cdktfJSON, err := terraformutils.Print(map[string]interface{}{
+		"language":           language.name(),
+		"app":                language.app(),
+		"codeMakerOutput":    language.codeMakerOutput(),
+		"terraformProviders": []string{terraformProvider},
+	}, map[string]struct{}{}, "json")
+	if err != nil {
+		return err
+	}
+	files["cdktf.json"] = append(cdktfJSON, '\n')
+
+	for fileName, content := range files {
+		fullPath := filepath.Join(path, fileName)
+		if err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
+			return err
+		}
+		PrintFile(fullPath, content)
+	}
+	return nil
+}
+
+// cdktfConfig converts attributes and blocks to config object of construct class.
+// Values are converted to types of schema attributes, without schema they are printed as they are.
+func cdktfConfig(module, class string, block *configschema.Block, values map[string]interface{}) cdktfExpr {
+	config := cdktfObjectExpr(module, class, block, values)
+	config.Class = class + "Config"
+	return config
+}
+
+func cdktfObjectExpr(module, class string, block *configschema.Block, values map[string]interface{}) cdktfExpr {
+	expr := cdktfExpr{Kind: cdktfObject, Module: module, Class: class}
+	for _, key := range sortedKeys(values) {
+		var item cdktfExpr
+		var ok bool
+		switch {
+		case block == nil:
+			item, ok = cdktfValueExpr(cty.DynamicPseudoType, values[key])
+		case block.Attributes[key] != nil:
+			item, ok = cdktfValueExpr(block.Attributes[key].Type, values[key])
+		case block.BlockTypes[key] != nil:
+			item, ok = cdktfBlockExpr(module, class+cdktfPascalCase(key), block.BlockTypes[key], values[key])
+		}
+		if ok {
+			expr.Keys = append(expr.Keys, key)
+			expr.Items = append(expr.Items, item)
+		}
+	}
+	return expr
+}
+
+func cdktfBlockExpr(module, class string, block *configschema.NestedBlock, value interface{}) (cdktfExpr, bool) {
+	switch block.Nesting {
+	case configschema.NestingSingle, configschema.NestingGroup:
+		values, ok := value.(map[string]interface{})
+		return cdktfObjectExpr(module, class, &block.Block, values), ok
+	case configschema.NestingMap:
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return cdktfExpr{}, false
+		}
+		expr := cdktfExpr{Kind: cdktfMap, ElementType: "*" + module + "." + class}
+		for _, key := range sortedKeys(values) {
+			element, _ := values[key].(map[string]interface{})
+			expr.Keys = append(expr.Keys, key)
+			expr.Items = append(expr.Items, cdktfObjectExpr(module, class, &block.Block, element))
+		}
+		return expr, true
+	default:
+		values, ok := value.([]interface{})
+		if !ok {
+			return cdktfExpr{}, false
+		}
+		expr := cdktfExpr{Kind: cdktfList, ElementType: "*" + module + "." + class}
+		for _, element := range values {
+			if element, ok := element.(map[string]interface{}); ok {
+				expr.Items = append(expr.Items, cdktfObjectExpr(module, class, &block.Block, element))
+			}
+		}
+		return expr, len(expr.Items) > 0
+	}
+}
+
+func cdktfValueExpr(ty cty.Type, value interface{}) (cdktfExpr, bool) {
+	if value == nil {
+		return cdktfExpr{}, false
+	}
+	if ty == cty.DynamicPseudoType {
+		ty = cdktfImpliedType(value)
+	}
+	switch {
+	case ty == cty.String:
+		return cdktfExpr{Kind: cdktfString, Value: fmt.Sprint(value)}, true
+	case ty == cty.Number:
+		s := fmt.Sprint(value)
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return cdktfExpr{Kind: cdktfNumber, Value: s}, true
+		}
+		return cdktfExpr{Kind: cdktfNumberToken, Value: s}, true
+	case ty == cty.Bool:
+		s := fmt.Sprint(value)
+		if s == "true" || s == "false" {
+			return cdktfExpr{Kind: cdktfBool, Value: s}, true
+		}
+		return cdktfExpr{Kind: cdktfAnyToken, Value: s}, true
+	case ty.IsListType() || ty.IsSetType():
+		values, ok := value.([]interface{})
+		if !ok {
+			return cdktfExpr{}, false
+		}
+		expr := cdktfExpr{Kind: cdktfList, ElementType: cdktfGoType(ty.ElementType())}
+		for _, element := range values {
+			if item, ok := cdktfValueExpr(ty.ElementType(), element); ok {
+				expr.Items = append(expr.Items, item)
+			}
+		}
+		return expr, true
+	case ty.IsMapType():
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return cdktfExpr{}, false
+		}
+		expr := cdktfExpr{Kind: cdktfMap, ElementType: cdktfGoType(ty.ElementType())}
+		for _, key := range sortedKeys(values) {
+			if item, ok := cdktfValueExpr(ty.ElementType(), values[key]); ok {
+				expr.Keys = append(expr.Keys, key)
+				expr.Items = append(expr.Items, item)
+			}
+		}
+		return expr, true
+	case ty.IsObjectType():
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return cdktfExpr{}, false
+		}
+		expr := cdktfExpr{Kind: cdktfMap, ElementType: "interface{}"}
+		for _, key := range sortedKeys(values) {
+			attributeType := cty.DynamicPseudoType
+			if ty.HasAttribute(key) {
+				attributeType = ty.AttributeType(key)
+			}
+			if item, ok := cdktfValueExpr(attributeType, values[key]); ok {
+				expr.Keys = append(expr.Keys, key)
+				expr.Items = append(expr.Items, item)
+			}
+		}
+		return expr, true
+	default:
+		return cdktfValueExpr(cdktfImpliedType(value), value)
+	}
+}
+
+func cdktfImpliedType(value interface{}) cty.Type {
+	switch value.(type) {
+	case bool:
+		return cty.Bool
+	case int, int64, float64:
+		return cty.Number
+	case []interface{}:
+		return cty.List(cty.DynamicPseudoType)
+	case map[string]interface{}:
+		return cty.Map(cty.DynamicPseudoType)
+	default:
+		return cty.String
+	}
+}
+
+func cdktfGoType(ty cty.Type) string {
+	switch ty {
+	case cty.String:
+		return "*string"
+	case cty.Number:
+		return "*float64"
+	case cty.Bool:
+		return "*bool"
+	default:
+		return "interface{}"
+	}
+}
+
+// cdktfResourceClass is the name of class generated for resource type, without provider prefix
+func cdktfResourceClass(resourceType string) string {
+	parts := strings.SplitN(resourceType, "_", 2)
+	return cdktfPascalCase(parts[len(parts)-1])
+}
+
+func cdktfPascalCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func cdktfCamelCase(name string) string {
+	pascal := cdktfPascalCase(name)
+	if pascal == "" {
+		return pascal
+	}
+	return strings.ToLower(pascal[:1]) + pascal[1:]
+}
+
+func sortedGroupNames(groups map[string][]terraformutils.Resource) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedBlockNames(m map[string]map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,427 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformoutput
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+const cdktfHeader = "generated by terraformer"
+
+// usesCdktfModule reports whether constructs need classes or tokens of cdktf module
+func usesCdktfModule(constructs []cdktfConstruct) bool {
+	for _, construct := range constructs {
+		if construct.Module == cdktfModule || usesCdktfTokens(construct.Config) {
+			return true
+		}
+	}
+	return false
+}
+
+func usesCdktfTokens(expr cdktfExpr) bool {
+	if expr.Kind == cdktfNumberToken || expr.Kind == cdktfAnyToken {
+		return true
+	}
+	for _, item := range expr.Items {
+		if usesCdktfTokens(item) {
+			return true
+		}
+	}
+	return false
+}
+
+func usesProviderModule(main cdktfMain, providerName string) bool {
+	if main.Provider != nil {
+		return true
+	}
+	for _, construct := range main.Constructs {
+		if construct.Module == providerName {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonQuote quotes strings for TypeScript and Python, without escaping of <, > and &
+func jsonQuote(s string) string {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+type typescriptLanguage struct{}
+
+func (typescriptLanguage) name() string            { return "typescript" }
+func (typescriptLanguage) app() string             { return "npx ts-node main.ts" }
+func (typescriptLanguage) codeMakerOutput() string { return ".gen" }
+
+func (l typescriptLanguage) groupFile(group cdktfGroup, providerName string) (string, []byte) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n", cdktfHeader)
+	b.WriteString("import { Construct } from \"constructs\";\n")
+	if usesCdktfModule(group.Constructs) {
+		b.WriteString("import * as cdktf from \"cdktf\";\n")
+	}
+	fmt.Fprintf(&b, "import * as %s from \"./.gen/providers/%s\";\n\n", providerName, providerName)
+	fmt.Fprintf(&b, "export function add%s(scope: Construct) {\n", cdktfPascalCase(group.Name))
+	for _, construct := range group.Constructs {
+		l.writeConstruct(&b, construct, "scope", "  ")
+	}
+	b.WriteString("}\n")
+	return group.Name + ".ts", []byte(b.String())
+}
+
+func (l typescriptLanguage) mainFile(main cdktfMain, providerName string) map[string][]byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n", cdktfHeader)
+	b.WriteString("import { Construct } from \"constructs\";\n")
+	b.WriteString("import * as cdktf from \"cdktf\";\n")
+	if usesProviderModule(main, providerName) {
+		fmt.Fprintf(&b, "import * as %s from \"./.gen/providers/%s\";\n", providerName, providerName)
+	}
+	for _, group := range main.Groups {
+		fmt.Fprintf(&b, "import { add%s } from \"./%s\";\n", cdktfPascalCase(group), group)
+	}
+	b.WriteString("\nclass ImportedStack extends cdktf.TerraformStack {\n")
+	b.WriteString("  constructor(scope: Construct, name: string) {\n")
+	b.WriteString("    super(scope, name);\n\n")
+	if main.Provider != nil {
+		l.writeConstruct(&b, *main.Provider, "this", "    ")
+	}
+	for _, construct := range main.Constructs {
+		l.writeConstruct(&b, construct, "this", "    ")
+	}
+	for _, group := range main.Groups {
+		fmt.Fprintf(&b, "    add%s(this);\n", cdktfPascalCase(group))
+	}
+	for _, construct := range main.Outputs {
+		l.writeConstruct(&b, construct, "this", "    ")
+	}
+	b.WriteString("  }\n}\n\n")
+	b.WriteString("const app = new cdktf.App();\n")
+	fmt.Fprintf(&b, "new ImportedStack(app, %s);\n", jsonQuote(main.Name))
+	b.WriteString("app.synth();\n")
+	return map[string][]byte{"main.ts": []byte(b.String())}
+}
+
+func (l typescriptLanguage) writeConstruct(b *strings.Builder, construct cdktfConstruct, scope, indent string) {
+	fmt.Fprintf(b, "%snew %s.%s(%s, %s, ", indent, construct.Module, construct.Class, scope, jsonQuote(construct.ID))
+	l.writeExpr(b, construct.Config, indent)
+	b.WriteString(");\n")
+}
+
+func (l typescriptLanguage) writeExpr(b *strings.Builder, expr cdktfExpr, indent string) {
+	switch expr.Kind {
+	case cdktfString:
+		b.WriteString(jsonQuote(expr.Value))
+	case cdktfNumber, cdktfBool:
+		b.WriteString(expr.Value)
+	case cdktfNumberToken:
+		fmt.Fprintf(b, "cdktf.Token.asNumber(%s)", jsonQuote(expr.Value))
+	case cdktfAnyToken:
+		fmt.Fprintf(b, "cdktf.Token.asAny(%s)", jsonQuote(expr.Value))
+	case cdktfList:
+		if len(expr.Items) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[\n")
+		for _, item := range expr.Items {
+			b.WriteString(indent + "  ")
+			l.writeExpr(b, item, indent+"  ")
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "]")
+	case cdktfMap, cdktfObject:
+		if len(expr.Items) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		b.WriteString("{\n")
+		for i, item := range expr.Items {
+			key := jsonQuote(expr.Keys[i])
+			if expr.Kind == cdktfObject {
+				key = cdktfCamelCase(expr.Keys[i])
+			}
+			fmt.Fprintf(b, "%s  %s: ", indent, key)
+			l.writeExpr(b, item, indent+"  ")
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "}")
+	}
+}
+
+type pythonLanguage struct{}
+
+// pythonKeywords are renamed by jsii with trailing underscore
+var pythonKeywords = map[string]bool{
+	"and": true, "as": true, "assert": true, "async": true, "await": true, "break": true, "class": true,
+	"continue": true, "def": true, "del": true, "elif": true, "else": true, "except": true, "finally": true,
+	"for": true, "from": true, "global": true, "if": true, "import": true, "in": true, "is": true,
+	"lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true, "raise": true, "return": true,
+	"try": true, "while": true, "with": true, "yield": true,
+}
+
+func (pythonLanguage) name() string            { return "python" }
+func (pythonLanguage) app() string             { return "python3 main.py" }
+func (pythonLanguage) codeMakerOutput() string { return "imports" }
+
+// groupFile is put into resources package, so modules like queue don't shadow standard library
+func (l pythonLanguage) groupFile(group cdktfGroup, providerName string) (string, []byte) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", cdktfHeader)
+	b.WriteString("from constructs import Construct\n")
+	if usesCdktfModule(group.Constructs) {
+		b.WriteString("import cdktf\n")
+	}
+	fmt.Fprintf(&b, "import imports.%s as %s\n\n\n", providerName, providerName)
+	fmt.Fprintf(&b, "def add_%s(scope: Construct):\n", group.Name)
+	for _, construct := range group.Constructs {
+		l.writeConstruct(&b, construct, "scope", "    ")
+	}
+	return "resources/" + group.Name + ".py", []byte(b.String())
+}
+
+func (l pythonLanguage) mainFile(main cdktfMain, providerName string) map[string][]byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", cdktfHeader)
+	b.WriteString("from constructs import Construct\n")
+	b.WriteString("import cdktf\n")
+	if usesProviderModule(main, providerName) {
+		fmt.Fprintf(&b, "import imports.%s as %s\n", providerName, providerName)
+	}
+	for _, group := range main.Groups {
+		fmt.Fprintf(&b, "from resources.%s import add_%s\n", group, group)
+	}
+	b.WriteString("\n\nclass ImportedStack(cdktf.TerraformStack):\n")
+	b.WriteString("    def __init__(self, scope: Construct, name: str):\n")
+	b.WriteString("        super().__init__(scope, name)\n\n")
+	if main.Provider != nil {
+		l.writeConstruct(&b, *main.Provider, "self", "        ")
+	}
+	for _, construct := range main.Constructs {
+		l.writeConstruct(&b, construct, "self", "        ")
+	}
+	for _, group := range main.Groups {
+		fmt.Fprintf(&b, "        add_%s(self)\n", group)
+	}
+	for _, construct := range main.Outputs {
+		l.writeConstruct(&b, construct, "self", "        ")
+	}
+	b.WriteString("\n\napp = cdktf.App()\n")
+	fmt.Fprintf(&b, "ImportedStack(app, %s)\n", jsonQuote(main.Name))
+	b.WriteString("app.synth()\n")
+	return map[string][]byte{
+		"main.py":               []byte(b.String()),
+		"resources/__init__.py": []byte(fmt.Sprintf("# %s\n", cdktfHeader)),
+	}
+}
+
+func (l pythonLanguage) writeConstruct(b *strings.Builder, construct cdktfConstruct, scope, indent string) {
+	fmt.Fprintf(b, "%s%s.%s(\n", indent, construct.Module, construct.Class)
+	fmt.Fprintf(b, "%s    %s,\n", indent, scope)
+	fmt.Fprintf(b, "%s    %s,\n", indent, jsonQuote(construct.ID))
+	l.writeArguments(b, construct.Config, indent+"    ")
+	b.WriteString(indent + ")\n")
+}
+
+func (l pythonLanguage) writeArguments(b *strings.Builder, object cdktfExpr, indent string) {
+	for i, item := range object.Items {
+		fmt.Fprintf(b, "%s%s=", indent, pythonName(object.Keys[i]))
+		l.writeExpr(b, item, indent)
+		b.WriteString(",\n")
+	}
+}
+
+func (l pythonLanguage) writeExpr(b *strings.Builder, expr cdktfExpr, indent string) {
+	switch expr.Kind {
+	case cdktfString, cdktfNumber:
+		if expr.Kind == cdktfString {
+			b.WriteString(jsonQuote(expr.Value))
+		} else {
+			b.WriteString(expr.Value)
+		}
+	case cdktfBool:
+		if expr.Value == "true" {
+			b.WriteString("True")
+		} else {
+			b.WriteString("False")
+		}
+	case cdktfNumberToken:
+		fmt.Fprintf(b, "cdktf.Token.as_number(%s)", jsonQuote(expr.Value))
+	case cdktfAnyToken:
+		fmt.Fprintf(b, "cdktf.Token.as_any(%s)", jsonQuote(expr.Value))
+	case cdktfList:
+		if len(expr.Items) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[\n")
+		for _, item := range expr.Items {
+			b.WriteString(indent + "    ")
+			l.writeExpr(b, item, indent+"    ")
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "]")
+	case cdktfMap:
+		if len(expr.Items) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		b.WriteString("{\n")
+		for i, item := range expr.Items {
+			fmt.Fprintf(b, "%s    %s: ", indent, jsonQuote(expr.Keys[i]))
+			l.writeExpr(b, item, indent+"    ")
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "}")
+	case cdktfObject:
+		fmt.Fprintf(b, "%s.%s(\n", expr.Module, expr.Class)
+		l.writeArguments(b, expr, indent+"    ")
+		b.WriteString(indent + ")")
+	}
+}
+
+func pythonName(name string) string {
+	if pythonKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+type goLanguage struct{}
+
+func (goLanguage) name() string            { return "go" }
+func (goLanguage) app() string             { return "go run ." }
+func (goLanguage) codeMakerOutput() string { return "generated" }
+
+// goProviderImport is the package generated by cdktf get in the module of cdktf init template
+func goProviderImport(providerName string) string {
+	return "cdk.tf/go/stack/generated/hashicorp/" + providerName
+}
+
+func (l goLanguage) groupFile(group cdktfGroup, providerName string) (string, []byte) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n\npackage main\n\n", cdktfHeader)
+	b.WriteString("import (\n")
+	b.WriteString("\t\"github.com/aws/constructs-go/constructs/v10\"\n")
+	b.WriteString("\t\"github.com/aws/jsii-runtime-go\"\n")
+	if usesCdktfModule(group.Constructs) {
+		b.WriteString("\t\"github.com/hashicorp/terraform-cdk-go/cdktf\"\n")
+	}
+	fmt.Fprintf(&b, "\n\t%q\n)\n\n", goProviderImport(providerName))
+	fmt.Fprintf(&b, "func add%s(scope constructs.Construct) {\n", cdktfPascalCase(group.Name))
+	for _, construct := range group.Constructs {
+		l.writeConstruct(&b, construct, "scope")
+	}
+	b.WriteString("}\n")
+	return group.Name + ".go", goFormat(b.String())
+}
+
+func (l goLanguage) mainFile(main cdktfMain, providerName string) map[string][]byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n\npackage main\n\n", cdktfHeader)
+	b.WriteString("import (\n")
+	b.WriteString("\t\"github.com/aws/constructs-go/constructs/v10\"\n")
+	b.WriteString("\t\"github.com/aws/jsii-runtime-go\"\n")
+	b.WriteString("\t\"github.com/hashicorp/terraform-cdk-go/cdktf\"\n")
+	if usesProviderModule(main, providerName) {
+		fmt.Fprintf(&b, "\n\t%q\n", goProviderImport(providerName))
+	}
+	b.WriteString(")\n\n")
+	b.WriteString("func NewImportedStack(scope constructs.Construct, name string) cdktf.TerraformStack {\n")
+	b.WriteString("stack := cdktf.NewTerraformStack(scope, jsii.String(name))\n")
+	if main.Provider != nil {
+		l.writeConstruct(&b, *main.Provider, "stack")
+	}
+	for _, construct := range main.Constructs {
+		l.writeConstruct(&b, construct, "stack")
+	}
+	for _, group := range main.Groups {
+		fmt.Fprintf(&b, "add%s(stack)\n", cdktfPascalCase(group))
+	}
+	for _, construct := range main.Outputs {
+		l.writeConstruct(&b, construct, "stack")
+	}
+	b.WriteString("return stack\n}\n\n")
+	b.WriteString("func main() {\n")
+	b.WriteString("app := cdktf.NewApp(nil)\n")
+	fmt.Fprintf(&b, "NewImportedStack(app, %q)\n", main.Name)
+	b.WriteString("app.Synth()\n}\n")
+	return map[string][]byte{
+		"main.go": goFormat(b.String()),
+		"go.mod":  []byte("module cdk.tf/go/stack\n\ngo 1.16\n"),
+	}
+}
+
+func (l goLanguage) writeConstruct(b *strings.Builder, construct cdktfConstruct, scope string) {
+	fmt.Fprintf(b, "%s.New%s(%s, jsii.String(%q), ", construct.Module, construct.Class, scope, construct.ID)
+	l.writeExpr(b, construct.Config)
+	b.WriteString(")\n")
+}
+
+func (l goLanguage) writeExpr(b *strings.Builder, expr cdktfExpr) {
+	switch expr.Kind {
+	case cdktfString:
+		fmt.Fprintf(b, "jsii.String(%s)", strconv.Quote(expr.Value))
+	case cdktfNumber:
+		fmt.Fprintf(b, "jsii.Number(%s)", expr.Value)
+	case cdktfBool:
+		fmt.Fprintf(b, "jsii.Bool(%s)", expr.Value)
+	case cdktfNumberToken:
+		fmt.Fprintf(b, "cdktf.Token_AsNumber(jsii.String(%s))", strconv.Quote(expr.Value))
+	case cdktfAnyToken:
+		fmt.Fprintf(b, "cdktf.Token_AsAny(jsii.String(%s))", strconv.Quote(expr.Value))
+	case cdktfList:
+		fmt.Fprintf(b, "&[]%s{\n", expr.ElementType)
+		for _, item := range expr.Items {
+			l.writeExpr(b, item)
+			b.WriteString(",\n")
+		}
+		b.WriteString("}")
+	case cdktfMap:
+		fmt.Fprintf(b, "&map[string]%s{\n", expr.ElementType)
+		for i, item := range expr.Items {
+			fmt.Fprintf(b, "%s: ", strconv.Quote(expr.Keys[i]))
+			l.writeExpr(b, item)
+			b.WriteString(",\n")
+		}
+		b.WriteString("}")
+	case cdktfObject:
+		fmt.Fprintf(b, "&%s.%s{\n", expr.Module, expr.Class)
+		for i, item := range expr.Items {
+			fmt.Fprintf(b, "%s: ", cdktfPascalCase(expr.Keys[i]))
+			l.writeExpr(b, item)
+			b.WriteString(",\n")
+		}
+		b.WriteString("}")
+	}
+}
+
+// goFormat indents and aligns generated code like gofmt, unformatted code is kept if it can't be parsed
+func goFormat(code string) []byte {
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		return []byte(code)
+	}
+	return formatted
+}
@@ -0,0 +1,92 @@
+package terraformoutput
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCdktfConfig(t *testing.T) {
+	block := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"instance_type":   {Type: cty.String},
+			"cpu_core_count":  {Type: cty.Number},
+			"volume_size":     {Type: cty.Number},
+			"ebs_optimized":   {Type: cty.Bool},
+			"tags":            {Type: cty.Map(cty.String)},
+			"security_groups": {Type: cty.Set(cty.String)},
+			"lambda":          {Type: cty.String},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"root_block_device": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{Attributes: map[string]*configschema.Attribute{
+					"encrypted": {Type: cty.Bool},
+				}},
+			},
+		},
+	}
+	config := cdktfConfig("aws", "Instance", block, map[string]interface{}{
+		"instance_type":     "t3.micro",
+		"cpu_core_count":    "2",
+		"volume_size":       "${aws_ebs_volume.tfer--data.size}",
+		"ebs_optimized":     "true",
+		"tags":              map[string]interface{}{"Name": "web"},
+		"security_groups":   []interface{}{"sg-1"},
+		"lambda":            "x",
+		"root_block_device": []interface{}{map[string]interface{}{"encrypted": "false"}},
+		"not_in_schema":     "ignored",
+	})
+
+	testCases := map[string]struct {
+		language cdktfLanguage
+		expected []string
+	}{
+		"typescript": {typescriptLanguage{}, []string{
+			`new aws.Instance(scope, "tfer--web", {`,
+			`cpuCoreCount: 2,`,
+			`ebsOptimized: true,`,
+			`instanceType: "t3.micro",`,
+			`rootBlockDevice: [`,
+			`encrypted: false,`,
+			`securityGroups: [`,
+			`"Name": "web",`,
+			`volumeSize: cdktf.Token.asNumber("${aws_ebs_volume.tfer--data.size}"),`,
+		}},
+		"python": {pythonLanguage{}, []string{
+			`cpu_core_count=2,`,
+			`ebs_optimized=True,`,
+			`lambda_="x",`,
+			`aws.InstanceRootBlockDevice(`,
+			`"Name": "web",`,
+			`volume_size=cdktf.Token.as_number("${aws_ebs_volume.tfer--data.size}"),`,
+		}},
+		"go": {goLanguage{}, []string{
+			`aws.NewInstance(scope, jsii.String("tfer--web"), &aws.InstanceConfig{`,
+			`CpuCoreCount:`,
+			`jsii.Number(2),`,
+			`RootBlockDevice: &[]*aws.InstanceRootBlockDevice{`,
+			`SecurityGroups: &[]*string{`,
+			`Tags: &map[string]*string{`,
+			`cdktf.Token_AsNumber(jsii.String("${aws_ebs_volume.tfer--data.size}")),`,
+		}},
+	}
+	for name, testCase := range testCases {
+		_, content := testCase.language.groupFile(cdktfGroup{Name: "instance", Constructs: []cdktfConstruct{{
+			Module: "aws",
+			Class:  "Instance",
+			ID:     "tfer--web",
+			Config: config,
+		}}}, "aws")
+		for _, expected := range testCase.expected {
+			if !strings.Contains(string(content), expected) {
+				t.Errorf("%s: %q not found in:\n%s", name, expected, content)
+			}
+		}
+		if strings.Contains(string(content), "not_in_schema") || strings.Contains(string(content), "notInSchema") {
+			t.Errorf("%s: attribute missing in schema was printed:\n%s", name, content)
+		}
+	}
+}
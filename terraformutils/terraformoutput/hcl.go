@@ -25,11 +25,61 @@ import (
 	"github.com/hashicorp/terraform/terraform"
 )
 
-func OutputHclFiles(resources []terraformutils.Resource, provider terraformutils.ProviderGenerator, path string, serviceName string, isCompact bool, output string) error {
+func OutputHclFiles(resources []terraformutils.Resource, provider terraformutils.ProviderGenerator, path string, serviceName string, isCompact bool, output string, isCollapse bool, withProvider bool) error {
 	if err := os.MkdirAll(path, os.ModePerm); err != nil {
 		return err
 	}
+	if isCollapse {
+		collapseResources(resources)
+	}
 	// create provider file
+	if withProvider {
+		providerDataFile, err := ProviderFile(provider, output)
+		if err != nil {
+			return err
+		}
+		PrintFile(path+"/provider."+GetFileExtension(output), providerDataFile)
+	}
+
+	// create outputs files
+	outputs := map[string]interface{}{}
+	outputsByResource := resourceOutputs(resources, provider, serviceName)
+	if len(outputsByResource) > 0 {
+		outputs["output"] = outputsByResource
+		outputsFile, err := terraformutils.Print(outputs, map[string]struct{}{}, output)
+		if err != nil {
+			return err
+		}
+		if output == "hcl" {
+			outputsFile = terraformutils.UnescapeCollapsedReferences(outputsFile, resources)
+		}
+		PrintFile(path+"/outputs."+GetFileExtension(output), outputsFile)
+	}
+
+	// group by resource by type
+	typeOfServices := map[string][]terraformutils.Resource{}
+	for _, r := range resources {
+		typeOfServices[r.InstanceInfo.Type] = append(typeOfServices[r.InstanceInfo.Type], r)
+	}
+	if isCompact {
+		err := printFile(resources, "resources", path, output)
+		if err != nil {
+			return err
+		}
+	} else {
+		for k, v := range typeOfServices {
+			fileName := strings.ReplaceAll(k, strings.Split(k, "_")[0]+"_", "")
+			err := printFile(v, fileName, path, output)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ProviderFile returns provider configuration and required version of provider
+func ProviderFile(provider terraformutils.ProviderGenerator, output string) ([]byte, error) {
 	providerData := provider.GetProviderData()
 	providerData["terraform"] = map[string]interface{}{
 		"required_providers": []map[string]interface{}{{
@@ -38,21 +88,31 @@ func OutputHclFiles(resources []terraformutils.Resource, provider terraformutils
 			},
 		}},
 	}
+	return terraformutils.Print(providerData, map[string]struct{}{}, output)
+}
 
-	providerDataFile, err := terraformutils.Print(providerData, map[string]struct{}{}, output)
-	if err != nil {
-		return err
+// collapse homogeneous resources of each type into a single for_each resource
+func collapseResources(resources []terraformutils.Resource) {
+	resourcesByType := map[string][]*terraformutils.Resource{}
+	for i := range resources {
+		resourcesByType[resources[i].InstanceInfo.Type] = append(resourcesByType[resources[i].InstanceInfo.Type], &resources[i])
+	}
+	for resourceType, typeResources := range resourcesByType {
+		if terraformutils.CollapseResources(typeResources) {
+			log.Printf("collapsed %d resources of type %s into %s", len(typeResources), resourceType, typeResources[0].Address())
+		}
 	}
-	PrintFile(path+"/provider."+GetFileExtension(output), providerDataFile)
+	terraformutils.UpdateCollapsedReferences(resources)
+}
 
-	// create outputs files
-	outputs := map[string]interface{}{}
+// resourceOutputs returns outputs of resource IDs and attributes referenced by connected services,
+// and sets their values to Outputs of resources
+func resourceOutputs(resources []terraformutils.Resource, provider terraformutils.ProviderGenerator, serviceName string) map[string]map[string]interface{} {
 	outputsByResource := map[string]map[string]interface{}{}
-
 	for i, r := range resources {
 		outputState := map[string]*terraform.OutputState{}
 		outputsByResource[r.InstanceInfo.Type+"_"+r.ResourceName+"_"+r.GetIDKey()] = map[string]interface{}{
-			"value": "${" + r.InstanceInfo.Type + "." + r.ResourceName + "." + r.GetIDKey() + "}",
+			"value": "${" + r.Address() + "." + r.GetIDKey() + "}",
 		}
 		outputState[r.InstanceInfo.Type+"_"+r.ResourceName+"_"+r.GetIDKey()] = &terraform.OutputState{
 			Type:  "string",
@@ -68,7 +128,7 @@ func OutputHclFiles(resources []terraformutils.Resource, provider terraformutils
 						}
 						linkKey := r.InstanceInfo.Type + "_" + r.ResourceName + "_" + key
 						outputsByResource[linkKey] = map[string]interface{}{
-							"value": "${" + r.InstanceInfo.Type + "." + r.ResourceName + "." + key + "}",
+							"value": "${" + r.Address() + "." + key + "}",
 						}
 						outputState[linkKey] = &terraform.OutputState{
 							Type:  "string",
@@ -80,42 +140,28 @@ func OutputHclFiles(resources []terraformutils.Resource, provider terraformutils
 		}
 		resources[i].Outputs = outputState
 	}
-	if len(outputsByResource) > 0 {
-		outputs["output"] = outputsByResource
-		outputsFile, err := terraformutils.Print(outputs, map[string]struct{}{}, output)
-		if err != nil {
-			return err
-		}
-		PrintFile(path+"/outputs."+GetFileExtension(output), outputsFile)
+	return outputsByResource
+}
+
+func printFile(v []terraformutils.Resource, fileName, path, output string) error {
+	if err := writeDataFiles(v, path); err != nil {
+		return err
 	}
 
-	// group by resource by type
-	typeOfServices := map[string][]terraformutils.Resource{}
-	for _, r := range resources {
-		typeOfServices[r.InstanceInfo.Type] = append(typeOfServices[r.InstanceInfo.Type], r)
+	tfFile, err := terraformutils.HclPrintResource(v, map[string]interface{}{}, output)
+	if err != nil {
+		return err
 	}
-	if isCompact {
-		err := printFile(resources, "resources", path, output)
-		if err != nil {
-			return err
-		}
-	} else {
-		for k, v := range typeOfServices {
-			fileName := strings.ReplaceAll(k, strings.Split(k, "_")[0]+"_", "")
-			err := printFile(v, fileName, path, output)
-			if err != nil {
-				return err
-			}
-		}
+	err = ioutil.WriteFile(path+"/"+fileName+"."+GetFileExtension(output), tfFile, os.ModePerm)
+	if err != nil {
+		return err
 	}
+
 	return nil
 }
 
-func printFile(v []terraformutils.Resource, fileName, path, output string) error {
-	for _, res := range v {
-		if res.DataFiles == nil {
-			continue
-		}
+func writeDataFiles(resources []terraformutils.Resource, path string) error {
+	for _, res := range resources {
 		for fileName, content := range res.DataFiles {
 			if err := os.MkdirAll(path+"/data/", os.ModePerm); err != nil {
 				return err
@@ -126,16 +172,6 @@ func printFile(v []terraformutils.Resource, fileName, path, output string) error
 			}
 		}
 	}
-
-	tfFile, err := terraformutils.HclPrintResource(v, map[string]interface{}{}, output)
-	if err != nil {
-		return err
-	}
-	err = ioutil.WriteFile(path+"/"+fileName+"."+GetFileExtension(output), tfFile, os.ModePerm)
-	if err != nil {
-		return err
-	}
-
 	return nil
 }
 
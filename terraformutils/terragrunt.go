@@ -0,0 +1,99 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// TerragruntConfig is terragrunt.hcl of a directory of generated code
+type TerragruntConfig struct {
+	// Include makes the directory include the root config found in parent directories
+	Include bool
+	// RemoteState of each directory is kept in backend by the root config
+	RemoteState *TerragruntRemoteState
+	// ProviderFile is generated as provider.tf into each directory by the root config
+	ProviderFile []byte
+	// Dependencies are paths of directories, relative to the directory, by names
+	Dependencies map[string]string
+}
+
+// TerragruntRemoteState is backend and its config, values may use Terragrunt functions like get_terragrunt_dir()
+type TerragruntRemoteState struct {
+	Backend string
+	Config  map[string]string
+}
+
+// PrintTerragruntConfig prints terragrunt.hcl, outputs of each dependency are passed to the variable of its name
+func PrintTerragruntConfig(config TerragruntConfig) []byte {
+	var b bytes.Buffer
+	if config.Include {
+		b.WriteString("include \"root\" {\n  path = find_in_parent_folders()\n}\n\n")
+	}
+	if config.RemoteState != nil {
+		fmt.Fprintf(&b, "remote_state {\n  backend = %q\n  config = {\n", config.RemoteState.Backend)
+		for _, key := range sortedStringKeys(config.RemoteState.Config) {
+			fmt.Fprintf(&b, "    %s = \"%s\"\n", key, hclStringEscaper.Replace(config.RemoteState.Config[key]))
+		}
+		b.WriteString("  }\n  generate = {\n    path = \"backend.tf\"\n    if_exists = \"overwrite_terragrunt\"\n  }\n}\n\n")
+	}
+	if len(config.ProviderFile) > 0 {
+		// heredoc of Terragrunt is a template
+		contents := strings.NewReplacer("${", "$${", "%{", "%%{").Replace(string(config.ProviderFile))
+		if !strings.HasSuffix(contents, "\n") {
+			contents += "\n"
+		}
+		b.WriteString("generate \"provider\" {\n  path = \"provider.tf\"\n  if_exists = \"overwrite_terragrunt\"\n")
+		b.WriteString("  contents = <<EOF\n" + contents + "EOF\n}\n\n")
+	}
+	names := sortedStringKeys(config.Dependencies)
+	for _, name := range names {
+		fmt.Fprintf(&b, "dependency %q {\n  config_path = %q\n}\n\n", name, config.Dependencies[name])
+	}
+	if len(names) > 0 {
+		b.WriteString("inputs = {\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "  %s = dependency.%s.outputs\n", name, name)
+		}
+		b.WriteString("}\n")
+	}
+	return hclwrite.Format(append(bytes.TrimRight(b.Bytes(), "\n"), '\n'))
+}
+
+// PrintTerragruntVariables prints variables receiving outputs of dependencies from inputs of terragrunt.hcl
+func PrintTerragruntVariables(dependencies map[string]string) []byte {
+	var b bytes.Buffer
+	for i, name := range sortedStringKeys(dependencies) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "variable %q {\n  type = map(string)\n}\n", name)
+	}
+	return b.Bytes()
+}
+
+// UseTerragruntDependencies replaces references to outputs of remote states
+// with variables of dependencies of the same names
+func UseTerragruntDependencies(resources []Resource, dependencies map[string]string) {
+	for name := range dependencies {
+		for i := range resources {
+			transformReplace("data.terraform_remote_state."+name+".outputs.", "var."+name+".", resources[i].Item)
+		}
+	}
+}
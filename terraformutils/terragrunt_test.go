@@ -0,0 +1,70 @@
+package terraformutils
+
+import (
+	"testing"
+)
+
+func TestPrintTerragruntConfig(t *testing.T) {
+	config := TerragruntConfig{
+		RemoteState: &TerragruntRemoteState{
+			Backend: "gcs",
+			Config: map[string]string{
+				"bucket": "terraform-state",
+				"prefix": "generated/google/${path_relative_to_include()}",
+			},
+		},
+		ProviderFile: []byte("provider \"google\" {\n  project = \"${var.project}\"\n}\n"),
+		Dependencies: map[string]string{"networks": "../networks"},
+	}
+	expected := `remote_state {
+  backend = "gcs"
+  config = {
+    bucket = "terraform-state"
+    prefix = "generated/google/${path_relative_to_include()}"
+  }
+  generate = {
+    path      = "backend.tf"
+    if_exists = "overwrite_terragrunt"
+  }
+}
+
+generate "provider" {
+  path      = "provider.tf"
+  if_exists = "overwrite_terragrunt"
+  contents  = <<EOF
+provider "google" {
+  project = "$${var.project}"
+}
+EOF
+}
+
+dependency "networks" {
+  config_path = "../networks"
+}
+
+inputs = {
+  networks = dependency.networks.outputs
+}
+`
+	if actual := string(PrintTerragruntConfig(config)); actual != expected {
+		t.Errorf("unexpected config:\n%s", actual)
+	}
+}
+
+func TestUseTerragruntDependencies(t *testing.T) {
+	resources := []Resource{{Item: map[string]interface{}{
+		"network": "${data.terraform_remote_state.networks.outputs.google_compute_network_tfer--default_self_link}",
+		"disks":   []interface{}{"${data.terraform_remote_state.local.outputs.google_compute_disk_tfer--disk_self_link}"},
+	}}}
+	UseTerragruntDependencies(resources, map[string]string{"networks": "../networks"})
+	if resources[0].Item["network"] != "${var.networks.google_compute_network_tfer--default_self_link}" {
+		t.Errorf("reference to dependency isn't replaced: %s", resources[0].Item["network"])
+	}
+	if resources[0].Item["disks"].([]interface{})[0] != "${data.terraform_remote_state.local.outputs.google_compute_disk_tfer--disk_self_link}" {
+		t.Errorf("reference to local state is replaced: %s", resources[0].Item["disks"])
+	}
+	expected := "variable \"a\" {\n  type = map(string)\n}\n\nvariable \"networks\" {\n  type = map(string)\n}\n"
+	if actual := string(PrintTerragruntVariables(map[string]string{"networks": "../networks", "a": "../a"})); actual != expected {
+		t.Errorf("unexpected variables:\n%s", actual)
+	}
+}
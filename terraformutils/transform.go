@@ -0,0 +1,451 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/terraformerstring"
+)
+
+// TransformRule changes generated resources matching Types and Filter.
+// Attribute paths are dot separated, blocks and lists of objects are walked element by element.
+type TransformRule struct {
+	Name string `json:"name"`
+	// Types are resource type patterns, e.g. aws_* (all types if empty)
+	Types []string `json:"types,omitempty"`
+	// Filter uses --filter syntax, e.g. Name=tags.env;Value=prod
+	Filter []string `json:"filter,omitempty"`
+	// Set assigns values to attribute paths, creating missing maps
+	Set map[string]interface{} `json:"set,omitempty"`
+	// Delete removes attribute paths
+	Delete []string `json:"delete,omitempty"`
+	// DeleteDefaults removes attribute paths equal to given values
+	DeleteDefaults map[string]interface{} `json:"delete_defaults,omitempty"`
+	// Rename renames attribute paths to new attribute names, e.g. {"tags.Owner": "owner"}
+	Rename map[string]string `json:"rename,omitempty"`
+	// Replace replaces substrings in all string values, e.g. account IDs with variables
+	Replace map[string]string `json:"replace,omitempty"`
+	// ResourceName renames resources, {name}, {id}, {type} and {attr:path} are replaced
+	ResourceName string `json:"resource_name,omitempty"`
+	// IgnoreKeys adds attribute patterns to leave out of generated files
+	IgnoreKeys []string `json:"ignore_keys,omitempty"`
+
+	filters []ResourceFilter
+}
+
+type TransformRules struct {
+	Rules []TransformRule `json:"rules"`
+}
+
+// TransformChange describes what a rule changed in a resource
+type TransformChange struct {
+	Rule     string
+	Resource string
+	Change   string
+}
+
+func (c TransformChange) String() string {
+	return c.Rule + ": " + c.Resource + " " + c.Change
+}
+
+var resourceNamePlaceholder = regexp.MustCompile(`\{(name|id|type|attr:[^}]+)\}`)
+var resourceNameLiteral = regexp.MustCompile(`^[0-9A-Za-z_-]*$`)
+
+// LoadTransformRules reads rules from json file
+func LoadTransformRules(path string) ([]TransformRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rules := TransformRules{}
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("failed to parse transform rules %s: %v", path, err)
+	}
+	for i := range rules.Rules {
+		rule := &rules.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		for _, pattern := range rule.Types {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s: invalid type pattern %s", rule.Name, pattern)
+			}
+		}
+		if literal := resourceNamePlaceholder.ReplaceAllString(rule.ResourceName, ""); !resourceNameLiteral.MatchString(literal) {
+			return nil, fmt.Errorf("%s: invalid characters in resource name %s", rule.Name, rule.ResourceName)
+		}
+		for _, pattern := range rule.IgnoreKeys {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("%s: invalid ignore key %s: %v", rule.Name, pattern, err)
+			}
+		}
+		service := &Service{}
+		for _, rawFilter := range rule.Filter {
+			rule.filters = append(rule.filters, service.ParseFilter(rawFilter)...)
+		}
+	}
+	return rules.Rules, nil
+}
+
+// TransformResources applies rules in order to resources of all services and returns what was changed.
+// Renamed resources must not get the address of another resource, changes are returned with the error then.
+func TransformResources(resources map[string][]Resource, rules []TransformRule) ([]TransformChange, error) {
+	var services []string
+	for service := range resources {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	var changes []TransformChange
+	renamed := map[*Resource]string{}
+	for _, rule := range rules {
+		for _, service := range services {
+			for i := range resources[service] {
+				resource := &resources[service][i]
+				if !rule.matches(resource) {
+					continue
+				}
+				address := resource.InstanceInfo.Type + "." + resource.ResourceName
+				for _, change := range rule.apply(resource) {
+					changes = append(changes, TransformChange{
+						Rule:     rule.Name,
+						Resource: address,
+						Change:   change,
+					})
+				}
+				if resource.InstanceInfo.Type+"."+resource.ResourceName != address {
+					if _, exist := renamed[resource]; !exist {
+						renamed[resource] = address
+					}
+				}
+			}
+		}
+	}
+	return changes, checkRenamedAddresses(resources, services, renamed)
+}
+
+// checkRenamedAddresses fails if renamed resources have the same address as another resource
+func checkRenamedAddresses(resources map[string][]Resource, services []string, renamed map[*Resource]string) error {
+	if len(renamed) == 0 {
+		return nil
+	}
+	originalAddresses := map[string][]string{}
+	var addresses []string
+	for _, service := range services {
+		for i := range resources[service] {
+			resource := &resources[service][i]
+			address := resource.InstanceInfo.Type + "." + resource.ResourceName
+			original, exist := renamed[resource]
+			if !exist {
+				original = address
+			}
+			if _, exist := originalAddresses[address]; !exist {
+				addresses = append(addresses, address)
+			}
+			originalAddresses[address] = append(originalAddresses[address], original)
+		}
+	}
+	var collisions []string
+	for _, address := range addresses {
+		if len(originalAddresses[address]) < 2 {
+			continue
+		}
+		// duplicates which no rule renamed are left as they were
+		for _, original := range originalAddresses[address] {
+			if original != address {
+				collisions = append(collisions, fmt.Sprintf("%s (%s)", address, strings.Join(originalAddresses[address], ", ")))
+				break
+			}
+		}
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+	return fmt.Errorf("resource_name of transform rules gives several resources the same address, add {id} to names: %s", strings.Join(collisions, "; "))
+}
+
+func (rule *TransformRule) matches(resource *Resource) bool {
+	if len(rule.Types) > 0 {
+		matched := false
+		for _, pattern := range rule.Types {
+			if ok, _ := filepath.Match(pattern, resource.InstanceInfo.Type); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for i := range rule.filters {
+		if !rule.filters[i].Filter(*resource) {
+			return false
+		}
+	}
+	return true
+}
+
+func (rule *TransformRule) apply(resource *Resource) []string {
+	var changes []string
+	if resource.Item == nil {
+		resource.Item = map[string]interface{}{}
+	}
+	for _, path := range sortedKeys(rule.Set) {
+		if transformSet(strings.Split(path, "."), rule.Set[path], resource.Item) {
+			changes = append(changes, fmt.Sprintf("set %s = %v", path, rule.Set[path]))
+		}
+	}
+	for _, path := range rule.Delete {
+		if transformDelete(strings.Split(path, "."), nil, resource.Item) {
+			changes = append(changes, "delete "+path)
+		}
+	}
+	for _, path := range sortedKeys(rule.DeleteDefaults) {
+		defaultValue := fmt.Sprint(rule.DeleteDefaults[path])
+		if transformDelete(strings.Split(path, "."), &defaultValue, resource.Item) {
+			changes = append(changes, fmt.Sprintf("delete %s = %s", path, defaultValue))
+		}
+	}
+	for _, path := range sortedStringKeys(rule.Rename) {
+		if transformRename(strings.Split(path, "."), rule.Rename[path], resource.Item) {
+			changes = append(changes, "rename "+path+" to "+rule.Rename[path])
+		}
+	}
+	for _, old := range sortedStringKeys(rule.Replace) {
+		if replaced := transformReplace(old, rule.Replace[old], resource.Item); replaced > 0 {
+			changes = append(changes, fmt.Sprintf("replace %q with %q in %d values", old, rule.Replace[old], replaced))
+		}
+	}
+	if rule.ResourceName != "" {
+		name := transformResourceName(rule.ResourceName, resource)
+		if name != resource.ResourceName {
+			changes = append(changes, "rename resource to "+name)
+			resource.ResourceName = name
+			resource.InstanceInfo.Id = resource.InstanceInfo.Type + "." + name
+		}
+	}
+	// resources are already converted, so values matching ignore keys are removed from the item,
+	// the keys are kept for resources converted again, e.g. by regenerate
+	for _, key := range rule.IgnoreKeys {
+		if !terraformerstring.ContainsString(resource.IgnoreKeys, key) {
+			resource.IgnoreKeys = append(resource.IgnoreKeys, key)
+		}
+		if _, removed := transformIgnore(regexp.MustCompile(key), "", resource.Item); removed > 0 {
+			changes = append(changes, fmt.Sprintf("ignore %s in %d values", key, removed))
+		}
+	}
+	return changes
+}
+
+// transformIgnore removes values with flatmap paths, like ebs_block_device.0.device_name, matching pattern.
+// Lists can't be changed in place, so data is returned without the removed elements.
+func transformIgnore(pattern *regexp.Regexp, prefix string, data interface{}) (interface{}, int) {
+	removed := 0
+	switch data := data.(type) {
+	case []interface{}:
+		var elements []interface{}
+		for i, element := range data {
+			path := prefix + strconv.Itoa(i)
+			if pattern.MatchString(path) {
+				removed++
+				continue
+			}
+			element, elementRemoved := transformIgnore(pattern, path+".", element)
+			removed += elementRemoved
+			if elementRemoved > 0 && isEmptyTransformValue(element) {
+				continue
+			}
+			elements = append(elements, element)
+		}
+		return elements, removed
+	case map[string]interface{}:
+		for key, element := range data {
+			path := prefix + key
+			if pattern.MatchString(path) {
+				delete(data, key)
+				removed++
+				continue
+			}
+			element, elementRemoved := transformIgnore(pattern, path+".", element)
+			if elementRemoved == 0 {
+				continue
+			}
+			removed += elementRemoved
+			if isEmptyTransformValue(element) {
+				delete(data, key)
+			} else {
+				data[key] = element
+			}
+		}
+	}
+	return data, removed
+}
+
+// isEmptyTransformValue reports lists and maps left empty by transformIgnore, like empty values are left out by conversion
+func isEmptyTransformValue(value interface{}) bool {
+	switch value := value.(type) {
+	case []interface{}:
+		return len(value) == 0
+	case map[string]interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+func transformSet(path []string, value interface{}, data interface{}) bool {
+	switch data := data.(type) {
+	case []interface{}:
+		changed := false
+		for _, element := range data {
+			changed = transformSet(path, value, element) || changed
+		}
+		return changed
+	case map[string]interface{}:
+		if len(path) == 1 {
+			if current, exist := data[path[0]]; exist && fmt.Sprint(current) == fmt.Sprint(value) {
+				return false
+			}
+			data[path[0]] = value
+			return true
+		}
+		if _, exist := data[path[0]]; !exist {
+			data[path[0]] = map[string]interface{}{}
+		}
+		return transformSet(path[1:], value, data[path[0]])
+	}
+	return false
+}
+
+// transformDelete removes path, or only values equal to onlyValue if it's set
+func transformDelete(path []string, onlyValue *string, data interface{}) bool {
+	switch data := data.(type) {
+	case []interface{}:
+		changed := false
+		for _, element := range data {
+			changed = transformDelete(path, onlyValue, element) || changed
+		}
+		return changed
+	case map[string]interface{}:
+		current, exist := data[path[0]]
+		if !exist {
+			return false
+		}
+		if len(path) > 1 {
+			return transformDelete(path[1:], onlyValue, current)
+		}
+		if onlyValue != nil && fmt.Sprint(current) != *onlyValue {
+			return false
+		}
+		delete(data, path[0])
+		return true
+	}
+	return false
+}
+
+func transformRename(path []string, newName string, data interface{}) bool {
+	switch data := data.(type) {
+	case []interface{}:
+		changed := false
+		for _, element := range data {
+			changed = transformRename(path, newName, element) || changed
+		}
+		return changed
+	case map[string]interface{}:
+		current, exist := data[path[0]]
+		if !exist {
+			return false
+		}
+		if len(path) > 1 {
+			return transformRename(path[1:], newName, current)
+		}
+		delete(data, path[0])
+		data[newName] = current
+		return true
+	}
+	return false
+}
+
+func transformReplace(old, new string, data interface{}) int {
+	replaced := 0
+	switch data := data.(type) {
+	case []interface{}:
+		for i, element := range data {
+			if value, ok := element.(string); ok {
+				if strings.Contains(value, old) {
+					data[i] = strings.ReplaceAll(value, old, new)
+					replaced++
+				}
+				continue
+			}
+			replaced += transformReplace(old, new, element)
+		}
+	case map[string]interface{}:
+		for key, element := range data {
+			if value, ok := element.(string); ok {
+				if strings.Contains(value, old) {
+					data[key] = strings.ReplaceAll(value, old, new)
+					replaced++
+				}
+				continue
+			}
+			replaced += transformReplace(old, new, element)
+		}
+	}
+	return replaced
+}
+
+func transformResourceName(template string, resource *Resource) string {
+	name := resourceNamePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value := ""
+		switch key := strings.Trim(placeholder, "{}"); key {
+		case "name":
+			// already sanitized
+			return strings.TrimPrefix(resource.ResourceName, "tfer--")
+		case "id":
+			value = resourceID(resource)
+		case "type":
+			value = resource.InstanceInfo.Type
+		default:
+			if values := WalkAndGet(strings.TrimPrefix(key, "attr:"), resource.Item); len(values) > 0 {
+				value = fmt.Sprint(values[0])
+			}
+		}
+		return unsafeChars.ReplaceAllStringFunc(value, escapeRune)
+	})
+	// resource names must start with a letter or underscore
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || name[0] == '-' {
+		name = "tfer--" + name
+	}
+	return name
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,131 @@
+package terraformutils
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTransformResources(t *testing.T) {
+	rulesPath := filepath.Join(t.TempDir(), "rules.json")
+	err := ioutil.WriteFile(rulesPath, []byte(`{"rules": [
+		{
+			"name": "mandatory tags",
+			"types": ["aws_*"],
+			"filter": ["Name=tags.env;Value=prod"],
+			"set": {"tags.owner": "platform"},
+			"rename": {"tags.Team": "team"}
+		},
+		{
+			"name": "cleanup",
+			"types": ["aws_instance"],
+			"delete": ["credit_specification"],
+			"delete_defaults": {"monitoring": false, "ebs_block_device.delete_on_termination": true},
+			"replace": {"123456789012": "${var.account_id}"},
+			"resource_name": "{attr:tags.Name}-{id}",
+			"ignore_keys": ["^arn$"]
+		}
+	]}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadTransformRules(rulesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prod := NewSimpleResource("i-1", "web", "aws_instance", "aws", []string{})
+	prod.Item = map[string]interface{}{
+		"tags":                 map[string]interface{}{"env": "prod", "Name": "web server", "Team": "a"},
+		"monitoring":           false,
+		"arn":                  "arn:aws:ec2:us-east-1:123456789012:instance/i-1",
+		"iam_instance_profile": "arn:aws:iam::123456789012:instance-profile/web",
+		"credit_specification": []interface{}{map[string]interface{}{"cpu_credits": "standard"}},
+		"ebs_block_device": []interface{}{
+			map[string]interface{}{"device_name": "/dev/sdb", "delete_on_termination": true},
+			map[string]interface{}{"device_name": "/dev/sdc", "delete_on_termination": false},
+		},
+	}
+	dev := NewSimpleResource("vpc-1", "dev", "aws_vpc", "aws", []string{})
+	dev.Item = map[string]interface{}{"tags": map[string]interface{}{"env": "dev"}}
+	resources := map[string][]Resource{"ec2_instance": {prod}, "vpc": {dev}}
+
+	changes, err := TransformResources(resources, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 8 {
+		t.Errorf("wrong number of changes %d: %v", len(changes), changes)
+	}
+	if changes[2].String() != "cleanup: aws_instance.tfer--web delete credit_specification" {
+		t.Errorf("wrong change %s", changes[2])
+	}
+
+	expectedItem := map[string]interface{}{
+		"tags":                 map[string]interface{}{"env": "prod", "Name": "web server", "owner": "platform", "team": "a"},
+		"iam_instance_profile": "arn:aws:iam::${var.account_id}:instance-profile/web",
+		"ebs_block_device": []interface{}{
+			map[string]interface{}{"device_name": "/dev/sdb"},
+			map[string]interface{}{"device_name": "/dev/sdc", "delete_on_termination": false},
+		},
+	}
+	transformed := resources["ec2_instance"][0]
+	if !reflect.DeepEqual(transformed.Item, expectedItem) {
+		t.Errorf("wrong transformed item %v", transformed.Item)
+	}
+	if transformed.ResourceName != "web-0020-server-i-002D-1" || transformed.InstanceInfo.Id != "aws_instance.web-0020-server-i-002D-1" {
+		t.Errorf("wrong resource name %s", transformed.ResourceName)
+	}
+	if !reflect.DeepEqual(transformed.IgnoreKeys, []string{"^arn$"}) {
+		t.Errorf("wrong ignore keys %v", transformed.IgnoreKeys)
+	}
+	if !reflect.DeepEqual(resources["vpc"][0].Item, map[string]interface{}{"tags": map[string]interface{}{"env": "dev"}}) {
+		t.Errorf("resource not matching filter was changed %v", resources["vpc"][0].Item)
+	}
+}
+
+func TestTransformResourceNameCollision(t *testing.T) {
+	rules := []TransformRule{{Name: "names", Types: []string{"aws_instance"}, ResourceName: "{attr:tags.Name}"}}
+	first := NewSimpleResource("i-1", "first", "aws_instance", "aws", []string{})
+	first.Item = map[string]interface{}{"tags": map[string]interface{}{"Name": "web"}}
+	second := NewSimpleResource("i-2", "second", "aws_instance", "aws", []string{})
+	second.Item = map[string]interface{}{"tags": map[string]interface{}{"Name": "web"}}
+	vpc := NewSimpleResource("vpc-1", "web", "aws_vpc", "aws", []string{})
+	resources := map[string][]Resource{"ec2_instance": {first, second}, "vpc": {vpc}}
+
+	changes, err := TransformResources(resources, rules)
+	if err == nil {
+		t.Fatal("expected error of resources renamed to the same address")
+	}
+	if !strings.Contains(err.Error(), "aws_instance.web (aws_instance.tfer--first, aws_instance.tfer--second)") {
+		t.Errorf("wrong error %v", err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("wrong number of changes %d: %v", len(changes), changes)
+	}
+
+	// resource not matching the filter keeps its name
+	rules, err = LoadTransformRules(writeTransformRules(t, `{"rules": [
+		{"types": ["aws_instance"], "filter": ["Name=tags.Name;Value=web"], "resource_name": "{attr:tags.Name}"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := NewSimpleResource("i-3", "web", "aws_instance", "aws", []string{})
+	other.ResourceName = "web"
+	other.Item = map[string]interface{}{"tags": map[string]interface{}{"Name": "other"}}
+	resources = map[string][]Resource{"ec2_instance": {first, other}}
+	if _, err := TransformResources(resources, rules); err == nil {
+		t.Error("expected error of resource renamed to the address of another resource")
+	}
+}
+
+func writeTransformRules(t *testing.T, rules string) string {
+	rulesPath := filepath.Join(t.TempDir(), "rules.json")
+	if err := ioutil.WriteFile(rulesPath, []byte(rules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return rulesPath
+}
@@ -16,11 +16,24 @@ package terraformutils
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/GoogleCloudPlatform/terraformer/terraformutils/providerwrapper"
+	"github.com/GoogleCloudPlatform/terraformer/terraformutils/telemetry"
 
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs/hcl2shim"
+	"github.com/hashicorp/terraform/states"
+	"github.com/hashicorp/terraform/states/statefile"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -33,6 +46,7 @@ func NewTfState(resources []Resource) *terraform.State {
 		Version:   terraform.StateVersion,
 		TFVersion: terraform.VersionString(), //nolint
 		Serial:    1,
+		Lineage:   stateLineage(resources),
 	}
 	outputs := map[string]*terraform.OutputState{}
 	for _, r := range resources {
@@ -51,7 +65,7 @@ func NewTfState(resources []Resource) *terraform.State {
 		resourceState := &terraform.ResourceState{
 			Type:     resource.InstanceInfo.Type,
 			Primary:  resource.InstanceState,
-			Provider: "provider." + resource.Provider,
+			Provider: "provider." + resource.ProviderAddress(),
 		}
 		tfstate.Modules[0].Resources[resource.InstanceInfo.Type+"."+resource.ResourceName] = resourceState
 	}
@@ -59,13 +73,98 @@ func NewTfState(resources []Resource) *terraform.State {
 }
 
 func PrintTfState(resources []Resource) ([]byte, error) {
+	return PrintTfStateWithPrevious(resources, nil)
+}
+
+// PrintTfStateWithPrevious keeps lineage and serial of previously generated state,
+// serial is increased only if the state changed, so unchanged resources give identical state file
+func PrintTfStateWithPrevious(resources []Resource, previousState []byte) ([]byte, error) {
+	previous := struct {
+		Lineage string `json:"lineage"`
+		Serial  int64  `json:"serial"`
+	}{}
+	if len(previousState) == 0 || json.Unmarshal(previousState, &previous) != nil || previous.Lineage == "" {
+		return printTfState(resources, stateLineage(resources), 1)
+	}
+	state, err := printTfState(resources, previous.Lineage, previous.Serial)
+	if err != nil || bytes.Equal(state, previousState) {
+		return state, err
+	}
+	return printTfState(resources, previous.Lineage, previous.Serial+1)
+}
+
+func printTfState(resources []Resource, lineage string, serial int64) ([]byte, error) {
+	for _, resource := range resources {
+		if resource.CollapsedName != "" {
+			return printTfStateV4(resources, lineage, serial)
+		}
+	}
 	state := NewTfState(resources)
+	state.Lineage = lineage
+	state.Serial = serial
 	var buf bytes.Buffer
 	err := terraform.WriteState(state, &buf)
 	return buf.Bytes(), err
 }
 
-func RefreshResources(resources []*Resource, provider *providerwrapper.ProviderWrapper, slowProcessingResources [][]*Resource) ([]*Resource, error) {
+// stateLineage is derived from resources instead of random UUID, so the same resources give the same state
+func stateLineage(resources []Resource) string {
+	var addresses []string
+	for _, resource := range resources {
+		address := resource.Address()
+		if resource.InstanceState != nil {
+			address += "=" + resource.InstanceState.ID
+		}
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	h := sha256.Sum256([]byte(strings.Join(addresses, "\n")))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// State v3 can't hold for_each instances, so collapsed resources are written in v4 format
+func printTfStateV4(resources []Resource, lineage string, serial int64) ([]byte, error) {
+	state := states.NewState()
+	module := state.RootModule()
+	for _, resource := range resources {
+		for k, v := range resource.Outputs {
+			module.SetOutputValue(k, hcl2shim.HCL2ValueFromConfigValue(v.Value), v.Sensitive)
+		}
+		name := resource.ResourceName
+		var key addrs.InstanceKey = addrs.NoKey
+		if resource.CollapsedName != "" {
+			name = resource.CollapsedName
+			key = addrs.StringKey(resource.ResourceName)
+		}
+		schemaVersion := 0
+		if v, ok := resource.InstanceState.Meta["schema_version"]; ok {
+			schemaVersion, _ = strconv.Atoi(fmt.Sprint(v))
+		}
+		attributes := map[string]string{}
+		for k, v := range resource.InstanceState.Attributes {
+			attributes[k] = v
+		}
+		attributes["id"] = resource.InstanceState.ID
+		providerConfig := addrs.NewDefaultProviderConfig(resource.Provider)
+		providerConfig.Alias = resource.ProviderAlias
+		module.SetResourceInstanceCurrent(addrs.Resource{
+			Mode: addrs.ManagedResourceMode,
+			Type: resource.InstanceInfo.Type,
+			Name: name,
+		}.Instance(key), &states.ResourceInstanceObjectSrc{
+			Status:        states.ObjectReady,
+			SchemaVersion: uint64(schemaVersion),
+			AttrsFlat:     attributes,
+		}, providerConfig.Absolute(addrs.RootModuleInstance))
+	}
+	var buf bytes.Buffer
+	err := statefile.Write(statefile.New(state, lineage, uint64(serial)), &buf)
+	return buf.Bytes(), err
+}
+
+// RefreshResources refreshes resources with a pool of workers, refreshed is called after each resource if it's set
+func RefreshResources(ctx context.Context, resources []*Resource, provider *providerwrapper.ProviderWrapper, slowProcessingResources [][]*Resource,
+	refreshed func(resource *Resource, retries int, err error)) ([]*Resource, error) {
 	refreshedResources := []*Resource{}
 	input := make(chan *Resource, len(resources))
 	var wg sync.WaitGroup
@@ -77,7 +176,7 @@ func RefreshResources(resources []*Resource, provider *providerwrapper.ProviderW
 	close(input)
 
 	for i := 0; i < poolSize; i++ {
-		go RefreshResourceWorker(input, &wg, provider)
+		go RefreshResourceWorker(ctx, input, &wg, provider, refreshed)
 	}
 
 	spInputs := []chan *Resource{}
@@ -91,10 +190,13 @@ func RefreshResources(resources []*Resource, provider *providerwrapper.ProviderW
 
 	for i := 0; i < len(spInputs); i++ {
 		wg.Add(len(slowProcessingResources[i]))
-		go RefreshResourceWorker(spInputs[i], &wg, provider)
+		go RefreshResourceWorker(ctx, spInputs[i], &wg, provider, refreshed)
 	}
 
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	for _, r := range resources {
 		if r.InstanceState != nil && r.InstanceState.ID != "" {
 			refreshedResources = append(refreshedResources, r)
@@ -116,7 +218,8 @@ func RefreshResources(resources []*Resource, provider *providerwrapper.ProviderW
 	return refreshedResources, nil
 }
 
-func RefreshResourcesByProvider(providersMapping *ProvidersMapping, providerWrapper *providerwrapper.ProviderWrapper) error {
+// RefreshResourcesByProvider refreshes resources of all services, progress receives refreshing and refreshed events if it's set
+func RefreshResourcesByProvider(ctx context.Context, providersMapping *ProvidersMapping, providerWrapper *providerwrapper.ProviderWrapper, progress ProgressFunc) error {
 	allResources := providersMapping.ShuffleResources()
 	slowProcessingResources := make(map[ProviderGenerator][]*Resource)
 	regularResources := []*Resource{}
@@ -138,7 +241,22 @@ func RefreshResourcesByProvider(providersMapping *ProvidersMapping, providerWrap
 		spResourcesList = append(spResourcesList, slowProcessingResources[p])
 	}
 
-	refreshedResources, err := RefreshResources(regularResources, providerWrapper, spResourcesList)
+	var refreshed func(resource *Resource, retries int, err error)
+	if progress != nil {
+		for service, resources := range providersMapping.GetResourcesByService() {
+			progress(ProgressEvent{Stage: ProgressRefreshing, Service: service, Resources: len(resources)})
+		}
+		refreshed = func(resource *Resource, retries int, err error) {
+			progress(ProgressEvent{
+				Stage:     ProgressRefreshed,
+				Service:   providersMapping.ServiceOf(resource),
+				Resources: 1,
+				Retries:   retries,
+				Err:       err,
+			})
+		}
+	}
+	refreshedResources, err := RefreshResources(ctx, regularResources, providerWrapper, spResourcesList, refreshed)
 	if err != nil {
 		return err
 	}
@@ -147,10 +265,30 @@ func RefreshResourcesByProvider(providersMapping *ProvidersMapping, providerWrap
 	return nil
 }
 
-func RefreshResourceWorker(input chan *Resource, wg *sync.WaitGroup, provider *providerwrapper.ProviderWrapper) {
+func RefreshResourceWorker(ctx context.Context, input chan *Resource, wg *sync.WaitGroup, provider *providerwrapper.ProviderWrapper,
+	refreshed func(resource *Resource, retries int, err error)) {
 	for r := range input {
+		// remaining resources are skipped once the import is cancelled
+		if ctx.Err() != nil {
+			wg.Done()
+			continue
+		}
 		log.Println("Refreshing state...", r.InstanceInfo.Id)
-		r.Refresh(provider)
+		refreshCtx, span := telemetry.Start(ctx, "refresh "+r.InstanceInfo.Type,
+			telemetry.TypeKey.String(r.InstanceInfo.Type), telemetry.IDKey.String(r.InstanceState.ID))
+		start := time.Now()
+		retries, err := r.refresh(provider)
+		if err != nil {
+			log.Println(err)
+		} else if r.InstanceState == nil || r.InstanceState.ID == "" {
+			err = fmt.Errorf("unable to refresh resource %s", r.InstanceInfo.Id)
+		}
+		telemetry.RecordRefresh(refreshCtx, r.InstanceInfo.Type, time.Since(start), err != nil)
+		telemetry.RecordRetries(refreshCtx, r.InstanceInfo.Type, retries)
+		telemetry.End(span, err)
+		if refreshed != nil {
+			refreshed(r, retries, err)
+		}
 		wg.Done()
 	}
 }
@@ -0,0 +1,43 @@
+package terraformutils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintTfStateWithPrevious(t *testing.T) {
+	resources := []Resource{NewSimpleResource("vhost", "vhost", "rabbitmq_vhost", "rabbitmq", []string{})}
+
+	state, err := PrintTfState(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameState, err := PrintTfState(resources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(state, sameState) {
+		t.Errorf("state of the same resources differs:\n%s\n%s", state, sameState)
+	}
+
+	unchangedState, err := PrintTfStateWithPrevious(resources, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(state, unchangedState) {
+		t.Errorf("unchanged state must keep serial:\n%s", unchangedState)
+	}
+
+	resources[0].InstanceState.Attributes["name"] = "vhost"
+	changedState, err := PrintTfStateWithPrevious(resources, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(changedState), `"serial": 2`) {
+		t.Errorf("changed state must increase serial:\n%s", changedState)
+	}
+	if lineage := stateLineage(resources); !strings.Contains(string(changedState), lineage) {
+		t.Errorf("changed state must keep lineage %s:\n%s", lineage, changedState)
+	}
+}
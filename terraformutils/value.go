@@ -0,0 +1,200 @@
+// Copyright 2021 The Terraformer Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraformutils
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ValueParser converts the typed state value of a resource returned by its provider, unlike
+// FlatmapParser it keeps numbers, bools and dynamic values. Paths of values are matched by
+// IgnoreKeys and AllowEmptyValues patterns the way keys of flatmap attributes are, e.g. ingress.0.self
+type ValueParser struct {
+	value            cty.Value
+	ignoreKeys       []*regexp.Regexp
+	allowEmptyValues []*regexp.Regexp
+}
+
+func NewValueParser(value cty.Value, ignoreKeys []*regexp.Regexp, allowEmptyValues []*regexp.Regexp) *ValueParser {
+	return &ValueParser{
+		value:            value,
+		ignoreKeys:       ignoreKeys,
+		allowEmptyValues: allowEmptyValues,
+	}
+}
+
+// Parse converts the value, types of values come from the value itself so dynamic attributes of ty
+// keep the types they were read with
+func (p *ValueParser) Parse(ty cty.Type) (map[string]interface{}, error) {
+	if p.value == cty.NilVal || p.value.IsNull() {
+		return nil, nil
+	}
+	if !p.value.Type().IsObjectType() {
+		return nil, fmt.Errorf("ValueParser#Parse called on %#v", p.value.Type())
+	}
+	values, err := p.fromValue(nil, p.value)
+	if err != nil || values == nil {
+		return nil, err
+	}
+	return values.(map[string]interface{}), nil
+}
+
+func (p *ValueParser) fromValue(path cty.Path, value cty.Value) (interface{}, error) {
+	value, _ = value.Unmark()
+	if !value.IsKnown() || value.IsNull() {
+		return nil, nil
+	}
+	ty := value.Type()
+	switch {
+	case ty == cty.String:
+		return value.AsString(), nil
+	case ty == cty.Number:
+		return fromNumber(value.AsBigFloat()), nil
+	case ty == cty.Bool:
+		return value.True(), nil
+	case ty.IsObjectType():
+		values := map[string]interface{}{}
+		for name, attributeValue := range value.AsValueMap() {
+			attributePath := path.GetAttr(name)
+			key := pathKey(attributePath)
+			if p.isIgnored(key) {
+				continue
+			}
+			converted, err := p.fromValue(attributePath, attributeValue)
+			if err != nil {
+				return nil, err
+			}
+			if p.isValueAllowed(converted, key) {
+				values[name] = converted
+			}
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return values, nil
+	case ty.IsMapType():
+		values := map[string]interface{}{}
+		for it := value.ElementIterator(); it.Next(); {
+			key, element := it.Element()
+			elementPath := path.Index(key)
+			if p.isIgnored(pathKey(elementPath)) {
+				continue
+			}
+			converted, err := p.fromValue(elementPath, element)
+			if err != nil {
+				return nil, err
+			}
+			if p.isValueAllowed(converted, pathKey(path)+".") {
+				values[key.AsString()] = converted
+			}
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return values, nil
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		var values []interface{}
+		i := 0
+		for it := value.ElementIterator(); it.Next(); i++ {
+			_, element := it.Element()
+			elementPath := path.Index(cty.NumberIntVal(int64(i)))
+			if p.isIgnored(pathKey(elementPath)) {
+				continue
+			}
+			converted, err := p.fromValue(elementPath, element)
+			if err != nil {
+				return nil, err
+			}
+			if p.isValueAllowed(converted, pathKey(path)+".") {
+				values = append(values, converted)
+			}
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %s of %s", ty.FriendlyName(), pathKey(path))
+	}
+}
+
+// fromNumber returns integers as int64, so they are printed without exponent
+func fromNumber(number *big.Float) interface{} {
+	if number.IsInt() {
+		if i, accuracy := number.Int64(); accuracy == big.Exact {
+			return i
+		}
+	}
+	f, _ := number.Float64()
+	return f
+}
+
+// pathKey returns key of flatmap attribute at path, like nested.0.attribute
+func pathKey(path cty.Path) string {
+	keys := make([]string, 0, len(path))
+	for _, step := range path {
+		switch step := step.(type) {
+		case cty.GetAttrStep:
+			keys = append(keys, step.Name)
+		case cty.IndexStep:
+			if step.Key.Type() == cty.Number {
+				i, _ := step.Key.AsBigFloat().Int64()
+				keys = append(keys, strconv.FormatInt(i, 10))
+			} else {
+				keys = append(keys, step.Key.AsString())
+			}
+		}
+	}
+	return strings.Join(keys, ".")
+}
+
+func (p *ValueParser) isIgnored(key string) bool {
+	for _, pattern := range p.ignoreKeys {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// isValueAllowed drops null values, empty collections and empty strings, which are kept only
+// if key matches AllowEmptyValues. Unlike flatmap strings, false and 0 are values.
+func (p *ValueParser) isValueAllowed(value interface{}, key string) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case []interface{}:
+		return len(value) > 0
+	case map[string]interface{}:
+		return len(value) > 0
+	case string:
+		if value != "" {
+			return true
+		}
+		for _, pattern := range p.allowEmptyValues {
+			if pattern.MatchString(key) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
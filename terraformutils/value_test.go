@@ -0,0 +1,88 @@
+package terraformutils
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestValueParserKeepsTypes(t *testing.T) {
+	value := cty.ObjectVal(map[string]cty.Value{
+		"name":        cty.StringVal("queue"),
+		"durable":     cty.True,
+		"auto_delete": cty.False,
+		"port":        cty.NumberIntVal(5672),
+		"ratio":       cty.NumberFloatVal(0.5),
+		"zero":        cty.Zero,
+		"description": cty.StringVal(""),
+		"comment":     cty.NullVal(cty.String),
+		"arguments": cty.ObjectVal(map[string]cty.Value{
+			"x-max-length": cty.NumberIntVal(10),
+			"x-lazy":       cty.True,
+		}),
+		"tags":  cty.MapValEmpty(cty.String),
+		"ports": cty.ListVal([]cty.Value{cty.NumberIntVal(80), cty.NumberIntVal(443)}),
+	})
+
+	result, err := NewValueParser(value, nil, nil).Parse(value.Type())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"name":        "queue",
+		"durable":     true,
+		"auto_delete": false,
+		"port":        int64(5672),
+		"ratio":       0.5,
+		"zero":        int64(0),
+		"arguments": map[string]interface{}{
+			"x-max-length": int64(10),
+			"x-lazy":       true,
+		},
+		"ports": []interface{}{int64(80), int64(443)},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("failed to convert %v, got %v", expected, result)
+	}
+}
+
+func TestValueParserPaths(t *testing.T) {
+	value := cty.ObjectVal(map[string]cty.Value{
+		"attribute": cty.StringVal("value1"),
+		"nested": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"attribute": cty.StringVal("value2"),
+				"id":        cty.StringVal("id"),
+			}),
+		}),
+		"tags": cty.MapVal(map[string]cty.Value{
+			"empty": cty.StringVal(""),
+		}),
+		"labels": cty.MapVal(map[string]cty.Value{
+			"empty": cty.StringVal(""),
+		}),
+	})
+
+	ignoreKeys := []*regexp.Regexp{
+		regexp.MustCompile(`^attribute$`),
+		regexp.MustCompile(`^nested\.[0-9]+\.id$`),
+	}
+	allowEmptyValues := []*regexp.Regexp{
+		regexp.MustCompile(`^tags\.`),
+	}
+	result, err := NewValueParser(value, ignoreKeys, allowEmptyValues).Parse(value.Type())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"nested": []interface{}{
+			map[string]interface{}{"attribute": "value2"},
+		},
+		"tags": map[string]interface{}{"empty": ""},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("failed to convert %v, got %v", expected, result)
+	}
+}
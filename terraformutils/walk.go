@@ -79,16 +79,28 @@ func walkAndGet(path string, data interface{}) (bool, []interface{}) {
 		return true, []interface{}{val.Interface()}
 	}
 
+	// typed values of state are returned like flatmap strings
+	switch val.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		if path == "" {
+			return true, []interface{}{fmt.Sprint(val.Interface())}
+		}
+	}
+
 	return false, []interface{}{}
 }
 
-func walkAndOverride(pathSegments []string, oldValue, newValue string, data interface{}) {
+// walkAndOverride returns true if any value was overridden
+func walkAndOverride(pathSegments []string, oldValue, newValue string, data interface{}) bool {
+	overridden := false
 	val := reflect.ValueOf(data)
 	switch {
 	case isArray(val.Interface()):
 		for i := 0; i < val.Len(); i++ {
 			arrayValue := val.Index(i).Interface()
-			walkAndOverride(pathSegments, oldValue, newValue, arrayValue)
+			if walkAndOverride(pathSegments, oldValue, newValue, arrayValue) {
+				overridden = true
+			}
 		}
 	case len(pathSegments) == 1:
 		if val.Kind() == reflect.Map {
@@ -99,8 +111,10 @@ func walkAndOverride(pathSegments []string, oldValue, newValue string, data inte
 					case isArray(v.Interface()):
 						valss := v.Interface().([]interface{})
 						for idx, currentValue := range valss {
-							if oldValue == currentValue.(string) {
+							// elements of lists may be numbers, booleans or objects
+							if value, ok := currentValue.(string); ok && oldValue == value {
 								valss[idx] = newValue
+								overridden = true
 							}
 						}
 					case isStringArray(v.Interface()):
@@ -108,10 +122,12 @@ func walkAndOverride(pathSegments []string, oldValue, newValue string, data inte
 						for idx, currentValue := range valss {
 							if oldValue == currentValue {
 								valss[idx] = newValue
+								overridden = true
 							}
 						}
 					case oldValue == fmt.Sprint(v.Interface()):
 						val.Interface().(map[string]interface{})[pathSegments[0]] = newValue
+						overridden = true
 					}
 				}
 			}
@@ -120,10 +136,13 @@ func walkAndOverride(pathSegments []string, oldValue, newValue string, data inte
 		for _, e := range val.MapKeys() {
 			v := val.MapIndex(e)
 			if e.String() == pathSegments[0] {
-				walkAndOverride(pathSegments[1:], oldValue, newValue, v.Interface())
+				if walkAndOverride(pathSegments[1:], oldValue, newValue, v.Interface()) {
+					overridden = true
+				}
 			}
 		}
 	}
+	return overridden
 }
 
 func isArray(val interface{}) bool { // Go reflect lib can't sometimes detect given value is array
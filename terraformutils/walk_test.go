@@ -115,6 +115,29 @@ func TestSimpleArrayWalkAndOverride(t *testing.T) {
 	}
 }
 
+func TestMixedArrayWalkAndOverride(t *testing.T) {
+	structure := map[string]interface{}{
+		"ports": []interface{}{int64(80), true, map[string]interface{}{"port": "443"}, "443"},
+	}
+	WalkAndOverride("ports", "443", "${var.port}", structure)
+
+	expected := []interface{}{int64(80), true, map[string]interface{}{"port": "443"}, "${var.port}"}
+	if !reflect.DeepEqual(structure["ports"], expected) {
+		t.Errorf("failed to set value %v", structure["ports"])
+	}
+}
+
+func TestNumberArrayWalkAndNotOverride(t *testing.T) {
+	structure := map[string]interface{}{
+		"ports": []interface{}{int64(80), int64(443)},
+	}
+	WalkAndOverride("ports", "80", "newValue", structure)
+
+	if !reflect.DeepEqual(structure["ports"], []interface{}{int64(80), int64(443)}) {
+		t.Errorf("numbers were changed %v", structure["ports"])
+	}
+}
+
 func TestSimpleWalkAndNotOverride(t *testing.T) {
 	structure := map[string]interface{}{
 		"attr1": "value",